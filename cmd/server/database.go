@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/vinofsteel/grpc-management/internal/changefeed"
+	"github.com/vinofsteel/grpc-management/internal/config"
+	"github.com/vinofsteel/grpc-management/internal/database"
+	"github.com/vinofsteel/grpc-management/internal/database/cache"
+	"github.com/vinofsteel/grpc-management/internal/database/sql/postgres"
+	"github.com/vinofsteel/grpc-management/internal/database/sql/sqlite"
+	"github.com/vinofsteel/grpc-management/pkg"
+)
+
+// The functions below pick the implementation of the configured database driver, validated to be postgres or sqlite
+
+func newDatabaseProvider(cfg config.DatabaseConfig) pkg.DBProvider {
+	if cfg.Driver == "sqlite" {
+		return sqlite.NewSQLiteDatabaseProvider(cfg)
+	}
+
+	return postgres.NewPostgresDatabaseProvider(cfg)
+}
+
+func newQueries(ctx context.Context, cfg config.DatabaseConfig, provider pkg.DBProvider) (database.Queries, error) {
+	if cfg.Driver == "sqlite" {
+		return sqlite.NewSQLiteQueries(ctx, provider)
+	}
+
+	return postgres.NewPSQLQueries(ctx, provider)
+}
+
+func newMigrator(cfg config.DatabaseConfig, db *sql.DB) (*goose.Provider, error) {
+	if cfg.Driver == "sqlite" {
+		return sqlite.NewMigrator(db)
+	}
+
+	return postgres.NewMigrator(db)
+}
+
+func migrateUp(ctx context.Context, cfg config.DatabaseConfig, db *sql.DB) error {
+	if cfg.Driver == "sqlite" {
+		return sqlite.MigrateUp(ctx, db)
+	}
+
+	return postgres.MigrateUp(ctx, db)
+}
+
+// watchReplicas health checks the Postgres read replicas until ctx is done
+func watchReplicas(ctx context.Context, provider pkg.DBProvider, interval time.Duration) {
+	if p, ok := provider.(*postgres.PostgresProvider); ok {
+		p.WatchReplicas(ctx, interval)
+	}
+}
+
+// newCacheBroadcaster shares cache invalidations through Postgres notifications. A SQLite file has a single server,
+// which needs none.
+func newCacheBroadcaster(cfg config.DatabaseConfig, provider pkg.DBProvider) (cache.Broadcaster, error) {
+	if cfg.Driver == "sqlite" {
+		return nil, nil
+	}
+
+	return postgres.NewNotifier(provider)
+}
+
+// newChangeListener announces user changes through Postgres notifications, SQLite's being polled instead
+func newChangeListener(cfg config.DatabaseConfig, provider pkg.DBProvider) (changefeed.Listener, error) {
+	if cfg.Driver == "sqlite" {
+		return nil, nil
+	}
+
+	return postgres.NewNotifier(provider)
+}
+
+// databaseName labels the connection pool metrics
+func databaseName(cfg config.DatabaseConfig) string {
+	if cfg.Driver == "sqlite" {
+		return cfg.Path
+	}
+
+	return cfg.Name
+}
+
+// validateMigrations checks the migrations of every driver, as it runs without a configuration
+func validateMigrations() error {
+	if err := postgres.ValidateMigrations(); err != nil {
+		return fmt.Errorf("postgres: %w", err)
+	}
+
+	if err := sqlite.ValidateMigrations(); err != nil {
+		return fmt.Errorf("sqlite: %w", err)
+	}
+
+	return nil
+}
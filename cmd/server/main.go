@@ -12,12 +12,29 @@ import (
 	"time"
 
 	"github.com/joho/godotenv"
-	"github.com/vinofsteel/grpc-management/internal/database/sql/postgres"
+	"github.com/vinofsteel/grpc-management/internal/changefeed"
+	"github.com/vinofsteel/grpc-management/internal/concurrency"
+	"github.com/vinofsteel/grpc-management/internal/config"
+	"github.com/vinofsteel/grpc-management/internal/database"
+	"github.com/vinofsteel/grpc-management/internal/database/cache"
+	"github.com/vinofsteel/grpc-management/internal/gateway"
 	"github.com/vinofsteel/grpc-management/internal/handlers"
+	"github.com/vinofsteel/grpc-management/internal/handlers/proto_admin"
 	"github.com/vinofsteel/grpc-management/internal/handlers/proto_user"
+	"github.com/vinofsteel/grpc-management/internal/health"
+	"github.com/vinofsteel/grpc-management/internal/interceptors"
+	"github.com/vinofsteel/grpc-management/internal/metrics"
+	"github.com/vinofsteel/grpc-management/internal/outbox"
+	"github.com/vinofsteel/grpc-management/internal/password"
+	"github.com/vinofsteel/grpc-management/internal/ratelimit"
+	"github.com/vinofsteel/grpc-management/internal/tracing"
+	"github.com/vinofsteel/grpc-management/internal/transport"
 	"github.com/vinofsteel/grpc-management/internal/validation"
+	"github.com/vinofsteel/grpc-management/internal/webhooks"
 	"github.com/vinofsteel/grpc-management/pkg"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func main() {
@@ -25,11 +42,6 @@ func main() {
 	ctx := context.Background()
 	ctx, cancel := context.WithCancel(ctx)
 
-	// Setting up slog
-	pkg.SetupSlog(ctx)
-	daysToKeepLogs := 30
-	go pkg.ScheduleLogRotation(ctx, daysToKeepLogs)
-
 	// Initializing environment variables
 	if os.Getenv("ENV") != "production" {
 		if err := godotenv.Load(); err != nil {
@@ -38,11 +50,48 @@ func main() {
 		}
 	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3000"
+	// Validating the embedded migrations needs neither a database nor its configuration, e.g. in CI
+	subcommand, err := config.RemainingArgs(os.Args[1:])
+	if err != nil {
+		os.Exit(2)
+	}
+	if len(subcommand) == 2 && subcommand[0] == "migrate" && subcommand[1] == "validate" {
+		if err := validateMigrations(); err != nil {
+			slog.ErrorContext(ctx, "Invalid migrations", "error", err)
+			os.Exit(1)
+		}
+		slog.InfoContext(ctx, "Migrations are valid")
+		return
 	}
-	addr := fmt.Sprintf(":%s", port)
+
+	// Loading configuration from defaults, the config file, the environment and flags
+	cfg, err := config.Load(ctx, os.Args[1:])
+	if err != nil {
+		slog.ErrorContext(ctx, "Error loading configuration", "error", err)
+		os.Exit(1)
+	}
+
+	// Setting up slog
+	pkg.SetupSlog(ctx, cfg.Env, cfg.Log.Level)
+
+	if len(subcommand) > 0 {
+		if subcommand[0] != "migrate" {
+			slog.ErrorContext(ctx, "Unknown subcommand", "subcommand", subcommand[0])
+			os.Exit(2)
+		}
+
+		if err := runMigrate(ctx, cfg, subcommand[1:]); err != nil {
+			slog.ErrorContext(ctx, "Error running migrations", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	go pkg.ScheduleLogRotation(ctx, cfg.Log.RetentionDays)
+
+	slog.DebugContext(ctx, "Configuration loaded", "config", cfg)
+
+	addr := fmt.Sprintf(":%d", cfg.Server.Port)
 
 	slog.LogAttrs(
 		ctx,
@@ -63,34 +112,370 @@ func main() {
 		slog.Group("server", slog.String("address", addr)),
 	)
 
-	// Create dependencies for Handlers
-	dbProvider := postgres.NewPostgresDatabaseProvider()
-	psqlQueries, err := postgres.NewPSQLQueries(ctx, dbProvider)
+	// Tracing, exported according to the configured exporter
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		ServiceName: "grpc-management",
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error setting up tracing", "error", err)
+		os.Exit(1)
+	}
+
+	// Create dependencies for Handlers, waiting for the database in case it's still starting
+	dbProvider := newDatabaseProvider(cfg.Database)
+	db, err := pkg.WaitForConnection(ctx, dbProvider, cfg.Database.ConnectTimeout)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting database connection", "error", err)
+		os.Exit(1)
+	}
+
+	if cfg.Database.AutoMigrate {
+		if err := migrateUp(ctx, cfg.Database, db.DB); err != nil {
+			slog.ErrorContext(ctx, "Error running migrations", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	go watchReplicas(ctx, dbProvider, cfg.Database.ReplicaCheckInterval)
+
+	queries, err := newQueries(ctx, cfg.Database, dbProvider)
 	if err != nil {
-		slog.ErrorContext(ctx, "Error creating PSQL Queries", "error", err)
+		slog.ErrorContext(ctx, "Error creating Queries", "error", err, "driver", cfg.Database.Driver)
 		os.Exit(1)
 	}
 
-	validationProvider := validation.NewValidateValidationrovider(ctx)
+	// Prometheus metrics, served on their own listener
+	appMetrics := metrics.New()
+
+	if err := appMetrics.RegisterDBStats(db.DB, databaseName(cfg.Database)); err != nil {
+		slog.ErrorContext(ctx, "Error registering database metrics", "error", err)
+		os.Exit(1)
+	}
+
+	usernamePolicy, err := validation.NewUsernamePolicy(ctx, validation.UsernamePolicyConfig{
+		ReservedWords: cfg.Validation.UsernameReservedWords,
+		ProfanityFile: cfg.Validation.UsernameProfanityFile,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating username policy", "error", err)
+		os.Exit(1)
+	}
+
+	emailPolicy, err := validation.NewEmailPolicy(ctx, validation.EmailPolicyConfig{
+		AllowFile:           cfg.Validation.EmailAllowFile,
+		DenyFile:            cfg.Validation.EmailDenyFile,
+		TenantOverridesFile: cfg.Validation.EmailTenantOverridesFile,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating email policy", "error", err)
+		os.Exit(1)
+	}
+	go emailPolicy.Watch(ctx, 30*time.Second)
+
+	validationProvider := validation.NewValidateValidationrovider(ctx,
+		validation.WithUsernamePolicy(usernamePolicy),
+		validation.WithEmailPolicy(emailPolicy),
+	)
+
+	// Password hashing runs on its own bounded pool
+	hasher := password.NewPooledHasher(password.NewBcryptHasher(password.DefaultBcryptCost), password.PoolConfig{
+		Workers:  cfg.Concurrency.PasswordHashWorkers,
+		MaxQueue: 4 * cfg.Concurrency.PasswordHashWorkers,
+	})
+
+	// User lifecycle events, recorded in the outbox along with every change and relayed to the configured publisher
+	// and, when enabled, to the webhook subscriptions, whose deliveries are sent by the dispatcher. Without either the
+	// outbox is disabled, as nothing would relay (and then clean up) the events recorded.
+	if publisher := newOutboxPublisher(cfg, metrics.InstrumentQueries(queries, appMetrics)); publisher != nil {
+		relay := outbox.NewRelay(metrics.InstrumentQueries(queries, appMetrics), publisher, outbox.RelayConfig{
+			PollInterval: cfg.Outbox.PollInterval,
+			Retention:    cfg.Outbox.Retention,
+		})
+		go relay.Run(ctx)
+
+		queries = outbox.NewRecordingQueries(queries)
+	}
+
+	if cfg.Webhooks.Enabled {
+		dispatcher := webhooks.NewDispatcher(metrics.InstrumentQueries(queries, appMetrics), webhooks.DispatcherConfig{
+			PollInterval: cfg.Webhooks.PollInterval,
+			Timeout:      cfg.Webhooks.Timeout,
+			MaxAttempts:  int32(cfg.Webhooks.MaxAttempts),
+		})
+		go dispatcher.Run(ctx)
+	}
+
+	// User lookups are cached in front of the instrumented queries, so the query metrics only count database hits
+	usersQueries := metrics.InstrumentQueries(queries, appMetrics)
+	if cfg.Cache.Size > 0 {
+		broadcaster, err := newCacheBroadcaster(cfg.Database, dbProvider)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error creating cache invalidation broadcaster", "error", err)
+			os.Exit(1)
+		}
+
+		cachedQueries := cache.NewCachedQueries(usersQueries, cache.Config{
+			Size:            cfg.Cache.Size,
+			TTL:             cfg.Cache.TTL,
+			NegativeTTL:     cfg.Cache.NegativeTTL,
+			LoadFromPrimary: cfg.Cache.LoadFromPrimary,
+			LoadTimeout:     cfg.Cache.LoadTimeout,
+		}, broadcaster)
+		go cachedQueries.Run(ctx)
+		usersQueries = cachedQueries
+	}
+
+	// Feed of the changes to the users table, streamed by WatchUsers
+	changeListener, err := newChangeListener(cfg.Database, dbProvider)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating user changes listener", "error", err)
+		os.Exit(1)
+	}
+
+	changeFeed := changefeed.NewFeed(metrics.InstrumentQueries(queries, appMetrics), changeListener, changefeed.Config{
+		PollInterval: cfg.Watch.PollInterval,
+		Retention:    cfg.Watch.Retention,
+	})
+	go changeFeed.Run(ctx)
 
 	handlers := handlers.New(handlers.Config{
-		Queries:   psqlQueries,
-		Validator: validationProvider,
+		Queries:        usersQueries,
+		Validator:      validationProvider,
+		UsernamePolicy: usernamePolicy,
+		EmailPolicy:    emailPolicy,
+		Hasher:         tracing.InstrumentHasher(metrics.InstrumentHasher(hasher, appMetrics)),
+		ChangeFeed:     changeFeed,
 	})
 
-	grpcServer := grpc.NewServer()
+	// Rate limiting, every method is unlimited until a limits file is configured (which can happen on a reload)
+	limits, err := loadRateLimits(cfg.RateLimit.File)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error loading rate limits", "error", err)
+		os.Exit(1)
+	}
+	rateLimitPolicy := ratelimit.NewPolicy(limits)
+
+	var limiter ratelimit.Limiter
+	switch backend := cfg.RateLimit.Backend; backend {
+	case "postgres":
+		limiter, err = ratelimit.NewPostgresLimiter(ctx, dbProvider, time.Minute)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error creating Postgres rate limiter", "error", err)
+			os.Exit(1)
+		}
+	case "memory":
+		limiter = ratelimit.NewMemoryLimiter(ctx, time.Minute)
+	default:
+		slog.ErrorContext(ctx, "Unsupported rate limit backend", "backend", backend)
+		os.Exit(1)
+	}
+
+	rateLimit := &interceptors.RateLimitConfig{
+		Limiter:           limiter,
+		Policy:            rateLimitPolicy,
+		ExemptServices:    []string{healthpb.Health_ServiceDesc.ServiceName},
+		APIKeyDigests:     cfg.RateLimit.APIKeyDigests,
+		TrustedPrincipals: cfg.Auth.TrustedPrincipals,
+	}
+
+	// Concurrency limiting, adjusted from the observed latency
+	concurrencyLimit := &interceptors.ConcurrencyLimitConfig{
+		Limiter: concurrency.NewGradientLimiter(concurrency.Config{MaxLimit: cfg.Concurrency.MaxLimit}),
+		ExemptServices: []string{
+			healthpb.Health_ServiceDesc.ServiceName,
+			proto_admin.AdminService_ServiceDesc.ServiceName,
+		},
+		ExemptMethods: []string{proto_user.UserService_WatchUsers_FullMethodName},
+	}
+
+	// AdminService is only served to the admin principals, never to anonymous or gateway callers
+	authorization := &interceptors.AuthorizationConfig{
+		Services:   []string{proto_admin.AdminService_ServiceDesc.ServiceName},
+		Principals: cfg.Auth.AdminPrincipals,
+	}
+
+	grpcServer := grpc.NewServer(interceptors.Chain(interceptors.Config{
+		Tracing:          true,
+		RequestID:        true,
+		Session:          true,
+		Metrics:          appMetrics,
+		AccessLog:        cfg.Server.AccessLog,
+		Authorization:    authorization,
+		RateLimit:        rateLimit,
+		ConcurrencyLimit: concurrencyLimit,
+		Recovery:         true,
+		Validator:        validationProvider,
+		Tenants: interceptors.TenantConfig{
+			TrustedPrincipals: cfg.Auth.TrustedPrincipals,
+			Principals:        cfg.Auth.TenantsByPrincipal(),
+		},
+	})...)
 	proto_user.RegisterUserServiceServer(grpcServer, handlers)
+	proto_admin.RegisterAdminServiceServer(grpcServer, handlers)
+
+	healthChecker := health.NewChecker(dbProvider, health.Config{
+		Interval:         5 * time.Second,
+		Timeout:          2 * time.Second,
+		DatabaseServices: []string{proto_user.UserService_ServiceDesc.ServiceName},
+		Services:         []string{proto_admin.AdminService_ServiceDesc.ServiceName},
+	})
+	healthpb.RegisterHealthServer(grpcServer, healthChecker.Server())
+	go healthChecker.Run(ctx)
+
+	// gRPC, gRPC-Web and Connect share the same listener, all of them handled by grpcServer
+	// TLS is enabled when a certificate is configured, and mTLS when a client CA bundle is too
+	var serverTLS transport.TLS
+	var gatewayCredentials credentials.TransportCredentials
+	if cfg.TLS.CertFile != "" {
+		serverTLS, err = transport.NewTLS(ctx, transport.TLSConfig{
+			CertFile:          cfg.TLS.CertFile,
+			KeyFile:           cfg.TLS.KeyFile,
+			MinVersion:        cfg.TLS.MinVersion,
+			CipherSuites:      cfg.TLS.CipherSuites,
+			ClientCAFile:      cfg.TLS.ClientCAFile,
+			RequireClientCert: cfg.TLS.RequireClientCert,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Error loading TLS configuration", "error", err)
+			os.Exit(1)
+		}
+		go serverTLS.Watch(ctx, 30*time.Second)
+
+		gatewayCredentials, err = transport.ClientCredentials(transport.ClientTLSConfig{
+			CAFile:   cfg.TLS.GatewayCAFile,
+			CertFile: cfg.TLS.GatewayCertFile,
+			KeyFile:  cfg.TLS.GatewayKeyFile,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Error loading REST gateway TLS configuration", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	apiServer, err := transport.NewServer(grpcServer, transport.Config{
+		Address:            addr,
+		CORSAllowedOrigins: cfg.Server.CORSAllowedOrigins,
+		CORSMaxAge:         2 * time.Hour,
+		TLS:                serverTLS,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating API server", "error", err)
+		os.Exit(1)
+	}
 
 	// Channel to capture server errors
 	serverErrCh := make(chan error, 1)
 
-	// Start gRPC server in a goroutine
+	// Start API server in a goroutine
+	go func() {
+		var err error
+		if serverTLS != nil {
+			err = apiServer.ServeTLS(lis, "", "")
+		} else {
+			err = apiServer.Serve(lis)
+		}
+
+		if err != nil && err != http.ErrServerClosed {
+			serverErrCh <- err
+		}
+	}()
+
+	// REST/JSON gateway, forwarding to the gRPC server above
+	gatewayServer, err := gateway.New(ctx, gateway.Config{
+		Address:         fmt.Sprintf(":%d", cfg.Server.GatewayPort),
+		GRPCAddress:     fmt.Sprintf("localhost:%d", cfg.Server.Port),
+		GRPCCredentials: gatewayCredentials,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating REST gateway", "error", err)
+		os.Exit(1)
+	}
+
+	slog.LogAttrs(
+		ctx,
+		slog.LevelInfo,
+		"Starting REST gateway",
+		slog.Group("server", slog.String("address", gatewayServer.Addr)),
+	)
+
+	go func() {
+		if err := gatewayServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serverErrCh <- err
+		}
+	}()
+
+	metricsServer := metrics.NewServer(metrics.Config{Address: fmt.Sprintf(":%d", cfg.Server.MetricsPort)}, appMetrics)
+
+	slog.LogAttrs(
+		ctx,
+		slog.LevelInfo,
+		"Starting metrics server",
+		slog.Group("server", slog.String("address", metricsServer.Addr)),
+	)
+
 	go func() {
-		if err := grpcServer.Serve(lis); err != nil {
+		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			serverErrCh <- err
 		}
 	}()
 
+	// Live configuration reload, on SIGHUP or when the config file changes
+	reloader, err := config.NewReloader(os.Args[1:], cfg)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating configuration reloader", "error", err)
+		os.Exit(1)
+	}
+
+	reloader.Subscribe("logger", func(ctx context.Context, cfg config.Config) error {
+		return pkg.SetLogLevel(cfg.Env, cfg.Log.Level)
+	})
+	reloader.Subscribe("rate_limit", func(ctx context.Context, cfg config.Config) error {
+		limits, err := loadRateLimits(cfg.RateLimit.File)
+		if err != nil {
+			return err
+		}
+
+		rateLimitPolicy.Update(limits)
+		return nil
+	})
+	reloader.Subscribe("username_policy", func(ctx context.Context, cfg config.Config) error {
+		return usernamePolicy.Reconfigure(ctx, validation.UsernamePolicyConfig{
+			ReservedWords: cfg.Validation.UsernameReservedWords,
+			ProfanityFile: cfg.Validation.UsernameProfanityFile,
+		})
+	})
+	reloader.Subscribe("email_policy", func(ctx context.Context, cfg config.Config) error {
+		return emailPolicy.Reconfigure(ctx, validation.EmailPolicyConfig{
+			AllowFile:           cfg.Validation.EmailAllowFile,
+			DenyFile:            cfg.Validation.EmailDenyFile,
+			TenantOverridesFile: cfg.Validation.EmailTenantOverridesFile,
+		})
+	})
+	reloader.Subscribe("password_pool", func(ctx context.Context, cfg config.Config) error {
+		hasher.Resize(password.PoolConfig{
+			Workers:  cfg.Concurrency.PasswordHashWorkers,
+			MaxQueue: 4 * cfg.Concurrency.PasswordHashWorkers,
+		})
+		return nil
+	})
+	go reloader.Watch(ctx, 30*time.Second)
+
+	reloadCh := make(chan os.Signal, 1)
+	signal.Notify(reloadCh, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-reloadCh:
+				slog.InfoContext(ctx, "Received SIGHUP, reloading configuration")
+				_ = reloader.Reload(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
 	// Handle common termination signals
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -100,7 +485,7 @@ func main() {
 	case sig := <-sigCh:
 		slog.InfoContext(ctx, "Received cancellation signal", "signal", sig)
 	case err := <-serverErrCh:
-		slog.ErrorContext(ctx, "gRPC Server error", "error", err)
+		slog.ErrorContext(ctx, "Server error", "error", err)
 		cancel()
 		os.Exit(1)
 	case <-ctx.Done():
@@ -109,28 +494,72 @@ func main() {
 
 	// Graceful shutdown
 	slog.InfoContext(ctx, "Shutting down gRPC server...")
+	healthChecker.Shutdown(ctx)
 
 	// Create a context with timeout for graceful shutdown
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shutdownCancel()
 
-	// Channel to signal when graceful stop is complete
-	gracefulStopCh := make(chan struct{})
-
-	go func() {
-		grpcServer.GracefulStop()
-		close(gracefulStopCh)
-	}()
+	// The gateway goes first, since its requests are served by the gRPC server
+	if err := gatewayServer.Shutdown(shutdownCtx); err != nil {
+		slog.WarnContext(ctx, "REST gateway shutdown error", "error", err)
+	}
 
-	// Wait for graceful shutdown or timeout
-	select {
-	case <-gracefulStopCh:
+	// Requests are served through HTTP handlers, so draining happens in the HTTP server. Once it's done there's
+	// nothing left for grpcServer to wait on (and GracefulStop isn't supported for handlers served over HTTP)
+	if err := apiServer.Shutdown(shutdownCtx); err != nil {
+		slog.WarnContext(ctx, "Graceful shutdown timeout, forcing stop", "error", err)
+		_ = apiServer.Close()
+	} else {
 		slog.InfoContext(ctx, "gRPC server stopped gracefully")
-	case <-shutdownCtx.Done():
-		slog.WarnContext(ctx, "Graceful shutdown timeout, forcing stop")
-		grpcServer.Stop() // Force stop
+	}
+	grpcServer.Stop()
+
+	// Flush the spans of the calls drained above
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.WarnContext(ctx, "Tracing shutdown error", "error", err)
+	}
+
+	// Metrics go last, so scrapes still see the calls drained above
+	if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+		slog.WarnContext(ctx, "Metrics server shutdown error", "error", err)
 	}
 
 	cancel()
 	slog.InfoContext(ctx, "Application shutdown complete")
 }
+
+// Utilities
+// loadRateLimits reads the limits file, leaving every method unlimited when there's none
+func loadRateLimits(path string) (ratelimit.Limits, error) {
+	if path == "" {
+		return ratelimit.Limits{}, nil
+	}
+
+	return ratelimit.LoadLimits(path)
+}
+
+// newOutboxPublisher returns the publisher of the outbox events, the configured one along with the webhook
+// subscriptions when enabled, or nil when there's none
+func newOutboxPublisher(cfg config.Config, queries database.Queries) outbox.Publisher {
+	var publishers []outbox.Publisher
+	switch cfg.Outbox.Publisher {
+	case "stdout":
+		publishers = append(publishers, outbox.NewStdoutPublisher(os.Stdout))
+	case "webhook":
+		publishers = append(publishers, outbox.NewWebhookPublisher(outbox.WebhookConfig{URL: cfg.Outbox.WebhookURL}))
+	}
+
+	if cfg.Webhooks.Enabled {
+		publishers = append(publishers, webhooks.NewPublisher(queries))
+	}
+
+	switch len(publishers) {
+	case 0:
+		return nil
+	case 1:
+		return publishers[0]
+	default:
+		return outbox.NewMultiPublisher(publishers...)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/vinofsteel/grpc-management/internal/config"
+	"github.com/vinofsteel/grpc-management/pkg"
+)
+
+const migrateUsage = "usage: server [flags] migrate up | down | status | to <version> | validate"
+
+// runMigrate handles the migrate subcommand, running the migrations embedded in the binary
+func runMigrate(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	switch args[0] {
+	case "up", "down", "status", "to":
+	default:
+		return fmt.Errorf("unknown migrate command %q, %s", args[0], migrateUsage)
+	}
+
+	dbProvider := newDatabaseProvider(cfg.Database)
+	defer dbProvider.Close()
+
+	db, err := pkg.WaitForConnection(ctx, dbProvider, cfg.Database.ConnectTimeout)
+	if err != nil {
+		return err
+	}
+
+	migrator, err := newMigrator(cfg.Database, db.DB)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		return migrateUp(ctx, cfg.Database, db.DB)
+	case "down":
+		result, err := migrator.Down(ctx)
+		if err != nil {
+			return fmt.Errorf("error rolling back migration: %w", err)
+		}
+
+		slog.InfoContext(ctx, "Migration rolled back", "migration", result.Source.Path, "duration", result.Duration)
+		return nil
+	case "to":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+
+		target, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid migration version %q: %w", args[1], err)
+		}
+
+		current, err := migrator.GetDBVersion(ctx)
+		if err != nil {
+			return fmt.Errorf("error reading database version: %w", err)
+		}
+
+		if target >= current {
+			results, err := migrator.UpTo(ctx, target)
+			if err != nil {
+				return fmt.Errorf("error applying migrations: %w", err)
+			}
+			for _, result := range results {
+				slog.InfoContext(ctx, "Migration applied", "migration", result.Source.Path, "duration", result.Duration)
+			}
+			return nil
+		}
+
+		results, err := migrator.DownTo(ctx, target)
+		if err != nil {
+			return fmt.Errorf("error rolling back migrations: %w", err)
+		}
+		for _, result := range results {
+			slog.InfoContext(ctx, "Migration rolled back", "migration", result.Source.Path, "duration", result.Duration)
+		}
+		return nil
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("error reading migration status: %w", err)
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "MIGRATION\tSTATE\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "-"
+			if !status.AppliedAt.IsZero() {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\n", status.Source.Path, status.State, appliedAt)
+		}
+		return writer.Flush()
+	}
+
+	return nil
+}
@@ -0,0 +1,236 @@
+// Package changefeed follows the change log of the users table, fanning out every new change to the subscribers of
+// this server, e.g. WatchUsers streams
+package changefeed
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/vinofsteel/grpc-management/internal/database"
+)
+
+// Channel is the channel the users table announces its changes on
+const Channel = "user_changes"
+
+// batchSize is how many changes are read from the log at a time
+const batchSize = 500
+
+// Listener wakes the feed up when changes are announced, see postgres.Notifier
+type Listener interface {
+	Listen(ctx context.Context, channel string, fn func(payload string)) error
+}
+
+type Config struct {
+	// PollInterval is how often the log is read without being woken up. With a Listener it only bounds the delay of
+	// changes whose notification was missed.
+	PollInterval time.Duration
+	// Retention is how long changes are kept in the log for clients catching up, checked every CleanupInterval
+	Retention       time.Duration
+	CleanupInterval time.Duration
+	// BufferSize is how many changes a subscriber can fall behind before being dropped
+	BufferSize int
+}
+
+type Feed struct {
+	queries  database.Queries
+	listener Listener
+	config   Config
+	now      func() time.Time
+	wake     chan struct{}
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	// last is the sequence of the last change fanned out
+	last        int64
+	initialized bool
+}
+
+// Subscription receives the changes fanned out after it was made. Subscribers catching up from the log should
+// subscribe first and skip the changes they already read, so none falls in between.
+type Subscription struct {
+	feed    *Feed
+	changes chan *database.UserChange
+	dropped chan struct{}
+}
+
+// NewFeed follows the change log through queries. listener may be nil, the log being polled then.
+func NewFeed(queries database.Queries, listener Listener, config Config) *Feed {
+	if config.CleanupInterval <= 0 {
+		config.CleanupInterval = time.Hour
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = 1000
+	}
+
+	return &Feed{
+		queries:     queries,
+		listener:    listener,
+		config:      config,
+		now:         time.Now,
+		wake:        make(chan struct{}, 1),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Run follows the log until ctx is done
+func (f *Feed) Run(ctx context.Context) {
+	if f.listener != nil {
+		go func() {
+			// Every notification wakes the feed up, including the empty one telling some may have been missed
+			if err := f.listener.Listen(ctx, Channel, func(string) { f.notify() }); err != nil {
+				slog.ErrorContext(ctx, "Error listening for user changes", "error", err)
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(f.config.PollInterval)
+	defer ticker.Stop()
+
+	var lastCleanup time.Time
+	for {
+		f.poll(ctx)
+
+		if f.now().Sub(lastCleanup) >= f.config.CleanupInterval {
+			f.cleanup(ctx)
+			lastCleanup = f.now()
+		}
+
+		select {
+		case <-f.wake:
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (f *Feed) Subscribe() *Subscription {
+	s := &Subscription{
+		feed:    f,
+		changes: make(chan *database.UserChange, f.config.BufferSize),
+		dropped: make(chan struct{}),
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.subscribers[s] = struct{}{}
+	return s
+}
+
+func (s *Subscription) Changes() <-chan *database.UserChange {
+	return s.changes
+}
+
+// Dropped is closed when the subscriber fell too far behind, no more changes being sent to it
+func (s *Subscription) Dropped() <-chan struct{} {
+	return s.dropped
+}
+
+func (s *Subscription) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+
+	delete(s.feed.subscribers, s)
+}
+
+// poll fans out the changes made since the last one fanned out
+func (f *Feed) poll(ctx context.Context) {
+	if !f.initialize(ctx) {
+		return
+	}
+
+	// Changes are only listed once sequenced, which any feed does for all of them
+	if _, err := f.queries.SequenceUserChanges(ctx); err != nil {
+		slog.ErrorContext(ctx, "Error sequencing user changes", "error", err)
+		return
+	}
+
+	for {
+		f.mu.Lock()
+		last := f.last
+		f.mu.Unlock()
+
+		changes, err := f.queries.ListUserChanges(ctx, database.ListUserChangesParams{AfterSequence: last, Limit: batchSize})
+		if err != nil {
+			slog.ErrorContext(ctx, "Error reading user changes", "error", err, "after_sequence", last)
+			return
+		}
+
+		f.publish(changes)
+
+		if len(changes) < batchSize {
+			return
+		}
+	}
+}
+
+// initialize starts the feed at the newest change of the log, reporting whether it's initialized. Should anyone have
+// subscribed already it starts at the oldest instead, as their catch up may not have read up to the newest.
+func (f *Feed) initialize(ctx context.Context) bool {
+	f.mu.Lock()
+	initialized := f.initialized
+	f.mu.Unlock()
+
+	if initialized {
+		return true
+	}
+
+	bounds, err := f.queries.ListUserChangesBounds(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error reading user changes bounds", "error", err)
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.subscribers) == 0 {
+		f.last = bounds.Last
+	}
+	f.initialized = true
+	return true
+}
+
+func (f *Feed) publish(changes []*database.UserChange) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, change := range changes {
+		for s := range f.subscribers {
+			select {
+			case s.changes <- change:
+			default:
+				close(s.dropped)
+				delete(f.subscribers, s)
+			}
+		}
+		f.last = change.Sequence
+	}
+}
+
+// cleanup deletes the changes older than the retention
+func (f *Feed) cleanup(ctx context.Context) {
+	deleted, err := f.queries.DeleteUserChanges(ctx, database.DeleteUserChangesParams{
+		ChangedBefore: f.now().UTC().Add(-f.config.Retention),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting user changes", "error", err)
+		return
+	}
+
+	if deleted > 0 {
+		slog.InfoContext(ctx, "Deleted user changes past the retention", "count", deleted)
+	}
+}
+
+// Utilities
+// notify wakes the feed up, a pending wake up covering any number of notifications
+func (f *Feed) notify() {
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,75 @@
+package changefeed
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vinofsteel/grpc-management/internal/database"
+	"github.com/vinofsteel/grpc-management/internal/database/memory"
+)
+
+func TestFeed(t *testing.T) {
+	ctx := context.Background()
+	queries := memory.NewMemoryQueries()
+	feed := NewFeed(queries, nil, Config{PollInterval: time.Second, Retention: time.Hour, BufferSize: 2})
+
+	insertUser(t, ctx, queries, "before")
+
+	t.Logf("Running success case: Testing the feed starts at the newest change and fans out the later ones")
+	feed.poll(ctx)
+	first, second := feed.Subscribe(), feed.Subscribe()
+	insertUser(t, ctx, queries, "after")
+	feed.poll(ctx)
+
+	for _, subscription := range []*Subscription{first, second} {
+		change := <-subscription.Changes()
+		assert.Equal(t, int64(2), change.Sequence)
+		assert.Equal(t, "after", change.Username)
+		assert.Empty(t, subscription.Changes())
+	}
+
+	t.Logf("Running failure case: Testing subscribers falling too far behind are dropped")
+	insertUser(t, ctx, queries, "third")
+	insertUser(t, ctx, queries, "fourth")
+	first.Close()
+	feed.poll(ctx)
+
+	assert.Len(t, second.Changes(), 2)
+	select {
+	case <-second.Dropped():
+		t.Error("subscriber within its buffer was dropped")
+	default:
+	}
+
+	insertUser(t, ctx, queries, "fifth")
+	feed.poll(ctx)
+	select {
+	case <-second.Dropped():
+	default:
+		t.Error("subscriber over its buffer wasn't dropped")
+	}
+	assert.Empty(t, feed.subscribers)
+
+	t.Logf("Running success case: Testing changes past the retention are deleted")
+	feed.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	feed.cleanup(ctx)
+	bounds, err := queries.ListUserChangesBounds(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &database.UserChangesBounds{First: 5, Last: 5}, bounds)
+}
+
+// Utilities
+func insertUser(t *testing.T, ctx context.Context, queries database.Queries, username string) {
+	t.Helper()
+
+	_, err := queries.InsertUser(ctx, database.InsertUserParams{
+		Email:            fmt.Sprintf("%s@example.com", username),
+		Username:         username,
+		UsernameSkeleton: username,
+		Password:         "hash",
+	})
+	assert.NoError(t, err)
+}
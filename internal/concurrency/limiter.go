@@ -0,0 +1,133 @@
+package concurrency
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Sample windows of the latency moving averages, in number of calls
+const (
+	shortWindow = 10
+	longWindow  = 600
+)
+
+type Config struct {
+	// InitialLimit is the number of concurrent calls allowed until enough latency has been observed, 20 by default
+	InitialLimit int
+	// MinLimit and MaxLimit bound the adjusted limit, 5 and 500 by default
+	MinLimit int
+	MaxLimit int
+	// Tolerance is how much the recent latency may exceed the long-term one before the limit shrinks, 1.5 by default
+	Tolerance float64
+	// Smoothing is how fast the limit moves towards each new estimate, between 0 and 1, 0.2 by default
+	Smoothing float64
+}
+
+// Limiter bounds the number of calls handled at once, shedding the excess instead of letting it queue up
+type Limiter interface {
+	// Acquire reserves a slot, returning false when the server is saturated. release must be called once the
+	// call is done, with dropped set when its latency isn't representative (e.g. it was cancelled by the client).
+	Acquire() (release func(dropped bool), ok bool)
+	// Limit returns the current number of concurrent calls allowed
+	Limit() int
+}
+
+// gradientLimiter adjusts the limit from the ratio between the long-term and the recent latency (Netflix's Gradient2).
+// While latency holds steady the limit grows by its square root, probing for spare capacity; once calls start
+// queueing somewhere (bcrypt, the connection pool...) the recent latency climbs and the limit shrinks with it.
+type gradientLimiter struct {
+	mu       sync.Mutex
+	config   Config
+	limit    float64
+	inflight int
+	shortRTT float64
+	longRTT  float64
+	now      func() time.Time
+}
+
+func NewGradientLimiter(config Config) Limiter {
+	if config.InitialLimit <= 0 {
+		config.InitialLimit = 20
+	}
+	if config.MinLimit <= 0 {
+		config.MinLimit = 5
+	}
+	if config.MaxLimit <= 0 {
+		config.MaxLimit = 500
+	}
+	if config.Tolerance < 1 {
+		config.Tolerance = 1.5
+	}
+	if config.Smoothing <= 0 || config.Smoothing > 1 {
+		config.Smoothing = 0.2
+	}
+
+	return &gradientLimiter{
+		config: config,
+		limit:  float64(config.InitialLimit),
+		now:    time.Now,
+	}
+}
+
+func (l *gradientLimiter) Acquire() (func(dropped bool), bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inflight >= int(l.limit) {
+		return nil, false
+	}
+	l.inflight++
+
+	inflight := l.inflight
+	start := l.now()
+
+	var once sync.Once
+	return func(dropped bool) {
+		once.Do(func() { l.release(start, inflight, dropped) })
+	}, true
+}
+
+func (l *gradientLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return int(l.limit)
+}
+
+func (l *gradientLimiter) release(start time.Time, inflight int, dropped bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inflight--
+	if dropped {
+		return
+	}
+
+	l.sample(l.now().Sub(start).Seconds(), inflight)
+}
+
+func (l *gradientLimiter) sample(rtt float64, inflight int) {
+	if l.longRTT == 0 {
+		l.shortRTT, l.longRTT = rtt, rtt
+	} else {
+		l.shortRTT += (rtt - l.shortRTT) * 2 / (shortWindow + 1)
+		l.longRTT += (rtt - l.longRTT) * 2 / (longWindow + 1)
+	}
+
+	// After a long stretch of high latency the long-term average lags behind, so it's pulled down faster
+	if l.longRTT/l.shortRTT > 2 {
+		l.longRTT *= 0.95
+	}
+
+	// Far from the limit the latency says nothing about it, growing it would only allow a bigger spike later
+	if float64(inflight) < l.limit/2 {
+		return
+	}
+
+	gradient := math.Max(0.5, math.Min(1, l.config.Tolerance*l.longRTT/l.shortRTT))
+	estimate := l.limit*gradient + math.Sqrt(l.limit)
+
+	limit := l.limit*(1-l.config.Smoothing) + estimate*l.config.Smoothing
+	l.limit = math.Max(float64(l.config.MinLimit), math.Min(float64(l.config.MaxLimit), limit))
+}
@@ -0,0 +1,66 @@
+package concurrency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGradientLimiter(t *testing.T) {
+	limiter := NewGradientLimiter(Config{InitialLimit: 10, MinLimit: 2, MaxLimit: 50}).(*gradientLimiter)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	// runBatch fills every slot, then completes all calls after latency
+	runBatch := func(latency time.Duration) {
+		var releases []func(bool)
+		for {
+			release, ok := limiter.Acquire()
+			if !ok {
+				break
+			}
+			releases = append(releases, release)
+		}
+
+		now = now.Add(latency)
+		for _, release := range releases {
+			release(false)
+		}
+	}
+
+	t.Logf("Running failure case: Testing calls over the limit are rejected")
+	releases := make([]func(bool), 0, 10)
+	for i := 0; i < 10; i++ {
+		release, ok := limiter.Acquire()
+		assert.True(t, ok)
+		releases = append(releases, release)
+	}
+	_, ok := limiter.Acquire()
+	assert.False(t, ok)
+
+	t.Logf("Running success case: Testing released slots can be reused and dropped calls aren't sampled")
+	for _, release := range releases {
+		release(true)
+	}
+	assert.Equal(t, 0, limiter.inflight)
+	assert.Zero(t, limiter.longRTT)
+	release, ok := limiter.Acquire()
+	assert.True(t, ok)
+	release(true)
+
+	t.Logf("Running success case: Testing the limit grows while latency holds steady")
+	for i := 0; i < 10; i++ {
+		runBatch(10 * time.Millisecond)
+	}
+	grown := limiter.Limit()
+	assert.Greater(t, grown, 10)
+	assert.LessOrEqual(t, grown, 50)
+
+	t.Logf("Running success case: Testing the limit shrinks once latency climbs")
+	for i := 0; i < 10; i++ {
+		runBatch(200 * time.Millisecond)
+	}
+	assert.Less(t, limiter.Limit(), grown)
+	assert.GreaterOrEqual(t, limiter.Limit(), 2)
+}
@@ -0,0 +1,342 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/vinofsteel/grpc-management/internal/validation"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every setting of the server. Fields are loaded, in increasing precedence, from the defaults below,
+// the YAML file given by -config (or CONFIG_FILE), the environment variable in their env tag and the command line
+// flag named after their YAML path (e.g. -server.port). Empty environment variables are ignored, as .env files
+// usually list every variable. Settings tagged reload:"true" are applied by Reloader without a restart.
+type Config struct {
+	Env         string            `yaml:"env" env:"ENV" validate:"oneof=development production test"`
+	SecretKey   Secret            `yaml:"secret_key" env:"SECRET_KEY"`
+	Server      ServerConfig      `yaml:"server"`
+	TLS         TLSConfig         `yaml:"tls"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Log         LogConfig         `yaml:"log"`
+	Validation  ValidationConfig  `yaml:"validation"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	Concurrency ConcurrencyConfig `yaml:"concurrency"`
+	Cache       CacheConfig       `yaml:"cache"`
+	Outbox      OutboxConfig      `yaml:"outbox"`
+	Watch       WatchConfig       `yaml:"watch"`
+	Webhooks    WebhooksConfig    `yaml:"webhooks"`
+}
+
+type ServerConfig struct {
+	Port               int      `yaml:"port" env:"PORT" validate:"min=1,max=65535"`
+	GatewayPort        int      `yaml:"gateway_port" env:"GATEWAY_PORT" validate:"min=1,max=65535"`
+	MetricsPort        int      `yaml:"metrics_port" env:"METRICS_PORT" validate:"min=1,max=65535"`
+	CORSAllowedOrigins []string `yaml:"cors_allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	AccessLog          bool     `yaml:"access_log" env:"ACCESS_LOG"`
+}
+
+type TLSConfig struct {
+	// TLS is disabled when CertFile is empty
+	CertFile          string   `yaml:"cert_file" env:"TLS_CERT_FILE"`
+	KeyFile           string   `yaml:"key_file" env:"TLS_KEY_FILE" validate:"required_with=CertFile"`
+	MinVersion        string   `yaml:"min_version" env:"TLS_MIN_VERSION" validate:"omitempty,oneof=1.2 1.3"`
+	CipherSuites      []string `yaml:"cipher_suites" env:"TLS_CIPHER_SUITES"`
+	ClientCAFile      string   `yaml:"client_ca_file" env:"TLS_CLIENT_CA_FILE"`
+	RequireClientCert bool     `yaml:"require_client_cert" env:"TLS_REQUIRE_CLIENT_CERT"`
+	// Gateway* configure the REST gateway's connection to the TLS listener
+	GatewayCAFile   string `yaml:"gateway_ca_file" env:"GATEWAY_TLS_CA_FILE"`
+	GatewayCertFile string `yaml:"gateway_cert_file" env:"GATEWAY_TLS_CERT_FILE"`
+	GatewayKeyFile  string `yaml:"gateway_key_file" env:"GATEWAY_TLS_KEY_FILE" validate:"required_with=GatewayCertFile"`
+}
+
+type AuthConfig struct {
+	// AdminPrincipals are the mTLS principals allowed to call AdminService, matched against the client certificate's
+	// first URI SAN, DNS SAN or CN. AdminService is unreachable when empty or when mTLS is disabled.
+	AdminPrincipals []string `yaml:"admin_principals" env:"ADMIN_PRINCIPALS"`
+	// TrustedPrincipals are upstream gateways that authenticate their own clients, the only callers whose x-tenant-id
+	// metadata is honored. Their calls are rate limited by the client address they forward in x-forwarded-for.
+	TrustedPrincipals []string `yaml:"trusted_principals" env:"TRUSTED_PRINCIPALS"`
+	// TenantPrincipals assign a tenant to the calls of a principal, as "tenant=principal" pairs
+	TenantPrincipals []string `yaml:"tenant_principals" env:"TENANT_PRINCIPALS"`
+}
+
+// TenantsByPrincipal returns the tenant of each principal in TenantPrincipals
+func (c AuthConfig) TenantsByPrincipal() map[string]string {
+	tenants := make(map[string]string, len(c.TenantPrincipals))
+	for _, pair := range c.TenantPrincipals {
+		tenant, principal, _ := strings.Cut(pair, "=")
+		tenants[principal] = tenant
+	}
+
+	return tenants
+}
+
+type DatabaseConfig struct {
+	// Driver chooses the backend, the connection settings below are for postgres and Path is for sqlite
+	Driver   string `yaml:"driver" env:"DB_DRIVER" validate:"oneof=postgres sqlite"`
+	Host     string `yaml:"host" env:"PGHOST" validate:"required_if=Driver postgres"`
+	Port     int    `yaml:"port" env:"PGPORT" validate:"min=1,max=65535"`
+	User     string `yaml:"user" env:"PGUSER" validate:"required_if=Driver postgres"`
+	Password Secret `yaml:"password" env:"PGPASSWORD"`
+	Name     string `yaml:"name" env:"PGDATABASE" validate:"required_if=Driver postgres"`
+	// Path is the SQLite database file, created when missing
+	Path string `yaml:"path" env:"SQLITE_PATH" validate:"required_if=Driver sqlite"`
+	// AutoMigrate applies pending migrations on start, under an advisory lock so replicas don't race
+	AutoMigrate bool `yaml:"auto_migrate" env:"PG_AUTO_MIGRATE"`
+	// ConnectTimeout is how long startup keeps retrying while the database is unreachable
+	ConnectTimeout time.Duration `yaml:"connect_timeout" env:"PG_CONNECT_TIMEOUT" validate:"min=0"`
+	// Pool settings, see database/sql. Zero leaves MaxOpenConns unlimited and connections open indefinitely.
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"PG_MAX_OPEN_CONNS" validate:"min=0"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"PG_MAX_IDLE_CONNS" validate:"min=0"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"PG_CONN_MAX_LIFETIME" validate:"min=0"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"PG_CONN_MAX_IDLE_TIME" validate:"min=0"`
+	// ReplicaHosts are Postgres read replicas ("host" or "host:port"), reached with the user, password and database
+	// above. Reads go to the replicas that passed their last health check, and to the primary when none did.
+	ReplicaHosts         []string      `yaml:"replica_hosts" env:"PG_REPLICA_HOSTS"`
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval" env:"PG_REPLICA_CHECK_INTERVAL" validate:"min=1s"`
+	// StickyWindow is how long the reads of a session (see interceptors.SessionHeader) stay on the primary after it
+	// writes, so it sees its own writes while replicas catch up
+	StickyWindow time.Duration `yaml:"sticky_window" env:"PG_STICKY_WINDOW" validate:"min=0"`
+}
+
+type LogConfig struct {
+	// Level defaults to debug in development, info in production and warn in test
+	Level         string `yaml:"level" env:"LOG_LEVEL" reload:"true" validate:"omitempty,oneof=debug info warn error"`
+	RetentionDays int    `yaml:"retention_days" env:"LOG_RETENTION_DAYS" validate:"min=1"`
+}
+
+type ValidationConfig struct {
+	UsernameReservedWords    []string `yaml:"username_reserved_words" env:"USERNAME_RESERVED_WORDS" reload:"true"`
+	UsernameProfanityFile    string   `yaml:"username_profanity_file" env:"USERNAME_PROFANITY_FILE" reload:"true"`
+	EmailAllowFile           string   `yaml:"email_allow_file" env:"EMAIL_ALLOW_FILE" reload:"true"`
+	EmailDenyFile            string   `yaml:"email_deny_file" env:"EMAIL_DENY_FILE" reload:"true"`
+	EmailTenantOverridesFile string   `yaml:"email_tenant_overrides_file" env:"EMAIL_TENANT_OVERRIDES_FILE" reload:"true"`
+}
+
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env:"OTEL_TRACES_EXPORTER" validate:"omitempty,oneof=otlp stdout none"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"OTEL_TRACES_SAMPLER_ARG" validate:"min=0,max=1"`
+}
+
+type RateLimitConfig struct {
+	// Rate limiting is disabled when File is empty. Reloading also re-reads the file, even if the path didn't change.
+	File    string `yaml:"file" env:"RATE_LIMIT_FILE" reload:"true"`
+	Backend string `yaml:"backend" env:"RATE_LIMIT_BACKEND" validate:"oneof=memory postgres"`
+	// APIKeyDigests are the hex SHA-256 digests of the API keys that get buckets of their own, other keys are ignored
+	APIKeyDigests []string `yaml:"api_key_digests" env:"RATE_LIMIT_API_KEY_DIGESTS" validate:"dive,len=64,hexadecimal"`
+}
+
+type ConcurrencyConfig struct {
+	// MaxLimit caps the adaptive concurrency limit, 0 uses the limiter's default
+	MaxLimit            int `yaml:"max_limit" env:"CONCURRENCY_MAX_LIMIT" validate:"min=0"`
+	PasswordHashWorkers int `yaml:"password_hash_workers" env:"PASSWORD_HASH_WORKERS" reload:"true" validate:"min=1"`
+}
+
+type CacheConfig struct {
+	// Size is how many user lookups are cached, 0 disables the cache. With Postgres, servers sharing the database
+	// invalidate each other's caches through LISTEN/NOTIFY.
+	Size        int           `yaml:"size" env:"CACHE_SIZE" validate:"min=0"`
+	TTL         time.Duration `yaml:"ttl" env:"CACHE_TTL" validate:"min=0"`
+	NegativeTTL time.Duration `yaml:"negative_ttl" env:"CACHE_NEGATIVE_TTL" validate:"min=0"`
+	// LoadFromPrimary reads cache misses from the primary. Off, they go to the replicas like any read, and a replica
+	// lagging behind a write can put the user as it was before back in the cache, until the TTL.
+	LoadFromPrimary bool          `yaml:"load_from_primary" env:"CACHE_LOAD_FROM_PRIMARY"`
+	LoadTimeout     time.Duration `yaml:"load_timeout" env:"CACHE_LOAD_TIMEOUT" validate:"min=0"`
+}
+
+type OutboxConfig struct {
+	// Publisher is where the user events are relayed to: stdout, webhook (POSTed to WebhookURL) or none. The outbox is
+	// disabled with none unless Webhooks.Enabled: changes made meanwhile record no event, and switching a publisher on
+	// later doesn't replay them. Recording without a publisher would grow the outbox forever, nothing relaying it.
+	Publisher    string        `yaml:"publisher" env:"OUTBOX_PUBLISHER" validate:"oneof=none stdout webhook"`
+	WebhookURL   string        `yaml:"webhook_url" env:"OUTBOX_WEBHOOK_URL" validate:"required_if=Publisher webhook,omitempty,url"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"OUTBOX_POLL_INTERVAL" validate:"min=10ms"`
+	// Retention is how long published events are kept before being deleted
+	Retention time.Duration `yaml:"retention" env:"OUTBOX_RETENTION" validate:"min=0"`
+}
+
+type WatchConfig struct {
+	// PollInterval is how often the change log is read. With Postgres, changes are announced through LISTEN/NOTIFY,
+	// and polling only catches the announcements that were missed and the changes held back by an older transaction.
+	PollInterval time.Duration `yaml:"poll_interval" env:"WATCH_POLL_INTERVAL" validate:"min=10ms"`
+	// Retention is how long changes are kept for WatchUsers clients resuming a stream
+	Retention time.Duration `yaml:"retention" env:"WATCH_RETENTION" validate:"min=1m"`
+}
+
+type WebhooksConfig struct {
+	// Enabled turns the user events into deliveries to the webhook subscriptions, which are managed through the
+	// admin service either way
+	Enabled      bool          `yaml:"enabled" env:"WEBHOOKS_ENABLED"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"WEBHOOKS_POLL_INTERVAL" validate:"min=10ms"`
+	// Timeout bounds each delivery attempt
+	Timeout time.Duration `yaml:"timeout" env:"WEBHOOKS_TIMEOUT" validate:"min=1s"`
+	// MaxAttempts is how many attempts a delivery gets before it's dead, retried with backoff up to an hour apart
+	MaxAttempts int `yaml:"max_attempts" env:"WEBHOOKS_MAX_ATTEMPTS" validate:"min=1"`
+}
+
+func Default() Config {
+	return Config{
+		Env: "development",
+		Server: ServerConfig{
+			Port:        3000,
+			GatewayPort: 8080,
+			MetricsPort: 9090,
+			AccessLog:   true,
+		},
+		Database: DatabaseConfig{
+			Driver:          "postgres",
+			Port:            5432,
+			Path:            "luso-wiki.db",
+			ConnectTimeout:  time.Minute,
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 5 * time.Minute,
+			ConnMaxIdleTime: time.Minute,
+			// Checked often, as a replica that falls over keeps receiving reads until the next check
+			ReplicaCheckInterval: 5 * time.Second,
+			StickyWindow:         5 * time.Second,
+		},
+		Log: LogConfig{
+			RetentionDays: 30,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			SampleRatio: 1,
+		},
+		RateLimit: RateLimitConfig{
+			Backend: "memory",
+		},
+		Concurrency: ConcurrencyConfig{
+			PasswordHashWorkers: runtime.NumCPU(),
+		},
+		Cache: CacheConfig{
+			Size:        10000,
+			TTL:         time.Minute,
+			NegativeTTL: 5 * time.Second,
+			LoadTimeout: 5 * time.Second,
+		},
+		Outbox: OutboxConfig{
+			Publisher:    "none",
+			PollInterval: time.Second,
+			Retention:    24 * time.Hour,
+		},
+		Watch: WatchConfig{
+			PollInterval: time.Second,
+			Retention:    24 * time.Hour,
+		},
+		Webhooks: WebhooksConfig{
+			PollInterval: time.Second,
+			Timeout:      10 * time.Second,
+			MaxAttempts:  10,
+		},
+	}
+}
+
+// Load builds the configuration from the defaults, the YAML file, the environment and args (without the program
+// name), then validates it
+func Load(ctx context.Context, args []string) (Config, error) {
+	config := Default()
+	fields := collectFields(&config)
+
+	configFile, flagValues, _, err := parseArgs(args)
+	if err != nil {
+		return Config{}, err
+	}
+
+	if configFile != "" {
+		// #nosec G304
+		content, err := os.ReadFile(configFile)
+		if err != nil {
+			return Config{}, fmt.Errorf("error reading config file: %w", err)
+		}
+
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+			return Config{}, fmt.Errorf("error parsing config file %s: %w", configFile, err)
+		}
+	}
+
+	for _, field := range fields {
+		if value := os.Getenv(field.env); value != "" {
+			if err := field.set(value); err != nil {
+				return Config{}, fmt.Errorf("invalid %s: %w", field.env, err)
+			}
+		}
+	}
+
+	for _, field := range fields {
+		if value, ok := flagValues[field.path]; ok {
+			if err := field.set(value); err != nil {
+				return Config{}, fmt.Errorf("invalid -%s: %w", field.path, err)
+			}
+		}
+	}
+
+	if err := config.Validate(ctx); err != nil {
+		return Config{}, err
+	}
+
+	return config, nil
+}
+
+func (c Config) Validate(ctx context.Context) error {
+	validationProvider := validation.NewValidateValidationrovider(ctx)
+	if validationErr := validationProvider.ValidateData(ctx, c); validationErr != nil {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(validationErr.Errors, "; "))
+	}
+
+	for _, pair := range c.Auth.TenantPrincipals {
+		if tenant, principal, ok := strings.Cut(pair, "="); !ok || tenant == "" || principal == "" {
+			return fmt.Errorf("invalid configuration: tenant principal %q isn't a tenant=principal pair", pair)
+		}
+	}
+
+	// The shared rate limit counters live in a Postgres table
+	if c.RateLimit.Backend == "postgres" && c.Database.Driver != "postgres" {
+		return fmt.Errorf("invalid configuration: the postgres rate limit backend needs the postgres database driver")
+	}
+
+	return nil
+}
+
+// RemainingArgs returns the arguments left after the flags, such as the arguments of a subcommand
+func RemainingArgs(args []string) ([]string, error) {
+	_, _, remaining, err := parseArgs(args)
+	return remaining, err
+}
+
+// Utilities
+// parseArgs returns the config file path (from -config or CONFIG_FILE), the raw value of every setting flag given
+// and the arguments left after the flags.
+// Flag values are only applied after the file and the environment, so they're collected rather than set.
+func parseArgs(args []string) (string, map[string]string, []string, error) {
+	flags := flag.NewFlagSet("server", flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML configuration file")
+
+	flagValues := make(map[string]string)
+	for _, field := range collectFields(&Config{}) {
+		name := field.path
+		flags.Func(name, "overrides "+field.env, func(value string) error {
+			flagValues[name] = value
+			return nil
+		})
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return "", nil, nil, err
+	}
+
+	return *configFile, flagValues, flags.Args(), nil
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	assert.NoError(t, os.WriteFile(configFile, []byte(`
+server:
+  port: 4000
+  gateway_port: 4001
+database:
+  host: file-host
+  user: file-user
+  name: file-db
+`), 0o600))
+
+	loadTests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		wantErr bool
+		check   func(t *testing.T, config Config)
+	}{
+		{
+			name: "success case: Testing defaults are used when nothing is set",
+			env:  map[string]string{"PGHOST": "db", "PGUSER": "app", "PGDATABASE": "app"},
+			check: func(t *testing.T, config Config) {
+				assert.Equal(t, 3000, config.Server.Port)
+				assert.Equal(t, 5432, config.Database.Port)
+				assert.Equal(t, "memory", config.RateLimit.Backend)
+				assert.True(t, config.Server.AccessLog)
+			},
+		},
+		{
+			name: "success case: Testing the environment overrides the file and flags override the environment",
+			env:  map[string]string{"PORT": "5000", "GATEWAY_PORT": "5001", "PGUSER": "env-user", "CORS_ALLOWED_ORIGINS": "http://a.test, http://b.test"},
+			args: []string{"-config", configFile, "-server.port", "6000"},
+			check: func(t *testing.T, config Config) {
+				assert.Equal(t, 6000, config.Server.Port)
+				assert.Equal(t, 5001, config.Server.GatewayPort)
+				assert.Equal(t, "file-host", config.Database.Host)
+				assert.Equal(t, "env-user", config.Database.User)
+				assert.Equal(t, []string{"http://a.test", "http://b.test"}, config.Server.CORSAllowedOrigins)
+			},
+		},
+		{
+			name:    "failure case: Testing invalid values are rejected by validation",
+			env:     map[string]string{"RATE_LIMIT_BACKEND": "redis"},
+			args:    []string{"-config", configFile},
+			wantErr: true,
+		},
+		{
+			name:    "failure case: Testing required settings are enforced",
+			wantErr: true,
+		},
+		{
+			name: "success case: Testing the sqlite driver doesn't need the Postgres settings",
+			env:  map[string]string{"DB_DRIVER": "sqlite", "SQLITE_PATH": "/tmp/test.db"},
+			check: func(t *testing.T, config Config) {
+				assert.Equal(t, "sqlite", config.Database.Driver)
+				assert.Equal(t, "/tmp/test.db", config.Database.Path)
+			},
+		},
+		{
+			name:    "failure case: Testing the postgres rate limit backend needs the postgres driver",
+			env:     map[string]string{"DB_DRIVER": "sqlite", "RATE_LIMIT_BACKEND": "postgres"},
+			wantErr: true,
+		},
+		{
+			name:    "failure case: Testing the webhook publisher needs a URL",
+			env:     map[string]string{"PGHOST": "db", "PGUSER": "app", "PGDATABASE": "app", "OUTBOX_PUBLISHER": "webhook"},
+			wantErr: true,
+		},
+		{
+			name:    "failure case: Testing unparseable values are rejected",
+			env:     map[string]string{"PORT": "not-a-port"},
+			args:    []string{"-config", configFile},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range loadTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Logf("Running %s", tt.name)
+
+			// Cleared so the environment of the machine running the tests doesn't leak in
+			for _, field := range collectFields(&Config{}) {
+				t.Setenv(field.env, "")
+			}
+			t.Setenv("CONFIG_FILE", "")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			config, err := Load(context.Background(), tt.args)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			tt.check(t, config)
+		})
+	}
+}
+
+func TestSecretRedaction(t *testing.T) {
+	config := Default()
+	config.Database.Password = "hunter2"
+	config.SecretKey = "top-secret"
+
+	t.Logf("Running success case: Testing secrets are redacted when printed and marshalled")
+	printed := fmt.Sprintf("%+v", config)
+	assert.NotContains(t, printed, "hunter2")
+	assert.NotContains(t, printed, "top-secret")
+	assert.Contains(t, printed, redacted)
+
+	marshalled, err := json.Marshal(config)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(marshalled), "hunter2")
+
+	t.Logf("Running success case: Testing the actual value is still available")
+	assert.Equal(t, "hunter2", config.Database.Password.Value())
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// field is a single setting of Config, addressable by its environment variable and its flag
+type field struct {
+	// path is the dotted YAML path of the field, used as its flag name
+	path string
+	env  string
+	// reloadable settings are applied by Reloader subscribers, others only take effect after a restart
+	reloadable bool
+	value      reflect.Value
+}
+
+func (f field) set(raw string) error {
+	// Durations are written as "5m", "30s"...
+	if f.value.Type() == reflect.TypeOf(time.Duration(0)) {
+		value, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		f.value.SetInt(int64(value))
+		return nil
+	}
+
+	switch f.value.Kind() {
+	case reflect.String:
+		f.value.SetString(raw)
+	case reflect.Bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		f.value.SetBool(value)
+	case reflect.Int:
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		f.value.SetInt(int64(value))
+	case reflect.Float64:
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		f.value.SetFloat(value)
+	case reflect.Slice:
+		// Lists are comma separated, an empty value clears them
+		var values []string
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		f.value.Set(reflect.ValueOf(values))
+	default:
+		return fmt.Errorf("unsupported setting type %s", f.value.Type())
+	}
+
+	return nil
+}
+
+// Utilities
+func collectFields(config *Config) []field {
+	return walkFields(reflect.ValueOf(config).Elem(), "")
+}
+
+func walkFields(value reflect.Value, prefix string) []field {
+	var fields []field
+
+	for i := 0; i < value.NumField(); i++ {
+		structField := value.Type().Field(i)
+		path := prefix + strings.Split(structField.Tag.Get("yaml"), ",")[0]
+
+		if structField.Type.Kind() == reflect.Struct {
+			fields = append(fields, walkFields(value.Field(i), path+".")...)
+			continue
+		}
+
+		fields = append(fields, field{
+			path:       path,
+			env:        structField.Tag.Get("env"),
+			reloadable: structField.Tag.Get("reload") == "true",
+			value:      value.Field(i),
+		})
+	}
+
+	return fields
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Subscriber applies a reloaded configuration to a component. Subscribers are called on every reload, even when
+// their settings didn't change, so the files they read (rate limits, word lists...) are picked up too.
+type Subscriber func(ctx context.Context, config Config) error
+
+// Reloader re-reads the configuration on demand (e.g. on SIGHUP) or when the config file changes. A new configuration
+// is fully loaded and validated before any subscriber sees it, so a broken file never leaves it half applied.
+type Reloader interface {
+	// Current returns the configuration in effect
+	Current() Config
+	// Subscribe registers a component to be reconfigured on each reload, name identifies it in the logs
+	Subscribe(name string, subscriber Subscriber)
+	// Reload loads and applies the configuration, returning an error when it's invalid or a subscriber failed
+	Reload(ctx context.Context) error
+	// Watch reloads the configuration whenever the config file changes, until ctx is done
+	Watch(ctx context.Context, interval time.Duration)
+}
+
+type subscription struct {
+	name       string
+	subscriber Subscriber
+}
+
+type reloader struct {
+	args       []string
+	configFile string
+	current    atomic.Pointer[Config]
+	// mu serializes reloads, so subscribers never run concurrently
+	mu            sync.Mutex
+	subscriptions []subscription
+	modTime       time.Time
+}
+
+// NewReloader watches the configuration loaded from args, starting from initial
+func NewReloader(args []string, initial Config) (Reloader, error) {
+	configFile, _, _, err := parseArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &reloader{
+		args:       args,
+		configFile: configFile,
+	}
+	r.current.Store(&initial)
+	r.modTime = r.currentModTime()
+
+	return r, nil
+}
+
+func (r *reloader) Current() Config {
+	return *r.current.Load()
+}
+
+func (r *reloader) Subscribe(name string, subscriber Subscriber) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subscriptions = append(r.subscriptions, subscription{name: name, subscriber: subscriber})
+}
+
+func (r *reloader) Reload(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	config, err := Load(ctx, r.args)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error reloading configuration, keeping previous one", "error", err)
+		return err
+	}
+
+	previous := r.current.Load()
+	changes, restartRequired := diff(*previous, config)
+	r.current.Store(&config)
+
+	var failed []string
+	for _, subscription := range r.subscriptions {
+		if err := subscription.subscriber(ctx, config); err != nil {
+			slog.ErrorContext(ctx, "Error applying reloaded configuration", "component", subscription.name, "error", err)
+			failed = append(failed, subscription.name)
+		}
+	}
+
+	if len(restartRequired) > 0 {
+		slog.WarnContext(ctx, "Some configuration changes only take effect after a restart", "changes", restartRequired)
+	}
+
+	if len(failed) > 0 {
+		slog.ErrorContext(ctx, "Configuration reloaded with errors", "changes", changes, "failed", failed)
+		return fmt.Errorf("error applying configuration to %v", failed)
+	}
+
+	slog.InfoContext(ctx, "Configuration reloaded", "changes", changes)
+	return nil
+}
+
+func (r *reloader) Watch(ctx context.Context, interval time.Duration) {
+	if r.configFile == "" {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			modTime := r.currentModTime()
+			if modTime.Equal(r.modTime) {
+				continue
+			}
+
+			// Remember the new time even on failure, so a broken file is reported once instead of on every tick
+			r.modTime = modTime
+			_ = r.Reload(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// A missing file gets the zero time, so it's picked up again once it's recreated
+func (r *reloader) currentModTime() time.Time {
+	if info, err := os.Stat(r.configFile); err == nil {
+		return info.ModTime()
+	}
+
+	return time.Time{}
+}
+
+// Utilities
+// diff lists the settings that differ between previous and current as "path: old -> new" (secrets stay redacted),
+// along with the ones among them that can't be applied without a restart
+func diff(previous, current Config) ([]string, []string) {
+	previousFields := collectFields(&previous)
+	currentFields := collectFields(&current)
+
+	var changes, restartRequired []string
+	for i, field := range currentFields {
+		old, updated := previousFields[i].value.Interface(), field.value.Interface()
+		if reflect.DeepEqual(old, updated) {
+			continue
+		}
+
+		change := fmt.Sprintf("%s: %v -> %v", field.path, old, updated)
+		changes = append(changes, change)
+		if !field.reloadable {
+			restartRequired = append(restartRequired, change)
+		}
+	}
+
+	return changes, restartRequired
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloader(t *testing.T) {
+	for _, field := range collectFields(&Config{}) {
+		t.Setenv(field.env, "")
+	}
+	t.Setenv("CONFIG_FILE", "")
+
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig := func(content string) {
+		assert.NoError(t, os.WriteFile(configFile, []byte(content), 0o600))
+	}
+	writeConfig(`
+database: {host: db, user: app, name: app, password: hunter2}
+log: {level: info}
+`)
+
+	args := []string{"-config", configFile}
+	initial, err := Load(context.Background(), args)
+	assert.NoError(t, err)
+
+	reloader, err := NewReloader(args, initial)
+	assert.NoError(t, err)
+
+	var applied []string
+	reloader.Subscribe("logger", func(ctx context.Context, config Config) error {
+		applied = append(applied, config.Log.Level)
+		return nil
+	})
+
+	t.Logf("Running success case: Testing subscribers receive the reloaded configuration")
+	writeConfig(`
+database: {host: db, user: app, name: app, password: hunter2}
+log: {level: debug}
+`)
+	assert.NoError(t, reloader.Reload(context.Background()))
+	assert.Equal(t, []string{"debug"}, applied)
+	assert.Equal(t, "debug", reloader.Current().Log.Level)
+
+	t.Logf("Running failure case: Testing an invalid configuration is never applied")
+	writeConfig(`
+database: {host: db, user: app, name: app, password: hunter2}
+log: {level: verbose}
+`)
+	assert.Error(t, reloader.Reload(context.Background()))
+	assert.Equal(t, []string{"debug"}, applied)
+	assert.Equal(t, "debug", reloader.Current().Log.Level)
+
+	t.Logf("Running failure case: Testing a failing subscriber is reported")
+	reloader.Subscribe("broken", func(ctx context.Context, config Config) error {
+		return errors.New("boom")
+	})
+	writeConfig(`
+database: {host: db, user: app, name: app, password: hunter2}
+log: {level: warn}
+`)
+	assert.Error(t, reloader.Reload(context.Background()))
+	assert.Equal(t, []string{"debug", "warn"}, applied)
+}
+
+func TestDiff(t *testing.T) {
+	previous := Default()
+	current := Default()
+	current.Log.Level = "debug"
+	current.Server.Port = 4000
+	current.Database.Password = "hunter2"
+
+	t.Logf("Running success case: Testing changes are listed with secrets redacted and restarts flagged")
+	changes, restartRequired := diff(previous, current)
+	assert.Equal(t, []string{
+		"server.port: 3000 -> 4000",
+		"database.password:  -> [REDACTED]",
+		"log.level:  -> debug",
+	}, changes)
+	assert.Equal(t, []string{
+		"server.port: 3000 -> 4000",
+		"database.password:  -> [REDACTED]",
+	}, restartRequired)
+}
@@ -0,0 +1,33 @@
+package config
+
+import "encoding/json"
+
+const redacted = "[REDACTED]"
+
+// Secret is a string that's redacted whenever it's printed, logged or marshalled, so the configuration
+// can be dumped safely. Value returns the actual secret.
+type Secret string
+
+func (s Secret) Value() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+
+	return redacted
+}
+
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s Secret) MarshalYAML() (any, error) {
+	return s.String(), nil
+}
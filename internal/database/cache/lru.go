@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinofsteel/grpc-management/internal/database"
+)
+
+// lru holds up to size lookups, evicting the least recently used one. A nil user records a lookup that found nothing.
+type lru struct {
+	size int
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	order *list.List
+	// byUser indexes the keys holding each user, so a write can drop all of them without knowing the old values
+	byUser map[uuid.UUID]map[string]struct{}
+	// generation changes on every invalidation, loads that started before one aren't stored
+	generation uint64
+}
+
+type entry struct {
+	key     string
+	user    *database.User
+	expires time.Time
+}
+
+func newLRU(size int) *lru {
+	return &lru{
+		size:   size,
+		items:  make(map[string]*list.Element),
+		order:  list.New(),
+		byUser: make(map[uuid.UUID]map[string]struct{}),
+	}
+}
+
+// get returns the user cached under key and whether there was a live entry
+func (c *lru) get(key string, now time.Time) (*database.User, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := element.Value.(*entry)
+	if !now.Before(e.expires) {
+		c.remove(element)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return e.user, true
+}
+
+func (c *lru) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+// set caches user under key, unless an invalidation happened since generation was read
+func (c *lru) set(key string, user *database.User, expires time.Time, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	if element, ok := c.items[key]; ok {
+		c.remove(element)
+	}
+
+	c.items[key] = c.order.PushFront(&entry{key: key, user: user, expires: expires})
+	if user != nil {
+		if c.byUser[user.ID] == nil {
+			c.byUser[user.ID] = make(map[string]struct{})
+		}
+		c.byUser[user.ID][key] = struct{}{}
+	}
+
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// invalidate drops keys and every key holding userID
+func (c *lru) invalidate(userID uuid.UUID, keys []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+
+	for key := range c.byUser[userID] {
+		keys = append(keys, key)
+	}
+
+	for _, key := range keys {
+		if element, ok := c.items[key]; ok {
+			c.remove(element)
+		}
+	}
+}
+
+func (c *lru) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+	c.byUser = make(map[uuid.UUID]map[string]struct{})
+}
+
+func (c *lru) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// Utilities
+// remove drops element, the lock must be held
+func (c *lru) remove(element *list.Element) {
+	e := c.order.Remove(element).(*entry)
+	delete(c.items, e.key)
+
+	if e.user != nil {
+		delete(c.byUser[e.user.ID], e.key)
+		if len(c.byUser[e.user.ID]) == 0 {
+			delete(c.byUser, e.user.ID)
+		}
+	}
+}
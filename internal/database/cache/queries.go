@@ -0,0 +1,378 @@
+// Package cache caches the user lookups of a database.Queries, dropping the cached users on writes, including writes
+// made by other servers when they share invalidations through a Broadcaster
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinofsteel/grpc-management/internal/database"
+	"golang.org/x/sync/singleflight"
+)
+
+// InvalidationChannel is the channel invalidations are broadcast on
+const InvalidationChannel = "users_cache_invalidation"
+
+type Config struct {
+	// Size is how many lookups are kept
+	Size int
+	// TTL bounds how long a user is served from the cache, should an invalidation be missed
+	TTL time.Duration
+	// NegativeTTL is how long lookups that found nothing are cached
+	NegativeTTL time.Duration
+	// LoadFromPrimary loads misses from the primary, so a lagging replica can't bring back a user that was just
+	// invalidated. Otherwise misses are routed like any read, and may cache a stale user for up to TTL.
+	LoadFromPrimary bool
+	// LoadTimeout bounds each load, which doesn't stop when the caller that started it gives up
+	LoadTimeout time.Duration
+}
+
+// Broadcaster shares invalidations between the servers using the same database, see postgres.Notifier
+type Broadcaster interface {
+	Notify(ctx context.Context, channel, payload string) error
+	Listen(ctx context.Context, channel string, fn func(payload string)) error
+}
+
+type CachedQueries struct {
+	queries     database.Queries
+	config      Config
+	cache       *lru
+	group       *singleflight.Group
+	broadcaster Broadcaster
+	now         func() time.Time
+
+	// pending collects the invalidations of the transaction in progress, applied once it commits. nil outside one.
+	pending *[]invalidation
+}
+
+// invalidation names the user a write changed and the keys that may now hold a stale lookup, e.g. its new username
+type invalidation struct {
+	UserID uuid.UUID `json:"user_id"`
+	Keys   []string  `json:"keys"`
+}
+
+// NewCachedQueries caches the lookups of users by ID, email and username made through queries. broadcaster may be
+// nil when a single server uses the database.
+func NewCachedQueries(queries database.Queries, config Config, broadcaster Broadcaster) *CachedQueries {
+	if config.LoadTimeout <= 0 {
+		config.LoadTimeout = 5 * time.Second
+	}
+
+	return &CachedQueries{
+		queries:     queries,
+		config:      config,
+		cache:       newLRU(config.Size),
+		group:       &singleflight.Group{},
+		broadcaster: broadcaster,
+		now:         time.Now,
+	}
+}
+
+// Run applies the invalidations broadcast by other servers until ctx is done
+func (c *CachedQueries) Run(ctx context.Context) {
+	if c.broadcaster == nil {
+		return
+	}
+
+	err := c.broadcaster.Listen(ctx, InvalidationChannel, func(payload string) {
+		// Invalidations sent while disconnected were missed, so nothing cached can be trusted
+		if payload == "" {
+			slog.WarnContext(ctx, "Cache invalidations may have been missed, purging the cache")
+			c.cache.purge()
+			return
+		}
+
+		var inv invalidation
+		if err := json.Unmarshal([]byte(payload), &inv); err != nil {
+			slog.ErrorContext(ctx, "Invalid cache invalidation", "error", err, "payload", payload)
+			return
+		}
+		c.cache.invalidate(inv.UserID, inv.Keys)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listening for cache invalidations", "error", err)
+	}
+}
+
+func (c *CachedQueries) ListUserByEmail(ctx context.Context, params database.ListUserByEmailParams) (*database.User, error) {
+	if params.ListDeleted || c.pending != nil {
+		return c.queries.ListUserByEmail(ctx, params)
+	}
+
+	return c.lookup(ctx, emailKey(params.Email), func(ctx context.Context) (*database.User, error) {
+		return c.queries.ListUserByEmail(ctx, params)
+	})
+}
+
+func (c *CachedQueries) ListUserByUsername(ctx context.Context, params database.ListUserByUsernameParams) (*database.User, error) {
+	if params.ListDeleted || c.pending != nil {
+		return c.queries.ListUserByUsername(ctx, params)
+	}
+
+	return c.lookup(ctx, usernameKey(params.Username), func(ctx context.Context) (*database.User, error) {
+		return c.queries.ListUserByUsername(ctx, params)
+	})
+}
+
+// ListUserByUsernameSkeleton isn't cached, it guards registrations, which must see every existing username
+func (c *CachedQueries) ListUserByUsernameSkeleton(ctx context.Context, params database.ListUserByUsernameSkeletonParams) (*database.User, error) {
+	return c.queries.ListUserByUsernameSkeleton(ctx, params)
+}
+
+func (c *CachedQueries) ListUserById(ctx context.Context, params database.ListUserByIdParams) (*database.User, error) {
+	if params.ListDeleted || c.pending != nil {
+		return c.queries.ListUserById(ctx, params)
+	}
+
+	return c.lookup(ctx, idKey(params.ID), func(ctx context.Context) (*database.User, error) {
+		return c.queries.ListUserById(ctx, params)
+	})
+}
+
+func (c *CachedQueries) ListUsers(ctx context.Context, params database.ListUsersParams) ([]*database.User, error) {
+	return c.queries.ListUsers(ctx, params)
+}
+
+func (c *CachedQueries) InsertUser(ctx context.Context, params database.InsertUserParams) (*database.User, error) {
+	user, err := c.queries.InsertUser(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Drops the lookups that found nothing before the user existed
+	c.invalidate(ctx, invalidation{
+		UserID: user.ID,
+		Keys:   []string{idKey(user.ID), emailKey(user.Email), usernameKey(user.Username)},
+	})
+	return user, nil
+}
+
+func (c *CachedQueries) UpdateUserPassword(ctx context.Context, params database.UpdateUserPasswordParams) (*database.User, error) {
+	user, err := c.queries.UpdateUserPassword(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	c.invalidate(ctx, invalidation{UserID: params.UserID, Keys: []string{idKey(params.UserID)}})
+	return user, nil
+}
+
+func (c *CachedQueries) UpdateUserUsername(ctx context.Context, params database.UpdateUserUsernameParams) (*database.User, error) {
+	user, err := c.queries.UpdateUserUsername(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// The old username is among the keys of the user, the new one may have been cached as not found
+	c.invalidate(ctx, invalidation{
+		UserID: params.UserID,
+		Keys:   []string{idKey(params.UserID), usernameKey(params.Username)},
+	})
+	return user, nil
+}
+
+func (c *CachedQueries) DeleteUser(ctx context.Context, params database.DeleteUserParams) error {
+	if err := c.queries.DeleteUser(ctx, params); err != nil {
+		return err
+	}
+
+	c.invalidate(ctx, invalidation{UserID: params.ID, Keys: []string{idKey(params.ID)}})
+	return nil
+}
+
+// The outbox, the change log and the webhooks aren't cached
+
+func (c *CachedQueries) InsertOutboxEvent(ctx context.Context, params database.InsertOutboxEventParams) (*database.OutboxEvent, error) {
+	return c.queries.InsertOutboxEvent(ctx, params)
+}
+
+func (c *CachedQueries) ClaimOutboxEvents(ctx context.Context, params database.ClaimOutboxEventsParams) ([]*database.OutboxEvent, error) {
+	return c.queries.ClaimOutboxEvents(ctx, params)
+}
+
+func (c *CachedQueries) MarkOutboxEventPublished(ctx context.Context, params database.MarkOutboxEventPublishedParams) error {
+	return c.queries.MarkOutboxEventPublished(ctx, params)
+}
+
+func (c *CachedQueries) MarkOutboxEventFailed(ctx context.Context, params database.MarkOutboxEventFailedParams) error {
+	return c.queries.MarkOutboxEventFailed(ctx, params)
+}
+
+func (c *CachedQueries) DeleteOutboxEvents(ctx context.Context, params database.DeleteOutboxEventsParams) (int64, error) {
+	return c.queries.DeleteOutboxEvents(ctx, params)
+}
+
+func (c *CachedQueries) SequenceUserChanges(ctx context.Context) (int64, error) {
+	return c.queries.SequenceUserChanges(ctx)
+}
+
+func (c *CachedQueries) ListUserChanges(ctx context.Context, params database.ListUserChangesParams) ([]*database.UserChange, error) {
+	return c.queries.ListUserChanges(ctx, params)
+}
+
+func (c *CachedQueries) ListUserChangesBounds(ctx context.Context) (*database.UserChangesBounds, error) {
+	return c.queries.ListUserChangesBounds(ctx)
+}
+
+func (c *CachedQueries) DeleteUserChanges(ctx context.Context, params database.DeleteUserChangesParams) (int64, error) {
+	return c.queries.DeleteUserChanges(ctx, params)
+}
+
+func (c *CachedQueries) InsertWebhookSubscription(ctx context.Context, params database.InsertWebhookSubscriptionParams) (*database.WebhookSubscription, error) {
+	return c.queries.InsertWebhookSubscription(ctx, params)
+}
+
+func (c *CachedQueries) ListWebhookSubscriptions(ctx context.Context) ([]*database.WebhookSubscription, error) {
+	return c.queries.ListWebhookSubscriptions(ctx)
+}
+
+func (c *CachedQueries) ListWebhookSubscriptionById(ctx context.Context, params database.ListWebhookSubscriptionByIdParams) (*database.WebhookSubscription, error) {
+	return c.queries.ListWebhookSubscriptionById(ctx, params)
+}
+
+func (c *CachedQueries) DeleteWebhookSubscription(ctx context.Context, params database.DeleteWebhookSubscriptionParams) error {
+	return c.queries.DeleteWebhookSubscription(ctx, params)
+}
+
+func (c *CachedQueries) InsertWebhookDelivery(ctx context.Context, params database.InsertWebhookDeliveryParams) error {
+	return c.queries.InsertWebhookDelivery(ctx, params)
+}
+
+func (c *CachedQueries) ClaimWebhookDeliveries(ctx context.Context, params database.ClaimWebhookDeliveriesParams) ([]*database.WebhookDelivery, error) {
+	return c.queries.ClaimWebhookDeliveries(ctx, params)
+}
+
+func (c *CachedQueries) RecordWebhookDeliveryAttempt(ctx context.Context, params database.RecordWebhookDeliveryAttemptParams) error {
+	return c.queries.RecordWebhookDeliveryAttempt(ctx, params)
+}
+
+func (c *CachedQueries) ListWebhookDeliveries(ctx context.Context, params database.ListWebhookDeliveriesParams) ([]*database.WebhookDelivery, error) {
+	return c.queries.ListWebhookDeliveries(ctx, params)
+}
+
+func (c *CachedQueries) ListWebhookDeliveryById(ctx context.Context, params database.ListWebhookDeliveryByIdParams) (*database.WebhookDelivery, error) {
+	return c.queries.ListWebhookDeliveryById(ctx, params)
+}
+
+func (c *CachedQueries) RedeliverWebhookDelivery(ctx context.Context, params database.RedeliverWebhookDeliveryParams) (*database.WebhookDelivery, error) {
+	return c.queries.RedeliverWebhookDelivery(ctx, params)
+}
+
+// WithTx bypasses the cache inside the transaction, whose invalidations are applied once it commits
+func (c *CachedQueries) WithTx(ctx context.Context, fn func(q database.Queries) error, options ...database.TxOption) error {
+	// Already inside a transaction, which fn joins
+	if c.pending != nil {
+		return fn(c)
+	}
+
+	var pending []invalidation
+	err := c.queries.WithTx(ctx, func(tx database.Queries) error {
+		// A retried transaction starts over
+		pending = pending[:0]
+
+		return fn(&CachedQueries{
+			queries:     tx,
+			config:      c.config,
+			cache:       c.cache,
+			group:       c.group,
+			broadcaster: c.broadcaster,
+			now:         c.now,
+			pending:     &pending,
+		})
+	}, options...)
+	if err != nil {
+		return err
+	}
+
+	for _, inv := range pending {
+		c.invalidate(ctx, inv)
+	}
+
+	return nil
+}
+
+// Utilities
+// lookup returns the user cached under key, loading it on a miss. Concurrent misses of a key share a single load.
+func (c *CachedQueries) lookup(ctx context.Context, key string, load func(ctx context.Context) (*database.User, error)) (*database.User, error) {
+	if user, ok := c.cache.get(key, c.now()); ok {
+		if user == nil {
+			return nil, sql.ErrNoRows
+		}
+		return copyUser(user), nil
+	}
+
+	result, err, _ := c.group.Do(key, func() (any, error) {
+		generation := c.cache.currentGeneration()
+
+		// The load is shared by every caller waiting on key, so it doesn't stop when the first one gives up, but has a
+		// deadline of its own
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.config.LoadTimeout)
+		defer cancel()
+		if c.config.LoadFromPrimary {
+			loadCtx = database.WithReadPrimary(loadCtx)
+		}
+
+		user, err := load(loadCtx)
+		switch {
+		case err == nil:
+			c.cache.set(key, user, c.now().Add(c.config.TTL), generation)
+		case errors.Is(err, sql.ErrNoRows):
+			c.cache.set(key, nil, c.now().Add(c.config.NegativeTTL), generation)
+		}
+
+		return user, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return copyUser(result.(*database.User)), nil
+}
+
+// invalidate drops the lookups of inv, here and on the other servers, or defers it to the commit of the transaction
+func (c *CachedQueries) invalidate(ctx context.Context, inv invalidation) {
+	if c.pending != nil {
+		*c.pending = append(*c.pending, inv)
+		return
+	}
+
+	c.cache.invalidate(inv.UserID, inv.Keys)
+
+	if c.broadcaster == nil {
+		return
+	}
+
+	payload, err := json.Marshal(inv)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error encoding cache invalidation", "error", err)
+		return
+	}
+
+	// Other servers keep serving the stale user until the TTL passes, which beats failing a write that succeeded
+	if err := c.broadcaster.Notify(ctx, InvalidationChannel, string(payload)); err != nil {
+		slog.ErrorContext(ctx, "Error broadcasting cache invalidation", "error", err, "user_id", inv.UserID)
+	}
+}
+
+// copyUser keeps callers from changing the cached user
+func copyUser(user *database.User) *database.User {
+	userCopy := *user
+	return &userCopy
+}
+
+func idKey(id uuid.UUID) string {
+	return "id:" + id.String()
+}
+
+func emailKey(email string) string {
+	return "email:" + email
+}
+
+func usernameKey(username string) string {
+	return "username:" + username
+}
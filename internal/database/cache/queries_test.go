@@ -0,0 +1,229 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vinofsteel/grpc-management/internal/database"
+	"github.com/vinofsteel/grpc-management/internal/database/memory"
+)
+
+// countingQueries counts the lookups reaching the database, optionally holding them until release is closed
+type countingQueries struct {
+	database.Queries
+	lookups atomic.Int32
+	release chan struct{}
+}
+
+func (q *countingQueries) ListUserById(ctx context.Context, params database.ListUserByIdParams) (*database.User, error) {
+	q.lookups.Add(1)
+	if q.release != nil {
+		<-q.release
+	}
+	return q.Queries.ListUserById(ctx, params)
+}
+
+func (q *countingQueries) ListUserByEmail(ctx context.Context, params database.ListUserByEmailParams) (*database.User, error) {
+	q.lookups.Add(1)
+	return q.Queries.ListUserByEmail(ctx, params)
+}
+
+func (q *countingQueries) ListUserByUsername(ctx context.Context, params database.ListUserByUsernameParams) (*database.User, error) {
+	q.lookups.Add(1)
+	return q.Queries.ListUserByUsername(ctx, params)
+}
+
+type fakeBroadcaster struct {
+	mu       sync.Mutex
+	payloads []string
+	listen   chan string
+}
+
+func (b *fakeBroadcaster) Notify(ctx context.Context, channel, payload string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.payloads = append(b.payloads, payload)
+	return nil
+}
+
+func (b *fakeBroadcaster) Listen(ctx context.Context, channel string, fn func(payload string)) error {
+	for {
+		select {
+		case payload := <-b.listen:
+			fn(payload)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func TestCachedQueries(t *testing.T) {
+	ctx := context.Background()
+	counting := &countingQueries{Queries: memory.NewMemoryQueries()}
+	broadcaster := &fakeBroadcaster{}
+	cached := NewCachedQueries(counting, Config{Size: 3, TTL: time.Minute, NegativeTTL: time.Second}, broadcaster)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cached.now = func() time.Time { return now }
+
+	t.Logf("Running success case: Testing lookups that found nothing are cached until the negative TTL")
+	_, err := cached.ListUserByEmail(ctx, database.ListUserByEmailParams{Email: "alice@example.com"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = cached.ListUserByEmail(ctx, database.ListUserByEmailParams{Email: "alice@example.com"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.Equal(t, int32(1), counting.lookups.Load())
+
+	t.Logf("Running success case: Testing an insert drops the lookups that found nothing and is broadcast")
+	user, err := cached.InsertUser(ctx, database.InsertUserParams{Email: "alice@example.com", Username: "alice", UsernameSkeleton: "alice", Password: "hash"})
+	assert.NoError(t, err)
+	found, err := cached.ListUserByEmail(ctx, database.ListUserByEmailParams{Email: "alice@example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+	assert.Len(t, broadcaster.payloads, 1)
+
+	t.Logf("Running success case: Testing cached users are served without reaching the database")
+	counting.lookups.Store(0)
+	for i := 0; i < 3; i++ {
+		_, err = cached.ListUserById(ctx, database.ListUserByIdParams{ID: user.ID})
+		assert.NoError(t, err)
+		_, err = cached.ListUserByUsername(ctx, database.ListUserByUsernameParams{Username: "alice"})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), counting.lookups.Load())
+
+	t.Logf("Running success case: Testing a username update drops every key of the user")
+	_, err = cached.UpdateUserUsername(ctx, database.UpdateUserUsernameParams{UserID: user.ID, Username: "alicia", UsernameSkeleton: "alicia"})
+	assert.NoError(t, err)
+	_, err = cached.ListUserByUsername(ctx, database.ListUserByUsernameParams{Username: "alice"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	renamed, err := cached.ListUserById(ctx, database.ListUserByIdParams{ID: user.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, "alicia", renamed.Username)
+
+	t.Logf("Running success case: Testing users expire after the TTL")
+	counting.lookups.Store(0)
+	now = now.Add(time.Minute)
+	_, err = cached.ListUserById(ctx, database.ListUserByIdParams{ID: user.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), counting.lookups.Load())
+
+	t.Logf("Running success case: Testing the least recently used lookups are evicted past the size")
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com"} {
+		_, _ = cached.ListUserByEmail(ctx, database.ListUserByEmailParams{Email: email})
+	}
+	assert.Equal(t, 3, cached.cache.len())
+	_, ok := cached.cache.get(idKey(user.ID), now)
+	assert.False(t, ok)
+
+	t.Logf("Running success case: Testing a rolled back transaction invalidates nothing")
+	_, err = cached.ListUserById(ctx, database.ListUserByIdParams{ID: user.ID})
+	assert.NoError(t, err)
+	errRollback := errors.New("rollback")
+	err = cached.WithTx(ctx, func(tx database.Queries) error {
+		_, err := tx.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{UserID: user.ID, Password: "new-hash"})
+		assert.NoError(t, err)
+		_, ok := cached.cache.get(idKey(user.ID), now)
+		assert.True(t, ok, "the invalidation waits for the commit")
+		return errRollback
+	})
+	assert.ErrorIs(t, err, errRollback)
+	_, ok = cached.cache.get(idKey(user.ID), now)
+	assert.True(t, ok)
+}
+
+func TestCachedQueriesConcurrentMisses(t *testing.T) {
+	ctx := context.Background()
+	queries := memory.NewMemoryQueries()
+	user, err := queries.InsertUser(ctx, database.InsertUserParams{Email: "bob@example.com", Username: "bob", UsernameSkeleton: "bob", Password: "hash"})
+	assert.NoError(t, err)
+
+	counting := &countingQueries{Queries: queries, release: make(chan struct{})}
+	cached := NewCachedQueries(counting, Config{Size: 10, TTL: time.Minute}, nil)
+
+	t.Logf("Running success case: Testing concurrent misses of a key share a single load")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			found, err := cached.ListUserById(ctx, database.ListUserByIdParams{ID: user.ID})
+			assert.NoError(t, err)
+			assert.Equal(t, user.ID, found.ID)
+		}()
+	}
+
+	// Gives the lookups time to pile up on the held load
+	time.Sleep(50 * time.Millisecond)
+	close(counting.release)
+	wg.Wait()
+	assert.Equal(t, int32(1), counting.lookups.Load())
+}
+
+// routingQueries records how the contexts of the lookups reaching the database are routed
+type routingQueries struct {
+	database.Queries
+	readPrimary bool
+	deadline    bool
+}
+
+func (q *routingQueries) ListUserByEmail(ctx context.Context, params database.ListUserByEmailParams) (*database.User, error) {
+	q.readPrimary = database.ReadPrimaryFromContext(ctx)
+	_, q.deadline = ctx.Deadline()
+	return q.Queries.ListUserByEmail(ctx, params)
+}
+
+func TestCachedQueriesLoadContext(t *testing.T) {
+	ctx := context.Background()
+
+	t.Logf("Running success case: Testing misses are routed like any read by default, with a deadline of their own")
+	queries := &routingQueries{Queries: memory.NewMemoryQueries()}
+	cached := NewCachedQueries(queries, Config{Size: 10, TTL: time.Minute}, nil)
+	_, err := cached.ListUserByEmail(ctx, database.ListUserByEmailParams{Email: "dave@example.com"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.False(t, queries.readPrimary)
+	assert.True(t, queries.deadline)
+
+	t.Logf("Running success case: Testing misses are read from the primary when configured")
+	queries = &routingQueries{Queries: memory.NewMemoryQueries()}
+	cached = NewCachedQueries(queries, Config{Size: 10, TTL: time.Minute, LoadFromPrimary: true}, nil)
+	_, err = cached.ListUserByEmail(ctx, database.ListUserByEmailParams{Email: "dave@example.com"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.True(t, queries.readPrimary)
+	assert.True(t, queries.deadline)
+}
+
+func TestCachedQueriesRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	queries := memory.NewMemoryQueries()
+	user, err := queries.InsertUser(ctx, database.InsertUserParams{Email: "carol@example.com", Username: "carol", UsernameSkeleton: "carol", Password: "hash"})
+	assert.NoError(t, err)
+
+	broadcaster := &fakeBroadcaster{listen: make(chan string)}
+	cached := NewCachedQueries(queries, Config{Size: 10, TTL: time.Minute}, broadcaster)
+	go cached.Run(ctx)
+
+	t.Logf("Running success case: Testing invalidations from other servers drop every key of the user")
+	_, err = cached.ListUserByEmail(ctx, database.ListUserByEmailParams{Email: "carol@example.com"})
+	assert.NoError(t, err)
+	_, err = cached.ListUserByUsername(ctx, database.ListUserByUsernameParams{Username: "carol"})
+	assert.NoError(t, err)
+	// The channel is unbuffered, so the second send only goes through once the first was applied
+	broadcaster.listen <- `{"user_id": "` + user.ID.String() + `", "keys": []}`
+	broadcaster.listen <- `{"user_id": "` + user.ID.String() + `", "keys": []}`
+	assert.Equal(t, 0, cached.cache.len())
+
+	t.Logf("Running success case: Testing the cache is purged when invalidations may have been missed")
+	_, err = cached.ListUserById(ctx, database.ListUserByIdParams{ID: user.ID})
+	assert.NoError(t, err)
+	broadcaster.listen <- ""
+	broadcaster.listen <- ""
+	assert.Equal(t, 0, cached.cache.len())
+}
@@ -0,0 +1,607 @@
+// Package databasetest holds the contract every database.Queries implementation must satisfy, so the in-memory
+// implementation used in tests keeps behaving like Postgres
+package databasetest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/vinofsteel/grpc-management/internal/database"
+)
+
+// RunQueriesContract runs the contract against the Queries returned by newQueries, which is called once per case
+// and must return an implementation without any rows
+func RunQueriesContract(t *testing.T, newQueries func(t *testing.T) database.Queries) {
+	contractTests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, q database.Queries)
+	}{
+		{name: "success case: Testing inserted users are listed by every key", run: testListUser},
+		{name: "success case: Testing users are listed newest first with limit and offset", run: testListUsers},
+		{name: "success case: Testing soft deleted users are only listed when asked for", run: testSoftDelete},
+		{name: "success case: Testing hard deleted users are removed", run: testHardDelete},
+		{name: "success case: Testing password and username updates", run: testUpdateUser},
+		{name: "success case: Testing transactions commit and roll back", run: testWithTx},
+		{name: "failure case: Testing emails, usernames and skeletons are unique, deleted users included", run: testUniqueness},
+		{name: "failure case: Testing missing users return sql.ErrNoRows", run: testNotFound},
+		{name: "success case: Testing outbox events are claimed one per aggregate, in order", run: testClaimOutboxEvents},
+		{name: "success case: Testing failed outbox events are retried and published ones cleaned up", run: testOutboxRetryAndCleanup},
+		{name: "success case: Testing every committed user write is appended to the change log", run: testUserChanges},
+		{name: "success case: Testing webhook subscriptions are listed and deleted with their deliveries", run: testWebhookSubscriptions},
+		{name: "success case: Testing webhook deliveries are claimed, retried, dead-lettered and redelivered", run: testWebhookDeliveries},
+	}
+
+	for _, tt := range contractTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Logf("Running %s", tt.name)
+			tt.run(t, context.Background(), newQueries(t))
+		})
+	}
+}
+
+func testListUser(t *testing.T, ctx context.Context, q database.Queries) {
+	inserted := insertUser(t, ctx, q, "alice")
+	assert.NotEqual(t, uuid.Nil, inserted.ID)
+	assert.False(t, inserted.CreatedAt.IsZero())
+	assert.Equal(t, "alice_skeleton", inserted.UsernameSkeleton.String)
+
+	byID, err := q.ListUserById(ctx, database.ListUserByIdParams{ID: inserted.ID})
+	assert.NoError(t, err)
+	assertSameUser(t, inserted, byID)
+
+	byEmail, err := q.ListUserByEmail(ctx, database.ListUserByEmailParams{Email: inserted.Email})
+	assert.NoError(t, err)
+	assertSameUser(t, inserted, byEmail)
+
+	byUsername, err := q.ListUserByUsername(ctx, database.ListUserByUsernameParams{Username: inserted.Username})
+	assert.NoError(t, err)
+	assertSameUser(t, inserted, byUsername)
+
+	bySkeleton, err := q.ListUserByUsernameSkeleton(ctx, database.ListUserByUsernameSkeletonParams{UsernameSkeleton: "alice_skeleton"})
+	assert.NoError(t, err)
+	assertSameUser(t, inserted, bySkeleton)
+}
+
+func testListUsers(t *testing.T, ctx context.Context, q database.Queries) {
+	first := insertUser(t, ctx, q, "first")
+	second := insertUser(t, ctx, q, "second")
+	third := insertUser(t, ctx, q, "third")
+
+	users, err := q.ListUsers(ctx, database.ListUsersParams{})
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{third.ID, second.ID, first.ID}, userIDs(users))
+
+	users, err = q.ListUsers(ctx, database.ListUsersParams{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{third.ID, second.ID}, userIDs(users))
+
+	users, err = q.ListUsers(ctx, database.ListUsersParams{Limit: 2, Offset: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{first.ID}, userIDs(users))
+
+	users, err = q.ListUsers(ctx, database.ListUsersParams{Offset: 5})
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+}
+
+func testSoftDelete(t *testing.T, ctx context.Context, q database.Queries) {
+	kept := insertUser(t, ctx, q, "kept")
+	deleted := insertUser(t, ctx, q, "deleted")
+	assert.NoError(t, q.DeleteUser(ctx, database.DeleteUserParams{ID: deleted.ID}))
+
+	_, err := q.ListUserById(ctx, database.ListUserByIdParams{ID: deleted.ID})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = q.ListUserByEmail(ctx, database.ListUserByEmailParams{Email: deleted.Email})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = q.ListUserByUsername(ctx, database.ListUserByUsernameParams{Username: deleted.Username})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = q.ListUserByUsernameSkeleton(ctx, database.ListUserByUsernameSkeletonParams{UsernameSkeleton: "deleted_skeleton"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	user, err := q.ListUserById(ctx, database.ListUserByIdParams{ID: deleted.ID, ListDeleted: true})
+	assert.NoError(t, err)
+	assertSameUser(t, deleted, user)
+
+	users, err := q.ListUsers(ctx, database.ListUsersParams{})
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{kept.ID}, userIDs(users))
+
+	users, err = q.ListUsers(ctx, database.ListUsersParams{ListDeleted: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{deleted.ID, kept.ID}, userIDs(users))
+
+	// Deleted users can't be renamed
+	_, err = q.UpdateUserUsername(ctx, database.UpdateUserUsernameParams{UserID: deleted.ID, Username: "renamed", UsernameSkeleton: "renamed"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testHardDelete(t *testing.T, ctx context.Context, q database.Queries) {
+	user := insertUser(t, ctx, q, "hard")
+	assert.NoError(t, q.DeleteUser(ctx, database.DeleteUserParams{ID: user.ID, Hard: true}))
+
+	_, err := q.ListUserById(ctx, database.ListUserByIdParams{ID: user.ID, ListDeleted: true})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// The email and username are free again
+	insertUser(t, ctx, q, "hard")
+
+	// Deleting a missing user isn't an error
+	assert.NoError(t, q.DeleteUser(ctx, database.DeleteUserParams{ID: uuid.New()}))
+	assert.NoError(t, q.DeleteUser(ctx, database.DeleteUserParams{ID: uuid.New(), Hard: true}))
+}
+
+func testUpdateUser(t *testing.T, ctx context.Context, q database.Queries) {
+	user := insertUser(t, ctx, q, "bob")
+
+	updated, err := q.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{UserID: user.ID, Password: "new-hash"})
+	assert.NoError(t, err)
+	assert.Equal(t, "new-hash", updated.Password)
+	assert.False(t, updated.UpdatedAt.Before(user.UpdatedAt))
+
+	renamed, err := q.UpdateUserUsername(ctx, database.UpdateUserUsernameParams{UserID: user.ID, Username: "robert", UsernameSkeleton: "robert_skeleton"})
+	assert.NoError(t, err)
+	assert.Equal(t, "robert", renamed.Username)
+	assert.Equal(t, "robert_skeleton", renamed.UsernameSkeleton.String)
+	assert.Equal(t, "new-hash", renamed.Password)
+
+	_, err = q.ListUserByUsername(ctx, database.ListUserByUsernameParams{Username: "bob"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Keeping one's own username doesn't conflict with itself
+	_, err = q.UpdateUserUsername(ctx, database.UpdateUserUsernameParams{UserID: user.ID, Username: "robert", UsernameSkeleton: "robert_skeleton"})
+	assert.NoError(t, err)
+}
+
+func testWithTx(t *testing.T, ctx context.Context, q database.Queries) {
+	errRollback := errors.New("rollback")
+
+	err := q.WithTx(ctx, func(tx database.Queries) error {
+		insertUser(t, ctx, tx, "rolled_back")
+		return errRollback
+	})
+	assert.ErrorIs(t, err, errRollback)
+
+	_, err = q.ListUserByUsername(ctx, database.ListUserByUsernameParams{Username: "rolled_back"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	assert.Panics(t, func() {
+		_ = q.WithTx(ctx, func(tx database.Queries) error {
+			insertUser(t, ctx, tx, "panicked")
+			panic("boom")
+		})
+	})
+
+	_, err = q.ListUserByUsername(ctx, database.ListUserByUsernameParams{Username: "panicked"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	err = q.WithTx(ctx, func(tx database.Queries) error {
+		user := insertUser(t, ctx, tx, "committed")
+
+		// Nested calls join the transaction and see its writes
+		return tx.WithTx(ctx, func(nested database.Queries) error {
+			_, err := nested.ListUserById(ctx, database.ListUserByIdParams{ID: user.ID})
+			return err
+		})
+	})
+	assert.NoError(t, err)
+
+	_, err = q.ListUserByUsername(ctx, database.ListUserByUsernameParams{Username: "committed"})
+	assert.NoError(t, err)
+}
+
+func testUniqueness(t *testing.T, ctx context.Context, q database.Queries) {
+	user := insertUser(t, ctx, q, "carol")
+	other := insertUser(t, ctx, q, "dave")
+	deleted := insertUser(t, ctx, q, "erin")
+	assert.NoError(t, q.DeleteUser(ctx, database.DeleteUserParams{ID: deleted.ID}))
+
+	conflicts := []database.InsertUserParams{
+		{Email: user.Email, Username: "unique_1", UsernameSkeleton: "unique_1", Password: "hash"},
+		{Email: "unique_2@example.com", Username: user.Username, UsernameSkeleton: "unique_2", Password: "hash"},
+		{Email: "unique_3@example.com", Username: "unique_3", UsernameSkeleton: "carol_skeleton", Password: "hash"},
+		{Email: deleted.Email, Username: "unique_4", UsernameSkeleton: "unique_4", Password: "hash"},
+		{Email: "unique_5@example.com", Username: deleted.Username, UsernameSkeleton: "unique_5", Password: "hash"},
+	}
+	for _, params := range conflicts {
+		_, err := q.InsertUser(ctx, params)
+		assert.ErrorIs(t, err, database.ErrUniqueViolation, "inserting %+v", params)
+	}
+
+	_, err := q.UpdateUserUsername(ctx, database.UpdateUserUsernameParams{UserID: other.ID, Username: user.Username, UsernameSkeleton: "unique_6"})
+	assert.ErrorIs(t, err, database.ErrUniqueViolation)
+
+	_, err = q.UpdateUserUsername(ctx, database.UpdateUserUsernameParams{UserID: other.ID, Username: "unique_7", UsernameSkeleton: "erin_skeleton"})
+	assert.ErrorIs(t, err, database.ErrUniqueViolation)
+
+	// The failed update left the user untouched
+	unchanged, err := q.ListUserById(ctx, database.ListUserByIdParams{ID: other.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, "dave", unchanged.Username)
+}
+
+func testNotFound(t *testing.T, ctx context.Context, q database.Queries) {
+	missing := uuid.New()
+
+	_, err := q.ListUserById(ctx, database.ListUserByIdParams{ID: missing, ListDeleted: true})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = q.ListUserByEmail(ctx, database.ListUserByEmailParams{Email: "missing@example.com"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = q.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{UserID: missing, Password: "hash"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = q.UpdateUserUsername(ctx, database.UpdateUserUsernameParams{UserID: missing, Username: "missing", UsernameSkeleton: "missing"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	users, err := q.ListUsers(ctx, database.ListUsersParams{ListDeleted: true})
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+}
+
+func testClaimOutboxEvents(t *testing.T, ctx context.Context, q database.Queries) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	alice, bob := uuid.New(), uuid.New()
+	first := insertOutboxEvent(t, ctx, q, alice, now)
+	second := insertOutboxEvent(t, ctx, q, alice, now)
+	third := insertOutboxEvent(t, ctx, q, bob, now)
+	assert.Less(t, first.ID, second.ID)
+	assert.Equal(t, []byte("payload"), first.Payload)
+
+	// Only the oldest event of each aggregate is claimed
+	claim := database.ClaimOutboxEventsParams{Now: now, LeaseUntil: now.Add(time.Minute), Limit: 10}
+	events, err := q.ClaimOutboxEvents(ctx, claim)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{first.ID, third.ID}, outboxIDs(events))
+
+	// Leased events aren't claimed again, and neither are the events queued behind them
+	events, err = q.ClaimOutboxEvents(ctx, claim)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	assert.NoError(t, q.MarkOutboxEventPublished(ctx, database.MarkOutboxEventPublishedParams{ID: first.ID, PublishedAt: now}))
+	events, err = q.ClaimOutboxEvents(ctx, claim)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{second.ID}, outboxIDs(events))
+
+	// An expired lease makes the event claimable again
+	claim.Now = claim.LeaseUntil
+	claim.LeaseUntil = claim.Now.Add(time.Minute)
+	claim.Limit = 1
+	events, err = q.ClaimOutboxEvents(ctx, claim)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{second.ID}, outboxIDs(events))
+}
+
+func testOutboxRetryAndCleanup(t *testing.T, ctx context.Context, q database.Queries) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	failed := insertOutboxEvent(t, ctx, q, uuid.New(), now)
+	published := insertOutboxEvent(t, ctx, q, uuid.New(), now)
+
+	claim := database.ClaimOutboxEventsParams{Now: now, LeaseUntil: now.Add(time.Minute), Limit: 10}
+	_, err := q.ClaimOutboxEvents(ctx, claim)
+	assert.NoError(t, err)
+
+	retryAt := now.Add(time.Second)
+	assert.NoError(t, q.MarkOutboxEventFailed(ctx, database.MarkOutboxEventFailedParams{ID: failed.ID, Error: "unreachable", NextAttemptAt: retryAt}))
+	assert.NoError(t, q.MarkOutboxEventPublished(ctx, database.MarkOutboxEventPublishedParams{ID: published.ID, PublishedAt: now}))
+
+	events, err := q.ClaimOutboxEvents(ctx, claim)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	claim.Now = retryAt
+	events, err = q.ClaimOutboxEvents(ctx, claim)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, failed.ID, events[0].ID)
+		assert.Equal(t, int32(1), events[0].Attempts)
+		assert.Equal(t, "unreachable", events[0].LastError.String)
+	}
+
+	// Only published events older than the cutoff are deleted
+	deleted, err := q.DeleteOutboxEvents(ctx, database.DeleteOutboxEventsParams{PublishedBefore: now})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), deleted)
+
+	deleted, err = q.DeleteOutboxEvents(ctx, database.DeleteOutboxEventsParams{PublishedBefore: now.Add(time.Second)})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
+
+func testUserChanges(t *testing.T, ctx context.Context, q database.Queries) {
+	alice := insertUser(t, ctx, q, "alice")
+	_, err := q.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{UserID: alice.ID, Password: "new-hash"})
+	assert.NoError(t, err)
+	_, err = q.UpdateUserUsername(ctx, database.UpdateUserUsernameParams{UserID: alice.ID, Username: "alicia", UsernameSkeleton: "alicia_skeleton"})
+	assert.NoError(t, err)
+	assert.NoError(t, q.DeleteUser(ctx, database.DeleteUserParams{ID: alice.ID}))
+	assert.NoError(t, q.DeleteUser(ctx, database.DeleteUserParams{ID: alice.ID}))
+	assert.NoError(t, q.DeleteUser(ctx, database.DeleteUserParams{ID: alice.ID, Hard: true}))
+
+	// Writes rolled back leave nothing behind
+	err = q.WithTx(ctx, func(tx database.Queries) error {
+		insertUser(t, ctx, tx, "bob")
+		return errors.New("rollback")
+	})
+	assert.Error(t, err)
+
+	_, err = q.SequenceUserChanges(ctx)
+	assert.NoError(t, err)
+
+	changes, err := q.ListUserChanges(ctx, database.ListUserChangesParams{})
+	assert.NoError(t, err)
+	if !assert.Len(t, changes, 6) {
+		return
+	}
+
+	var operations []string
+	for i, change := range changes {
+		operations = append(operations, change.Operation)
+		assert.Equal(t, alice.ID, change.UserID)
+		if i > 0 {
+			assert.Greater(t, change.Sequence, changes[i-1].Sequence)
+		}
+	}
+	assert.Equal(t, []string{
+		database.UserChangeCreated,
+		database.UserChangeUpdated,
+		database.UserChangeUpdated,
+		database.UserChangeDeleted,
+		database.UserChangeUpdated,
+		database.UserChangeDeleted,
+	}, operations)
+	assert.Equal(t, "alice", changes[0].Username)
+	assert.Equal(t, "alicia", changes[2].Username)
+	assert.False(t, changes[2].DeletedAt.Valid)
+	assert.True(t, changes[3].DeletedAt.Valid)
+	assert.Equal(t, alice.Email, changes[5].Email)
+
+	page, err := q.ListUserChanges(ctx, database.ListUserChangesParams{AfterSequence: changes[2].Sequence, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{changes[3].Sequence, changes[4].Sequence}, userChangeSequences(page))
+
+	bounds, err := q.ListUserChangesBounds(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &database.UserChangesBounds{First: changes[0].Sequence, Last: changes[5].Sequence}, bounds)
+
+	// The newest change outlives the retention
+	deleted, err := q.DeleteUserChanges(ctx, database.DeleteUserChangesParams{ChangedBefore: time.Now().UTC().Add(time.Hour)})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), deleted)
+
+	bounds, err = q.ListUserChangesBounds(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &database.UserChangesBounds{First: changes[5].Sequence, Last: changes[5].Sequence}, bounds)
+}
+
+func testWebhookSubscriptions(t *testing.T, ctx context.Context, q database.Queries) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	first := insertWebhookSubscription(t, ctx, q, now)
+	second := insertWebhookSubscription(t, ctx, q, now.Add(time.Second))
+	assert.Equal(t, database.EventTypes{"user.created", "user.deleted"}, first.EventTypes)
+
+	subscriptions, err := q.ListWebhookSubscriptions(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []*database.WebhookSubscription{first, second}, subscriptions)
+
+	byID, err := q.ListWebhookSubscriptionById(ctx, database.ListWebhookSubscriptionByIdParams{ID: second.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, second, byID)
+
+	delivery := insertWebhookDelivery(t, ctx, q, first.ID, uuid.New(), now)
+	assert.NoError(t, q.DeleteWebhookSubscription(ctx, database.DeleteWebhookSubscriptionParams{ID: first.ID}))
+
+	_, err = q.ListWebhookSubscriptionById(ctx, database.ListWebhookSubscriptionByIdParams{ID: first.ID})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = q.ListWebhookDeliveryById(ctx, database.ListWebhookDeliveryByIdParams{ID: delivery})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	err = q.DeleteWebhookSubscription(ctx, database.DeleteWebhookSubscriptionParams{ID: first.ID})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testWebhookDeliveries(t *testing.T, ctx context.Context, q database.Queries) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	subscription := insertWebhookSubscription(t, ctx, q, now)
+	eventID := uuid.New()
+	first := insertWebhookDelivery(t, ctx, q, subscription.ID, eventID, now)
+	second := insertWebhookDelivery(t, ctx, q, subscription.ID, uuid.New(), now.Add(time.Second))
+
+	// The same event isn't delivered twice to a subscription
+	insertWebhookDelivery(t, ctx, q, subscription.ID, eventID, now)
+	deliveries, err := q.ListWebhookDeliveries(ctx, database.ListWebhookDeliveriesParams{SubscriptionID: subscription.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{second, first}, webhookDeliveryIDs(deliveries))
+
+	claim := database.ClaimWebhookDeliveriesParams{Now: now.Add(time.Second), LeaseUntil: now.Add(time.Minute), Limit: 10}
+	deliveries, err = q.ClaimWebhookDeliveries(ctx, claim)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{first, second}, webhookDeliveryIDs(deliveries))
+	if assert.Len(t, deliveries, 2) {
+		assert.Equal(t, database.WebhookDeliveryPending, deliveries[0].Status)
+		assert.Equal(t, []byte(`{"id":"event"}`), deliveries[0].Payload)
+	}
+
+	// Leased deliveries aren't claimed again
+	deliveries, err = q.ClaimWebhookDeliveries(ctx, claim)
+	assert.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	retryAt := now.Add(2 * time.Minute)
+	assert.NoError(t, q.RecordWebhookDeliveryAttempt(ctx, database.RecordWebhookDeliveryAttemptParams{
+		ID:            first,
+		Status:        database.WebhookDeliveryFailed,
+		ResponseCode:  sql.NullInt32{Int32: 503, Valid: true},
+		LatencyMs:     12,
+		Error:         sql.NullString{String: "responded with status 503", Valid: true},
+		AttemptedAt:   now,
+		NextAttemptAt: retryAt,
+	}))
+	assert.NoError(t, q.RecordWebhookDeliveryAttempt(ctx, database.RecordWebhookDeliveryAttemptParams{
+		ID:            second,
+		Status:        database.WebhookDeliveryDead,
+		Error:         sql.NullString{String: "connection refused", Valid: true},
+		AttemptedAt:   now,
+		NextAttemptAt: now,
+	}))
+
+	// Failed deliveries are claimed again once due, dead ones never are
+	claim.Now = retryAt
+	claim.LeaseUntil = retryAt.Add(time.Minute)
+	deliveries, err = q.ClaimWebhookDeliveries(ctx, claim)
+	assert.NoError(t, err)
+	if assert.Len(t, deliveries, 1) {
+		assert.Equal(t, first, deliveries[0].ID)
+		assert.Equal(t, database.WebhookDeliveryFailed, deliveries[0].Status)
+		assert.Equal(t, int32(1), deliveries[0].Attempts)
+		assert.Equal(t, int32(503), deliveries[0].ResponseCode.Int32)
+		assert.Equal(t, int64(12), deliveries[0].LatencyMs.Int64)
+		assert.True(t, now.Equal(deliveries[0].LastAttemptAt.Time))
+	}
+
+	dead, err := q.ListWebhookDeliveries(ctx, database.ListWebhookDeliveriesParams{SubscriptionID: subscription.ID, Status: database.WebhookDeliveryDead})
+	assert.NoError(t, err)
+	if assert.Len(t, dead, 1) {
+		assert.False(t, dead[0].ResponseCode.Valid)
+	}
+
+	redelivered, err := q.RedeliverWebhookDelivery(ctx, database.RedeliverWebhookDeliveryParams{ID: second, Now: retryAt})
+	assert.NoError(t, err)
+	assert.Equal(t, database.WebhookDeliveryPending, redelivered.Status)
+	assert.Equal(t, int32(0), redelivered.Attempts)
+	assert.Equal(t, "connection refused", redelivered.LastError.String, "the outcome of the last attempt is kept")
+
+	deliveries, err = q.ClaimWebhookDeliveries(ctx, claim)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{second}, webhookDeliveryIDs(deliveries))
+
+	_, err = q.RedeliverWebhookDelivery(ctx, database.RedeliverWebhookDeliveryParams{ID: uuid.New(), Now: now})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+// Utilities
+func insertWebhookSubscription(t *testing.T, ctx context.Context, q database.Queries, createdAt time.Time) *database.WebhookSubscription {
+	t.Helper()
+
+	subscription, err := q.InsertWebhookSubscription(ctx, database.InsertWebhookSubscriptionParams{
+		ID:         uuid.New(),
+		URL:        "https://example.com/webhooks",
+		EventTypes: database.EventTypes{"user.created", "user.deleted"},
+		Secret:     "secret",
+		CreatedAt:  createdAt,
+	})
+	if err != nil {
+		t.Fatalf("error inserting webhook subscription: %v", err)
+	}
+
+	return subscription
+}
+
+// insertWebhookDelivery returns the ID of the delivery it inserted, which is ignored when it's a duplicate
+func insertWebhookDelivery(t *testing.T, ctx context.Context, q database.Queries, subscriptionID, eventID uuid.UUID, createdAt time.Time) uuid.UUID {
+	t.Helper()
+
+	id := uuid.New()
+	if err := q.InsertWebhookDelivery(ctx, database.InsertWebhookDeliveryParams{
+		ID:             id,
+		SubscriptionID: subscriptionID,
+		EventID:        eventID,
+		EventType:      "user.created",
+		Payload:        []byte(`{"id":"event"}`),
+		CreatedAt:      createdAt,
+	}); err != nil {
+		t.Fatalf("error inserting webhook delivery: %v", err)
+	}
+
+	return id
+}
+
+func webhookDeliveryIDs(deliveries []*database.WebhookDelivery) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		ids = append(ids, delivery.ID)
+	}
+
+	return ids
+}
+
+func insertOutboxEvent(t *testing.T, ctx context.Context, q database.Queries, aggregateID uuid.UUID, createdAt time.Time) *database.OutboxEvent {
+	t.Helper()
+
+	event, err := q.InsertOutboxEvent(ctx, database.InsertOutboxEventParams{
+		EventID:      uuid.New(),
+		AggregateID:  aggregateID,
+		EventType:    "user.created",
+		EventVersion: 1,
+		Payload:      []byte("payload"),
+		CreatedAt:    createdAt,
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	return event
+}
+
+func userChangeSequences(changes []*database.UserChange) []int64 {
+	sequences := make([]int64, 0, len(changes))
+	for _, change := range changes {
+		sequences = append(sequences, change.Sequence)
+	}
+
+	return sequences
+}
+
+func outboxIDs(events []*database.OutboxEvent) []int64 {
+	ids := make([]int64, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+
+	return ids
+}
+
+// insertUser inserts a user whose email and skeleton are derived from username
+func insertUser(t *testing.T, ctx context.Context, q database.Queries, username string) *database.User {
+	t.Helper()
+
+	user, err := q.InsertUser(ctx, database.InsertUserParams{
+		Email:            fmt.Sprintf("%s@example.com", username),
+		Username:         username,
+		UsernameSkeleton: username + "_skeleton",
+		Password:         "hash",
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	return user
+}
+
+// assertSameUser compares the columns every lookup returns
+func assertSameUser(t *testing.T, want, got *database.User) {
+	t.Helper()
+
+	if !assert.NotNil(t, got) {
+		return
+	}
+	assert.Equal(t, want.ID, got.ID)
+	assert.Equal(t, want.Email, got.Email)
+	assert.Equal(t, want.Username, got.Username)
+	assert.Equal(t, want.UsernameSkeleton, got.UsernameSkeleton)
+	assert.Equal(t, want.Password, got.Password)
+	assert.True(t, want.CreatedAt.Equal(got.CreatedAt))
+}
+
+func userIDs(users []*database.User) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+
+	return ids
+}
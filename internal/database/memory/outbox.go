@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/vinofsteel/grpc-management/internal/database"
+)
+
+func (q *MemoryQueries) InsertOutboxEvent(ctx context.Context, params database.InsertOutboxEventParams) (*database.OutboxEvent, error) {
+	slog.InfoContext(ctx, "Inserting outbox event", "event_id", params.EventID, "event_type", params.EventType, "aggregate_id", params.AggregateID, "layer", "repository", "driver", "memory")
+	defer q.lock()()
+
+	if slices.ContainsFunc(q.data.outbox, func(event database.OutboxEvent) bool { return event.EventID == params.EventID }) {
+		return nil, fmt.Errorf("%w: outbox: event %s already exists", database.ErrUniqueViolation, params.EventID)
+	}
+
+	q.data.outboxSeq++
+	event := database.OutboxEvent{
+		ID:            q.data.outboxSeq,
+		EventID:       params.EventID,
+		AggregateID:   params.AggregateID,
+		EventType:     params.EventType,
+		EventVersion:  params.EventVersion,
+		Payload:       slices.Clone(params.Payload),
+		CreatedAt:     params.CreatedAt,
+		NextAttemptAt: params.CreatedAt,
+	}
+
+	q.data.outbox = append(q.data.outbox, event)
+	return &event, nil
+}
+
+func (q *MemoryQueries) ClaimOutboxEvents(ctx context.Context, params database.ClaimOutboxEventsParams) ([]*database.OutboxEvent, error) {
+	defer q.lock()()
+
+	// Events are kept in ID order, so the first unpublished event of an aggregate is the oldest
+	var events []*database.OutboxEvent
+	seen := make(map[uuid.UUID]bool)
+	for i := range q.data.outbox {
+		if params.Limit > 0 && len(events) >= int(params.Limit) {
+			break
+		}
+
+		event := &q.data.outbox[i]
+		if event.PublishedAt.Valid || seen[event.AggregateID] {
+			continue
+		}
+		seen[event.AggregateID] = true
+
+		if event.NextAttemptAt.After(params.Now) {
+			continue
+		}
+
+		event.NextAttemptAt = params.LeaseUntil
+		claimed := *event
+		events = append(events, &claimed)
+	}
+
+	return events, nil
+}
+
+func (q *MemoryQueries) MarkOutboxEventPublished(ctx context.Context, params database.MarkOutboxEventPublishedParams) error {
+	defer q.lock()()
+
+	if event := q.findOutboxEvent(params.ID); event != nil {
+		event.PublishedAt = sql.NullTime{Time: params.PublishedAt, Valid: true}
+		event.Attempts++
+		event.LastError = sql.NullString{}
+	}
+
+	return nil
+}
+
+func (q *MemoryQueries) MarkOutboxEventFailed(ctx context.Context, params database.MarkOutboxEventFailedParams) error {
+	defer q.lock()()
+
+	if event := q.findOutboxEvent(params.ID); event != nil && !event.PublishedAt.Valid {
+		event.Attempts++
+		event.LastError = sql.NullString{String: params.Error, Valid: true}
+		event.NextAttemptAt = params.NextAttemptAt
+	}
+
+	return nil
+}
+
+func (q *MemoryQueries) DeleteOutboxEvents(ctx context.Context, params database.DeleteOutboxEventsParams) (int64, error) {
+	defer q.lock()()
+
+	count := len(q.data.outbox)
+	q.data.outbox = slices.DeleteFunc(q.data.outbox, func(event database.OutboxEvent) bool {
+		return event.PublishedAt.Valid && event.PublishedAt.Time.Before(params.PublishedBefore)
+	})
+
+	return int64(count - len(q.data.outbox)), nil
+}
+
+// Utilities
+func (q *MemoryQueries) findOutboxEvent(id int64) *database.OutboxEvent {
+	i := slices.IndexFunc(q.data.outbox, func(event database.OutboxEvent) bool { return event.ID == id })
+	if i < 0 {
+		return nil
+	}
+
+	return &q.data.outbox[i]
+}
@@ -0,0 +1,95 @@
+// Package memory implements database.Queries in memory, with the semantics of the Postgres implementation, for tests
+// and local runs without a database. Nothing is persisted.
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vinofsteel/grpc-management/internal/database"
+)
+
+type MemoryQueries struct {
+	// mu guards data, nil inside a transaction, which holds it until it ends
+	mu   *sync.Mutex
+	data *tables
+	now  func() time.Time
+}
+
+// tables holds the rows of every repository
+type tables struct {
+	users  []database.User
+	outbox []database.OutboxEvent
+	// outboxSeq is the ID of the last outbox event, like the sequence of the Postgres table
+	outboxSeq   int64
+	userChanges []database.UserChange
+	// userChangeSeq is the sequence of the last user change
+	userChangeSeq int64
+
+	webhookSubscriptions []database.WebhookSubscription
+	webhookDeliveries    []database.WebhookDelivery
+}
+
+func NewMemoryQueries() *MemoryQueries {
+	return &MemoryQueries{
+		mu:   &sync.Mutex{},
+		data: &tables{},
+		now:  time.Now,
+	}
+}
+
+// WithTx runs fn against a copy of the tables, which replaces them when fn returns nil. Transactions hold the lock
+// for their whole run, so they're serializable and never need to be retried.
+func (q *MemoryQueries) WithTx(ctx context.Context, fn func(q database.Queries) error, options ...database.TxOption) error {
+	// Already inside a transaction, which fn joins
+	if q.mu == nil {
+		return fn(q)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	tx := &MemoryQueries{
+		data: q.data.clone(),
+		now:  q.now,
+	}
+
+	// On a panic the copy is dropped, leaving the tables untouched
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	q.data = tx.data
+	return nil
+}
+
+// Utilities
+// lock locks the tables outside transactions and returns the matching unlock
+func (q *MemoryQueries) lock() func() {
+	if q.mu == nil {
+		return func() {}
+	}
+
+	q.mu.Lock()
+	return q.mu.Unlock
+}
+
+// timestamp matches the microsecond precision of Postgres timestamps
+func (q *MemoryQueries) timestamp() time.Time {
+	return q.now().UTC().Truncate(time.Microsecond)
+}
+
+func (t *tables) clone() *tables {
+	return &tables{
+		users:     append([]database.User(nil), t.users...),
+		outbox:    append([]database.OutboxEvent(nil), t.outbox...),
+		outboxSeq: t.outboxSeq,
+
+		userChanges:   append([]database.UserChange(nil), t.userChanges...),
+		userChangeSeq: t.userChangeSeq,
+
+		webhookSubscriptions: append([]database.WebhookSubscription(nil), t.webhookSubscriptions...),
+		webhookDeliveries:    append([]database.WebhookDelivery(nil), t.webhookDeliveries...),
+	}
+}
@@ -0,0 +1,14 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/vinofsteel/grpc-management/internal/database"
+	"github.com/vinofsteel/grpc-management/internal/database/databasetest"
+)
+
+func TestMemoryQueriesContract(t *testing.T) {
+	databasetest.RunQueriesContract(t, func(t *testing.T) database.Queries {
+		return NewMemoryQueries()
+	})
+}
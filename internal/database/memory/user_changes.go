@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/vinofsteel/grpc-management/internal/database"
+)
+
+// SequenceUserChanges has nothing to do, changes are sequenced as they're recorded under the lock
+func (q *MemoryQueries) SequenceUserChanges(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (q *MemoryQueries) ListUserChanges(ctx context.Context, params database.ListUserChangesParams) ([]*database.UserChange, error) {
+	defer q.lock()()
+
+	// Changes are kept in sequence order
+	var changes []*database.UserChange
+	for _, change := range q.data.userChanges {
+		if params.Limit > 0 && len(changes) >= int(params.Limit) {
+			break
+		}
+
+		if change.Sequence > params.AfterSequence {
+			changes = append(changes, &change)
+		}
+	}
+
+	return changes, nil
+}
+
+func (q *MemoryQueries) ListUserChangesBounds(ctx context.Context) (*database.UserChangesBounds, error) {
+	defer q.lock()()
+
+	if len(q.data.userChanges) == 0 {
+		return &database.UserChangesBounds{}, nil
+	}
+
+	return &database.UserChangesBounds{
+		First: q.data.userChanges[0].Sequence,
+		Last:  q.data.userChanges[len(q.data.userChanges)-1].Sequence,
+	}, nil
+}
+
+func (q *MemoryQueries) DeleteUserChanges(ctx context.Context, params database.DeleteUserChangesParams) (int64, error) {
+	defer q.lock()()
+
+	if len(q.data.userChanges) == 0 {
+		return 0, nil
+	}
+
+	count := len(q.data.userChanges)
+	newest := q.data.userChanges[count-1].Sequence
+	q.data.userChanges = slices.DeleteFunc(q.data.userChanges, func(change database.UserChange) bool {
+		return change.ChangedAt.Before(params.ChangedBefore) && change.Sequence < newest
+	})
+
+	return int64(count - len(q.data.userChanges)), nil
+}
+
+// Utilities
+// recordUserChange appends user, as operation left it, to the change log, like the trigger of the Postgres table.
+// The caller holds the lock.
+func (q *MemoryQueries) recordUserChange(operation string, user database.User) {
+	q.data.userChangeSeq++
+	q.data.userChanges = append(q.data.userChanges, database.UserChange{
+		Sequence:  q.data.userChangeSeq,
+		Operation: operation,
+		UserID:    user.ID,
+		Email:     user.Email,
+		Username:  user.Username,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		DeletedAt: user.DeletedAt,
+		ChangedAt: q.timestamp(),
+	})
+}
@@ -0,0 +1,204 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/vinofsteel/grpc-management/internal/database"
+)
+
+func (q *MemoryQueries) ListUserByEmail(ctx context.Context, params database.ListUserByEmailParams) (*database.User, error) {
+	slog.InfoContext(ctx, "Listing user by email", "email", params.Email, "layer", "repository", "driver", "memory")
+	defer q.lock()()
+
+	return q.findUser(params.ListDeleted, func(user *database.User) bool { return user.Email == params.Email })
+}
+
+func (q *MemoryQueries) ListUserByUsername(ctx context.Context, params database.ListUserByUsernameParams) (*database.User, error) {
+	slog.InfoContext(ctx, "Listing user by username", "username", params.Username, "layer", "repository", "driver", "memory")
+	defer q.lock()()
+
+	return q.findUser(params.ListDeleted, func(user *database.User) bool { return user.Username == params.Username })
+}
+
+func (q *MemoryQueries) ListUserByUsernameSkeleton(ctx context.Context, params database.ListUserByUsernameSkeletonParams) (*database.User, error) {
+	slog.InfoContext(ctx, "Listing user by username skeleton", "username_skeleton", params.UsernameSkeleton, "layer", "repository", "driver", "memory")
+	defer q.lock()()
+
+	return q.findUser(params.ListDeleted, func(user *database.User) bool {
+		return user.UsernameSkeleton.Valid && user.UsernameSkeleton.String == params.UsernameSkeleton
+	})
+}
+
+func (q *MemoryQueries) ListUserById(ctx context.Context, params database.ListUserByIdParams) (*database.User, error) {
+	slog.InfoContext(ctx, "Listing user by id", "id", params.ID, "layer", "repository", "driver", "memory")
+	defer q.lock()()
+
+	return q.findUser(params.ListDeleted, func(user *database.User) bool { return user.ID == params.ID })
+}
+
+func (q *MemoryQueries) ListUsers(ctx context.Context, params database.ListUsersParams) ([]*database.User, error) {
+	slog.InfoContext(ctx, "Listing users", "limit", params.Limit, "offset", params.Offset, "list_deleted", params.ListDeleted, "layer", "repository", "driver", "memory")
+	defer q.lock()()
+
+	// Newest first, rows created at the same time in reverse insertion order
+	var users []*database.User
+	for i := len(q.data.users) - 1; i >= 0; i-- {
+		if params.ListDeleted || !q.data.users[i].DeletedAt.Valid {
+			user := q.data.users[i]
+			users = append(users, &user)
+		}
+	}
+	slices.SortStableFunc(users, func(a, b *database.User) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	if params.Offset > 0 {
+		users = users[min(int(params.Offset), len(users)):]
+	}
+
+	if params.Limit > 0 {
+		users = users[:min(int(params.Limit), len(users))]
+	}
+
+	return users, nil
+}
+
+func (q *MemoryQueries) InsertUser(ctx context.Context, params database.InsertUserParams) (*database.User, error) {
+	slog.InfoContext(ctx, "Creating user", "email", params.Email, "username", params.Username, "layer", "repository", "driver", "memory")
+	defer q.lock()()
+
+	now := q.timestamp()
+	user := database.User{
+		ID:               uuid.New(),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		Email:            params.Email,
+		Username:         params.Username,
+		UsernameSkeleton: sql.NullString{String: params.UsernameSkeleton, Valid: true},
+		Password:         params.Password,
+	}
+
+	if err := q.checkUserConstraints(user); err != nil {
+		slog.ErrorContext(ctx, "Error inserting user", "error", err, "email", params.Email, "username", params.Username)
+		return nil, err
+	}
+
+	q.data.users = append(q.data.users, user)
+	q.recordUserChange(database.UserChangeCreated, user)
+	return &user, nil
+}
+
+func (q *MemoryQueries) UpdateUserPassword(ctx context.Context, params database.UpdateUserPasswordParams) (*database.User, error) {
+	slog.InfoContext(ctx, "Updating user password", "user_id", params.UserID, "layer", "repository", "driver", "memory")
+	defer q.lock()()
+
+	// Like the Postgres query, deleted users are updated as well
+	i := slices.IndexFunc(q.data.users, func(user database.User) bool { return user.ID == params.UserID })
+	if i < 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	q.data.users[i].Password = params.Password
+	q.data.users[i].UpdatedAt = q.timestamp()
+
+	user := q.data.users[i]
+	q.recordUserChange(database.UserChangeUpdated, user)
+	return &user, nil
+}
+
+func (q *MemoryQueries) UpdateUserUsername(ctx context.Context, params database.UpdateUserUsernameParams) (*database.User, error) {
+	slog.InfoContext(ctx, "Updating user username", "user_id", params.UserID, "username", params.Username, "layer", "repository", "driver", "memory")
+	defer q.lock()()
+
+	i := slices.IndexFunc(q.data.users, func(user database.User) bool {
+		return user.ID == params.UserID && !user.DeletedAt.Valid
+	})
+	if i < 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	user := q.data.users[i]
+	user.Username = params.Username
+	user.UsernameSkeleton = sql.NullString{String: params.UsernameSkeleton, Valid: true}
+	user.UpdatedAt = q.timestamp()
+
+	if err := q.checkUserConstraints(user); err != nil {
+		slog.ErrorContext(ctx, "Error updating user username", "error", err, "user_id", params.UserID)
+		return nil, err
+	}
+
+	q.data.users[i] = user
+	q.recordUserChange(database.UserChangeUpdated, user)
+	return &user, nil
+}
+
+func (q *MemoryQueries) DeleteUser(ctx context.Context, params database.DeleteUserParams) error {
+	slog.InfoContext(ctx, "Deleting user", "id", params.ID, "hard", params.Hard, "layer", "repository", "driver", "memory")
+	defer q.lock()()
+
+	// Deleting a user that doesn't exist isn't an error, as with the Postgres statements
+	i := slices.IndexFunc(q.data.users, func(user database.User) bool { return user.ID == params.ID })
+	if i < 0 {
+		return nil
+	}
+
+	if params.Hard {
+		q.recordUserChange(database.UserChangeDeleted, q.data.users[i])
+		q.data.users = slices.Delete(q.data.users, i, i+1)
+		return nil
+	}
+
+	// Like the Postgres trigger, deleting a deleted user again is an update
+	operation := database.UserChangeDeleted
+	if q.data.users[i].DeletedAt.Valid {
+		operation = database.UserChangeUpdated
+	}
+
+	q.data.users[i].DeletedAt = sql.NullTime{Time: q.timestamp(), Valid: true}
+	q.recordUserChange(operation, q.data.users[i])
+	return nil
+}
+
+// Utilities
+// findUser returns a copy of the first user matching, skipping deleted users unless listDeleted
+func (q *MemoryQueries) findUser(listDeleted bool, match func(user *database.User) bool) (*database.User, error) {
+	for i := range q.data.users {
+		user := q.data.users[i]
+		if (listDeleted || !user.DeletedAt.Valid) && match(&user) {
+			return &user, nil
+		}
+	}
+
+	return nil, sql.ErrNoRows
+}
+
+// checkUserConstraints enforces the checks and unique indexes of the users table against the other rows, deleted
+// ones included
+func (q *MemoryQueries) checkUserConstraints(user database.User) error {
+	if user.Email == "" || user.Username == "" {
+		return errors.New("users: email and username can't be empty")
+	}
+
+	for _, other := range q.data.users {
+		if other.ID == user.ID {
+			continue
+		}
+
+		switch {
+		case other.Email == user.Email:
+			return fmt.Errorf("%w: users: email %s already exists", database.ErrUniqueViolation, user.Email)
+		case other.Username == user.Username:
+			return fmt.Errorf("%w: users: username %s already exists", database.ErrUniqueViolation, user.Username)
+		case other.UsernameSkeleton.Valid && user.UsernameSkeleton.Valid && other.UsernameSkeleton.String == user.UsernameSkeleton.String:
+			return fmt.Errorf("%w: users: username skeleton %s already exists", database.ErrUniqueViolation, user.UsernameSkeleton.String)
+		}
+	}
+
+	return nil
+}
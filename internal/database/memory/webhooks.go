@@ -0,0 +1,233 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/vinofsteel/grpc-management/internal/database"
+)
+
+func (q *MemoryQueries) InsertWebhookSubscription(ctx context.Context, params database.InsertWebhookSubscriptionParams) (*database.WebhookSubscription, error) {
+	slog.InfoContext(ctx, "Inserting webhook subscription", "id", params.ID, "url", params.URL, "event_types", params.EventTypes, "layer", "repository", "driver", "memory")
+	defer q.lock()()
+
+	if q.findWebhookSubscription(params.ID) != nil {
+		return nil, fmt.Errorf("%w: webhook_subscriptions: subscription %s already exists", database.ErrUniqueViolation, params.ID)
+	}
+
+	subscription := database.WebhookSubscription{
+		ID:         params.ID,
+		URL:        params.URL,
+		EventTypes: slices.Clone(params.EventTypes),
+		Secret:     params.Secret,
+		CreatedAt:  params.CreatedAt,
+	}
+
+	q.data.webhookSubscriptions = append(q.data.webhookSubscriptions, subscription)
+	return copyWebhookSubscription(&subscription), nil
+}
+
+func (q *MemoryQueries) ListWebhookSubscriptions(ctx context.Context) ([]*database.WebhookSubscription, error) {
+	defer q.lock()()
+
+	var subscriptions []*database.WebhookSubscription
+	for i := range q.data.webhookSubscriptions {
+		subscriptions = append(subscriptions, copyWebhookSubscription(&q.data.webhookSubscriptions[i]))
+	}
+	slices.SortStableFunc(subscriptions, func(a, b *database.WebhookSubscription) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return subscriptions, nil
+}
+
+func (q *MemoryQueries) ListWebhookSubscriptionById(ctx context.Context, params database.ListWebhookSubscriptionByIdParams) (*database.WebhookSubscription, error) {
+	defer q.lock()()
+
+	subscription := q.findWebhookSubscription(params.ID)
+	if subscription == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	return copyWebhookSubscription(subscription), nil
+}
+
+func (q *MemoryQueries) DeleteWebhookSubscription(ctx context.Context, params database.DeleteWebhookSubscriptionParams) error {
+	slog.InfoContext(ctx, "Deleting webhook subscription", "id", params.ID, "layer", "repository", "driver", "memory")
+	defer q.lock()()
+
+	if q.findWebhookSubscription(params.ID) == nil {
+		return sql.ErrNoRows
+	}
+
+	q.data.webhookSubscriptions = slices.DeleteFunc(q.data.webhookSubscriptions, func(subscription database.WebhookSubscription) bool {
+		return subscription.ID == params.ID
+	})
+	q.data.webhookDeliveries = slices.DeleteFunc(q.data.webhookDeliveries, func(delivery database.WebhookDelivery) bool {
+		return delivery.SubscriptionID == params.ID
+	})
+
+	return nil
+}
+
+func (q *MemoryQueries) InsertWebhookDelivery(ctx context.Context, params database.InsertWebhookDeliveryParams) error {
+	defer q.lock()()
+
+	if q.findWebhookSubscription(params.SubscriptionID) == nil {
+		return fmt.Errorf("webhook_deliveries: subscription %s doesn't exist", params.SubscriptionID)
+	}
+
+	if slices.ContainsFunc(q.data.webhookDeliveries, func(delivery database.WebhookDelivery) bool {
+		return delivery.SubscriptionID == params.SubscriptionID && delivery.EventID == params.EventID
+	}) {
+		return nil
+	}
+
+	q.data.webhookDeliveries = append(q.data.webhookDeliveries, database.WebhookDelivery{
+		ID:             params.ID,
+		SubscriptionID: params.SubscriptionID,
+		EventID:        params.EventID,
+		EventType:      params.EventType,
+		Payload:        slices.Clone(params.Payload),
+		Status:         database.WebhookDeliveryPending,
+		NextAttemptAt:  params.CreatedAt,
+		CreatedAt:      params.CreatedAt,
+	})
+
+	return nil
+}
+
+func (q *MemoryQueries) ClaimWebhookDeliveries(ctx context.Context, params database.ClaimWebhookDeliveriesParams) ([]*database.WebhookDelivery, error) {
+	defer q.lock()()
+
+	var due []*database.WebhookDelivery
+	for i := range q.data.webhookDeliveries {
+		delivery := &q.data.webhookDeliveries[i]
+		if isRetriable(delivery.Status) && !delivery.NextAttemptAt.After(params.Now) {
+			due = append(due, delivery)
+		}
+	}
+	slices.SortStableFunc(due, func(a, b *database.WebhookDelivery) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	if params.Limit > 0 && len(due) > int(params.Limit) {
+		due = due[:params.Limit]
+	}
+
+	deliveries := make([]*database.WebhookDelivery, 0, len(due))
+	for _, delivery := range due {
+		delivery.NextAttemptAt = params.LeaseUntil
+		deliveries = append(deliveries, copyWebhookDelivery(delivery))
+	}
+
+	return deliveries, nil
+}
+
+func (q *MemoryQueries) RecordWebhookDeliveryAttempt(ctx context.Context, params database.RecordWebhookDeliveryAttemptParams) error {
+	defer q.lock()()
+
+	if delivery := q.findWebhookDelivery(params.ID); delivery != nil {
+		delivery.Status = params.Status
+		delivery.Attempts++
+		delivery.ResponseCode = params.ResponseCode
+		delivery.LatencyMs = sql.NullInt64{Int64: params.LatencyMs, Valid: true}
+		delivery.LastError = params.Error
+		delivery.LastAttemptAt = sql.NullTime{Time: params.AttemptedAt, Valid: true}
+		delivery.NextAttemptAt = params.NextAttemptAt
+	}
+
+	return nil
+}
+
+func (q *MemoryQueries) ListWebhookDeliveries(ctx context.Context, params database.ListWebhookDeliveriesParams) ([]*database.WebhookDelivery, error) {
+	defer q.lock()()
+
+	// Newest first, rows created at the same time in reverse insertion order
+	var deliveries []*database.WebhookDelivery
+	for i := len(q.data.webhookDeliveries) - 1; i >= 0; i-- {
+		delivery := &q.data.webhookDeliveries[i]
+		if delivery.SubscriptionID == params.SubscriptionID && (params.Status == "" || delivery.Status == params.Status) {
+			deliveries = append(deliveries, copyWebhookDelivery(delivery))
+		}
+	}
+	slices.SortStableFunc(deliveries, func(a, b *database.WebhookDelivery) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	if params.Offset > 0 {
+		deliveries = deliveries[min(int(params.Offset), len(deliveries)):]
+	}
+	if params.Limit > 0 && len(deliveries) > int(params.Limit) {
+		deliveries = deliveries[:params.Limit]
+	}
+
+	return deliveries, nil
+}
+
+func (q *MemoryQueries) ListWebhookDeliveryById(ctx context.Context, params database.ListWebhookDeliveryByIdParams) (*database.WebhookDelivery, error) {
+	defer q.lock()()
+
+	delivery := q.findWebhookDelivery(params.ID)
+	if delivery == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	return copyWebhookDelivery(delivery), nil
+}
+
+func (q *MemoryQueries) RedeliverWebhookDelivery(ctx context.Context, params database.RedeliverWebhookDeliveryParams) (*database.WebhookDelivery, error) {
+	slog.InfoContext(ctx, "Redelivering webhook delivery", "id", params.ID, "layer", "repository", "driver", "memory")
+	defer q.lock()()
+
+	delivery := q.findWebhookDelivery(params.ID)
+	if delivery == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	delivery.Status = database.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = params.Now
+
+	return copyWebhookDelivery(delivery), nil
+}
+
+// Utilities
+func (q *MemoryQueries) findWebhookSubscription(id uuid.UUID) *database.WebhookSubscription {
+	i := slices.IndexFunc(q.data.webhookSubscriptions, func(subscription database.WebhookSubscription) bool {
+		return subscription.ID == id
+	})
+	if i < 0 {
+		return nil
+	}
+
+	return &q.data.webhookSubscriptions[i]
+}
+
+func (q *MemoryQueries) findWebhookDelivery(id uuid.UUID) *database.WebhookDelivery {
+	i := slices.IndexFunc(q.data.webhookDeliveries, func(delivery database.WebhookDelivery) bool { return delivery.ID == id })
+	if i < 0 {
+		return nil
+	}
+
+	return &q.data.webhookDeliveries[i]
+}
+
+func isRetriable(status string) bool {
+	return status == database.WebhookDeliveryPending || status == database.WebhookDeliveryFailed
+}
+
+// copyWebhookSubscription and copyWebhookDelivery keep callers from modifying the stored slices
+func copyWebhookSubscription(subscription *database.WebhookSubscription) *database.WebhookSubscription {
+	copied := *subscription
+	copied.EventTypes = slices.Clone(subscription.EventTypes)
+	return &copied
+}
+
+func copyWebhookDelivery(delivery *database.WebhookDelivery) *database.WebhookDelivery {
+	copied := *delivery
+	copied.Payload = slices.Clone(delivery.Payload)
+	return &copied
+}
@@ -2,17 +2,108 @@ package database
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type User struct {
-	ID        uuid.UUID    `db:"id"`
+	ID               uuid.UUID      `db:"id"`
+	CreatedAt        time.Time      `db:"created_at"`
+	UpdatedAt        time.Time      `db:"updated_at"`
+	DeletedAt        sql.NullTime   `db:"deleted_at"`
+	Email            string         `db:"email"`
+	Username         string         `db:"username"`
+	UsernameSkeleton sql.NullString `db:"username_skeleton"`
+	Password         string         `db:"password"`
+}
+
+// OutboxEvent is a domain event written in the transaction of the change it describes, published afterwards. ID
+// grows with every event, giving the publishing order.
+type OutboxEvent struct {
+	ID            int64          `db:"id"`
+	EventID       uuid.UUID      `db:"event_id"`
+	AggregateID   uuid.UUID      `db:"aggregate_id"`
+	EventType     string         `db:"event_type"`
+	EventVersion  int32          `db:"event_version"`
+	Payload       []byte         `db:"payload"`
+	CreatedAt     time.Time      `db:"created_at"`
+	Attempts      int32          `db:"attempts"`
+	NextAttemptAt time.Time      `db:"next_attempt_at"`
+	LastError     sql.NullString `db:"last_error"`
+	PublishedAt   sql.NullTime   `db:"published_at"`
+}
+
+// UserChange is an entry of the change log of the users table, with the user as the change left it. Sequence grows
+// by one with every change (see SequenceUserChanges for their order).
+type UserChange struct {
+	Sequence  int64        `db:"seq"`
+	Operation string       `db:"operation"`
+	UserID    uuid.UUID    `db:"user_id"`
+	Email     string       `db:"email"`
+	Username  string       `db:"username"`
 	CreatedAt time.Time    `db:"created_at"`
 	UpdatedAt time.Time    `db:"updated_at"`
 	DeletedAt sql.NullTime `db:"deleted_at"`
-	Email     string       `db:"email"`
-	Username  string       `db:"username"`
-	Password  string       `db:"password"`
+	ChangedAt time.Time    `db:"changed_at"`
+}
+
+type UserChangesBounds struct {
+	First int64 `db:"first"`
+	Last  int64 `db:"last"`
+}
+
+type WebhookSubscription struct {
+	ID         uuid.UUID  `db:"id"`
+	URL        string     `db:"url"`
+	EventTypes EventTypes `db:"event_types"`
+	// Secret signs the deliveries, so it's stored as given
+	Secret    string    `db:"secret"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// EventTypes is stored as a comma separated list, which both Postgres and SQLite hold as text
+type EventTypes []string
+
+func (e EventTypes) Value() (driver.Value, error) {
+	return strings.Join(e, ","), nil
+}
+
+func (e *EventTypes) Scan(src any) error {
+	var value string
+	switch src := src.(type) {
+	case string:
+		value = src
+	case []byte:
+		value = string(src)
+	default:
+		return fmt.Errorf("cannot scan %T into EventTypes", src)
+	}
+
+	*e = nil
+	if value != "" {
+		*e = strings.Split(value, ",")
+	}
+	return nil
+}
+
+// WebhookDelivery is the delivery of an event to a webhook subscription, along with the outcome of its last attempt
+type WebhookDelivery struct {
+	ID             uuid.UUID `db:"id"`
+	SubscriptionID uuid.UUID `db:"subscription_id"`
+	EventID        uuid.UUID `db:"event_id"`
+	EventType      string    `db:"event_type"`
+	// Payload is the request body, the event as JSON
+	Payload       []byte         `db:"payload"`
+	Status        string         `db:"status"`
+	Attempts      int32          `db:"attempts"`
+	NextAttemptAt time.Time      `db:"next_attempt_at"`
+	ResponseCode  sql.NullInt32  `db:"response_code"`
+	LatencyMs     sql.NullInt64  `db:"latency_ms"`
+	LastError     sql.NullString `db:"last_error"`
+	LastAttemptAt sql.NullTime   `db:"last_attempt_at"`
+	CreatedAt     time.Time      `db:"created_at"`
 }
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Parameters
+type InsertOutboxEventParams struct {
+	EventID      uuid.UUID `json:"event_id" db:"event_id"`
+	AggregateID  uuid.UUID `json:"aggregate_id" db:"aggregate_id"`
+	EventType    string    `json:"event_type" db:"event_type"`
+	EventVersion int32     `json:"event_version" db:"event_version"`
+	Payload      []byte    `json:"payload" db:"payload"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+type ClaimOutboxEventsParams struct {
+	Now time.Time `json:"now" db:"now"`
+	// LeaseUntil is when the claimed events can be claimed again, should their publisher stop before marking them
+	LeaseUntil time.Time `json:"lease_until" db:"lease_until"`
+	Limit      int32     `json:"limit" db:"limit"`
+}
+
+type MarkOutboxEventPublishedParams struct {
+	ID          int64     `json:"id" db:"id"`
+	PublishedAt time.Time `json:"published_at" db:"published_at"`
+}
+
+type MarkOutboxEventFailedParams struct {
+	ID            int64     `json:"id" db:"id"`
+	Error         string    `json:"error" db:"error"`
+	NextAttemptAt time.Time `json:"next_attempt_at" db:"next_attempt_at"`
+}
+
+type DeleteOutboxEventsParams struct {
+	PublishedBefore time.Time `json:"published_before" db:"published_before"`
+}
+
+// Interface
+type OutboxRepository interface {
+	InsertOutboxEvent(ctx context.Context, params InsertOutboxEventParams) (*OutboxEvent, error)
+	// ClaimOutboxEvents leases the oldest unpublished event of up to Limit aggregates, skipping aggregates whose
+	// oldest unpublished event isn't due yet, so the events of an aggregate are published one at a time and in order
+	ClaimOutboxEvents(ctx context.Context, params ClaimOutboxEventsParams) ([]*OutboxEvent, error)
+	MarkOutboxEventPublished(ctx context.Context, params MarkOutboxEventPublishedParams) error
+	// MarkOutboxEventFailed records a failed attempt, the event being claimable again from NextAttemptAt
+	MarkOutboxEventFailed(ctx context.Context, params MarkOutboxEventFailedParams) error
+	// DeleteOutboxEvents deletes the events published before PublishedBefore, returning how many were
+	DeleteOutboxEvents(ctx context.Context, params DeleteOutboxEventsParams) (int64, error)
+}
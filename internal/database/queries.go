@@ -1,5 +1,66 @@
 package database
 
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ErrUniqueViolation is returned (wrapped) by writes conflicting with an existing row, such as a taken email or username.
+// Lookups that find nothing return sql.ErrNoRows.
+var ErrUniqueViolation = errors.New("unique constraint violation")
+
 type Queries interface {
 	UsersRepository
+	OutboxRepository
+	UserChangesRepository
+	WebhooksRepository
+
+	// WithTx runs fn with Queries bound to a single transaction, committed when fn returns nil and rolled back when
+	// it returns an error or panics. fn may run more than once, as the transaction is retried on serialization
+	// failures and deadlocks, so it shouldn't have side effects outside the database. Calls made while already
+	// inside a transaction join it.
+	WithTx(ctx context.Context, fn func(q Queries) error, options ...TxOption) error
+}
+
+// DefaultTxRetries is how many times WithTx retries a transaction that hit a serialization failure or deadlock
+const DefaultTxRetries = 3
+
+type TxOptions struct {
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+	// MaxRetries is how many times the transaction is retried after a serialization failure or deadlock
+	MaxRetries int
+}
+
+type TxOption func(*TxOptions)
+
+// WithIsolation sets the isolation level of the transaction, the database's default (read committed on Postgres) otherwise
+func WithIsolation(level sql.IsolationLevel) TxOption {
+	return func(o *TxOptions) {
+		o.Isolation = level
+	}
+}
+
+func WithReadOnly() TxOption {
+	return func(o *TxOptions) {
+		o.ReadOnly = true
+	}
+}
+
+// WithMaxRetries replaces DefaultTxRetries, 0 disables retries
+func WithMaxRetries(retries int) TxOption {
+	return func(o *TxOptions) {
+		o.MaxRetries = retries
+	}
+}
+
+// NewTxOptions applies options over the defaults
+func NewTxOptions(options ...TxOption) TxOptions {
+	txOptions := TxOptions{MaxRetries: DefaultTxRetries}
+	for _, option := range options {
+		option(&txOptions)
+	}
+
+	return txOptions
 }
@@ -0,0 +1,32 @@
+package database
+
+import "context"
+
+// Implementations with read replicas send reads to them, which may lag behind the primary. The context decides
+// when a read must see the latest writes instead.
+
+type readPrimaryContextKey struct{}
+
+type sessionContextKey struct{}
+
+// WithReadPrimary sends every read made with ctx to the primary, for callers that must see their own writes
+func WithReadPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readPrimaryContextKey{}, true)
+}
+
+func ReadPrimaryFromContext(ctx context.Context) bool {
+	readPrimary, _ := ctx.Value(readPrimaryContextKey{}).(bool)
+	return readPrimary
+}
+
+// WithSession ties the queries made with ctx to a client session: after the session writes, its reads stick to the
+// primary for a while, so it sees its own writes even when replicas lag behind
+func WithSession(ctx context.Context, session string) context.Context {
+	return context.WithValue(ctx, sessionContextKey{}, session)
+}
+
+// SessionFromContext returns the session of ctx, empty when there's none
+func SessionFromContext(ctx context.Context) string {
+	session, _ := ctx.Value(sessionContextKey{}).(string)
+	return session
+}
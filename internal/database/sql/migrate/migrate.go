@@ -0,0 +1,90 @@
+// Package migrate holds what the migration sets of every driver share
+package migrate
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/pressly/goose/v3"
+)
+
+// Validate checks a set of goose SQL migrations without a database: unique versions, a single Up section
+// and balanced StatementBegin/StatementEnd annotations in each file
+func Validate(migrations fs.FS) error {
+	paths, err := fs.Glob(migrations, "*.sql")
+	if err != nil {
+		return err
+	}
+
+	versions := make(map[int64]string)
+	for _, path := range paths {
+		version, err := goose.NumericComponent(path)
+		if err != nil {
+			return fmt.Errorf("invalid migration name %s: %w", path, err)
+		}
+		if existing, ok := versions[version]; ok {
+			return fmt.Errorf("duplicate migration version %d: %s and %s", version, existing, path)
+		}
+		versions[version] = path
+
+		if err := validateFile(migrations, path); err != nil {
+			return fmt.Errorf("invalid migration %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// Utilities
+func validateFile(migrations fs.FS, path string) error {
+	file, err := migrations.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	ups, downs := 0, 0
+	inStatement := false
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		annotation, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "-- +goose ")
+		if !ok {
+			continue
+		}
+
+		switch strings.Fields(annotation)[0] {
+		case "Up":
+			ups++
+		case "Down":
+			downs++
+		case "StatementBegin":
+			if inStatement {
+				return fmt.Errorf("nested StatementBegin")
+			}
+			inStatement = true
+		case "StatementEnd":
+			if !inStatement {
+				return fmt.Errorf("StatementEnd without StatementBegin")
+			}
+			inStatement = false
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if ups != 1 {
+		return fmt.Errorf("expected one Up annotation, found %d", ups)
+	}
+	if downs > 1 {
+		return fmt.Errorf("expected at most one Down annotation, found %d", downs)
+	}
+	if inStatement {
+		return fmt.Errorf("StatementBegin without StatementEnd")
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateFile(t *testing.T) {
+	migrationTests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{
+			name:    "success case: Testing a migration with a function body",
+			content: "-- +goose Up\n-- +goose StatementBegin\nCREATE FUNCTION f() RETURNS void AS $$ BEGIN END; $$ LANGUAGE plpgsql;\n-- +goose StatementEnd\n-- +goose Down\nDROP FUNCTION f;\n",
+		},
+		{
+			name:    "failure case: Testing a migration without an Up annotation",
+			content: "CREATE TABLE t (id INT);\n",
+			wantErr: true,
+		},
+		{
+			name:    "failure case: Testing an unterminated statement block",
+			content: "-- +goose Up\n-- +goose StatementBegin\nCREATE TABLE t (id INT);\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range migrationTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Logf("Running %s", tt.name)
+
+			migrations := fstest.MapFS{"00001_test.sql": {Data: []byte(tt.content)}}
+			err := validateFile(migrations, "00001_test.sql")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
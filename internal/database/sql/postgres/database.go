@@ -2,14 +2,33 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/vinofsteel/grpc-management/internal/database"
 	"github.com/vinofsteel/grpc-management/pkg"
 )
 
+// Postgres error codes of transactions that can succeed when retried, and of unique constraint violations
+const (
+	serializationFailureCode = "40001"
+	deadlockDetectedCode     = "40P01"
+	uniqueViolationCode      = "23505"
+)
+
 type PSQLQueries struct {
-	db *sqlx.DB
+	// db runs the queries, either the pool or the transaction in progress
+	db sqlx.ExtContext
+	// pool starts transactions, nil inside one
+	pool *sqlx.DB
+	// reads routes reads outside transactions to the read replicas, nil without any
+	reads *readRouter
 
 	database.UsersRepository
 }
@@ -20,7 +39,115 @@ func NewPSQLQueries(ctx context.Context, provider pkg.DBProvider) (*PSQLQueries,
 		return nil, err
 	}
 
-	return &PSQLQueries{
-		db: db,
-	}, nil
+	queries := &PSQLQueries{
+		db:   db,
+		pool: db,
+	}
+
+	if p, ok := provider.(*PostgresProvider); ok && len(p.replicas) > 0 {
+		queries.reads = newReadRouter(p, p.config.StickyWindow)
+	}
+
+	return queries, nil
+}
+
+func (q *PSQLQueries) WithTx(ctx context.Context, fn func(q database.Queries) error, options ...database.TxOption) error {
+	return q.withTx(ctx, func(tx *PSQLQueries) error { return fn(tx) }, options...)
+}
+
+func (q *PSQLQueries) withTx(ctx context.Context, fn func(tx *PSQLQueries) error, options ...database.TxOption) error {
+	// Already inside a transaction, which fn joins
+	if q.pool == nil {
+		return fn(q)
+	}
+
+	txOptions := database.NewTxOptions(options...)
+
+	for attempt := 0; ; attempt++ {
+		err := q.runTx(ctx, fn, txOptions)
+		if err == nil || attempt >= txOptions.MaxRetries || !isRetryable(err) {
+			return err
+		}
+
+		// A short random pause, so the transactions that conflicted don't collide again right away
+		wait := rand.N(time.Duration(attempt+1) * 10 * time.Millisecond)
+		slog.WarnContext(ctx, "Retrying transaction", "attempt", attempt+1, "error", err, "layer", "repository", "driver", "psql")
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+func (q *PSQLQueries) runTx(ctx context.Context, fn func(tx *PSQLQueries) error, txOptions database.TxOptions) (err error) {
+	tx, err := q.pool.BeginTxx(ctx, &sql.TxOptions{Isolation: txOptions.Isolation, ReadOnly: txOptions.ReadOnly})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error beginning transaction", "error", err)
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				slog.ErrorContext(ctx, "Could not rollback transaction after panic", "error", rollbackErr)
+			}
+			panic(p)
+		}
+	}()
+
+	if err := fn(&PSQLQueries{db: tx, reads: q.reads}); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			slog.ErrorContext(ctx, "Could not rollback transaction", "error", rollbackErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Could not commit transaction", "error", err)
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Utilities
+// reader returns where the reads of ctx run: the transaction in progress, a replica or the primary
+func (q *PSQLQueries) reader(ctx context.Context) sqlx.ExtContext {
+	if q.pool == nil || q.reads == nil {
+		return q.db
+	}
+
+	if replica := q.reads.replica(ctx); replica != nil {
+		return replica
+	}
+
+	return q.db
+}
+
+// wrote keeps the reads of the session of ctx on the primary for the sticky window
+func (q *PSQLQueries) wrote(ctx context.Context) {
+	if q.reads != nil {
+		q.reads.wrote(ctx)
+	}
+}
+
+// translateError wraps driver errors the callers care about in the errors of the database package
+func translateError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolationCode {
+		return fmt.Errorf("%w: %w", database.ErrUniqueViolation, err)
+	}
+
+	return err
+}
+
+func isRetryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	return pqErr.Code == serializationFailureCode || pqErr.Code == deadlockDetectedCode
 }
@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryable(t *testing.T) {
+	retryableTests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "success case: Testing serialization failures are retried",
+			err:  &pq.Error{Code: serializationFailureCode},
+			want: true,
+		},
+		{
+			name: "success case: Testing wrapped deadlocks are retried",
+			err:  fmt.Errorf("error committing transaction: %w", &pq.Error{Code: deadlockDetectedCode}),
+			want: true,
+		},
+		{
+			name: "failure case: Testing unique violations aren't retried",
+			err:  &pq.Error{Code: uniqueViolationCode},
+		},
+		{
+			name: "failure case: Testing errors from outside the database aren't retried",
+			err:  errors.New("validation failed"),
+		},
+	}
+
+	for _, tt := range retryableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Logf("Running %s", tt.name)
+			assert.Equal(t, tt.want, isRetryable(tt.err))
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+	"github.com/vinofsteel/grpc-management/internal/database/sql/migrate"
+	"github.com/vinofsteel/grpc-management/internal/database/sql/postgres/migrations"
+)
+
+//go:embed migrations/*.sql
+var embeddedMigrations embed.FS
+
+// Migrations holds the SQL migrations embedded in the binary, so it doesn't depend on the source tree at runtime
+func Migrations() fs.FS {
+	migrations, _ := fs.Sub(embeddedMigrations, "migrations")
+	return migrations
+}
+
+// NewMigrator runs the embedded migrations and the Go ones of the migrations package against db. Every command holds
+// a Postgres advisory lock for its whole duration, so replicas migrating on start (or an operator running the
+// subcommand meanwhile) never race each other.
+func NewMigrator(db *sql.DB) (*goose.Provider, error) {
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, fmt.Errorf("error creating migration lock: %w", err)
+	}
+
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, Migrations(),
+		goose.WithSessionLocker(locker),
+		goose.WithGoMigrations(migrations.BackfillUsernameSkeletons()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error loading migrations: %w", err)
+	}
+
+	return provider, nil
+}
+
+// MigrateUp applies every pending migration, logging each one
+func MigrateUp(ctx context.Context, db *sql.DB) error {
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	results, err := migrator.Up(ctx)
+	if err != nil {
+		return fmt.Errorf("error applying migrations: %w", err)
+	}
+
+	for _, result := range results {
+		slog.InfoContext(ctx, "Migration applied", "migration", result.Source.Path, "duration", result.Duration)
+	}
+
+	return nil
+}
+
+// ValidateMigrations checks the embedded migrations without a database, see migrate.Validate
+func ValidateMigrations() error {
+	return migrate.Validate(Migrations())
+}
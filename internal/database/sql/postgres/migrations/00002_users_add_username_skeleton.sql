@@ -0,0 +1,12 @@
+-- +goose Up
+-- Confusable skeleton of the username (UTS #39), computed by the application. Unique so that look-alikes of an
+-- existing username can't be registered, even by concurrent requests. Rows created before this column are
+-- backfilled by 00007_users_backfill_username_skeleton.go, which computes the skeletons in Go.
+ALTER TABLE users ADD COLUMN username_skeleton TEXT;
+
+CREATE UNIQUE INDEX users_username_skeleton_key ON users (username_skeleton);
+
+-- +goose Down
+DROP INDEX users_username_skeleton_key;
+
+ALTER TABLE users DROP COLUMN username_skeleton;
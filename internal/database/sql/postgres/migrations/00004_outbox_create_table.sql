@@ -0,0 +1,22 @@
+-- +goose Up
+-- Domain events written in the transaction of the change they describe (transactional outbox), published afterwards
+-- by the relay. id gives the publishing order, and published rows are deleted by the relay once retained long enough.
+CREATE TABLE outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_id UUID UNIQUE NOT NULL,
+    aggregate_id UUID NOT NULL,
+    event_type TEXT NOT NULL,
+    event_version INTEGER NOT NULL,
+    payload BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error TEXT,
+    published_at TIMESTAMP
+);
+
+CREATE INDEX outbox_unpublished_idx ON outbox (aggregate_id, id) WHERE published_at IS NULL;
+CREATE INDEX outbox_published_at_idx ON outbox (published_at) WHERE published_at IS NOT NULL;
+
+-- +goose Down
+DROP TABLE outbox;
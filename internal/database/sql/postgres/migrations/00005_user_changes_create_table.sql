@@ -0,0 +1,68 @@
+-- +goose Up
+-- Change log of the users table, appended to by a trigger so every write is captured, and announced on the
+-- user_changes channel. seq is the resume token of WatchUsers. Rows are deleted by the application once past the
+-- retention, keeping the newest one.
+-- Changes are recorded without a sequence, along with the ID of their transaction, and sequenced by the change feed
+-- (see SequenceUserChanges) once every transaction with a lower ID has finished. Sequences are gap-free and
+-- increasing, and no change is sequenced before one that could still commit, but they're ordered by transaction ID
+-- rather than by commit. The trade-off is latency: a change waits for the transactions older than its own, even ones
+-- that don't touch users.
+CREATE TABLE user_changes (
+    id BIGSERIAL PRIMARY KEY,
+    seq BIGINT,
+    txid XID8 NOT NULL DEFAULT pg_current_xact_id(),
+    operation TEXT NOT NULL CHECK (operation IN ('created', 'updated', 'deleted')),
+    user_id UUID NOT NULL,
+    email TEXT NOT NULL,
+    username TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    deleted_at TIMESTAMP,
+    changed_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+CREATE UNIQUE INDEX user_changes_seq_key ON user_changes (seq);
+
+CREATE INDEX user_changes_unsequenced_idx ON user_changes (txid, id) WHERE seq IS NULL;
+
+CREATE INDEX user_changes_changed_at_idx ON user_changes (changed_at);
+
+-- +goose StatementBegin
+CREATE FUNCTION record_user_change() RETURNS trigger AS $$
+DECLARE
+    changed users%ROWTYPE;
+    change_operation TEXT;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        changed := NEW;
+        change_operation := 'created';
+    ELSIF TG_OP = 'DELETE' THEN
+        changed := OLD;
+        change_operation := 'deleted';
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        changed := NEW;
+        change_operation := 'deleted';
+    ELSE
+        changed := NEW;
+        change_operation := 'updated';
+    END IF;
+
+    INSERT INTO user_changes (operation, user_id, email, username, created_at, updated_at, deleted_at)
+        VALUES (change_operation, changed.id, changed.email, changed.username, changed.created_at, changed.updated_at, changed.deleted_at);
+
+    -- Delivered on commit, waking the feeds up to sequence the change
+    PERFORM pg_notify('user_changes', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER users_record_change AFTER INSERT OR UPDATE OR DELETE ON users
+    FOR EACH ROW EXECUTE FUNCTION record_user_change();
+
+-- +goose Down
+DROP TRIGGER users_record_change ON users;
+
+DROP FUNCTION record_user_change();
+
+DROP TABLE user_changes;
@@ -0,0 +1,34 @@
+-- +goose Up
+-- Webhook subscriptions, with the comma separated event types they receive, and the log of the deliveries made to
+-- them. A delivery is retried until delivered or dead, its row recording the outcome of the last attempt.
+CREATE TABLE webhook_subscriptions (
+    id UUID PRIMARY KEY,
+    url TEXT NOT NULL,
+    event_types TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY,
+    subscription_id UUID NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    event_type TEXT NOT NULL,
+    payload BYTEA NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'failed', 'delivered', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    response_code INTEGER,
+    latency_ms BIGINT,
+    last_error TEXT,
+    last_attempt_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status IN ('pending', 'failed');
+CREATE INDEX webhook_deliveries_subscription_idx ON webhook_deliveries (subscription_id, created_at);
+
+-- +goose Down
+DROP TABLE webhook_deliveries;
+DROP TABLE webhook_subscriptions;
@@ -0,0 +1,99 @@
+// Package migrations holds the Go migrations of the Postgres schema, next to the SQL ones (embedded by the postgres
+// package) so `goose create` numbers new migrations after them
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/pressly/goose/v3"
+	"github.com/vinofsteel/grpc-management/internal/validation"
+)
+
+// BackfillUsernameSkeletons fills the username_skeleton column (see 00002) of the users created before it, with the
+// skeleton the application computes. Rolling it back keeps the skeletons, they're valid either way.
+func BackfillUsernameSkeletons() *goose.Migration {
+	return goose.NewGoMigration(7, &goose.GoFunc{RunTx: backfillUsernameSkeletons}, nil)
+}
+
+type skeletonRow struct {
+	ID       string
+	Username string
+	Skeleton sql.NullString
+}
+
+// Utilities
+func backfillUsernameSkeletons(ctx context.Context, tx *sql.Tx) error {
+	policy, err := validation.NewUsernamePolicy(ctx, validation.UsernamePolicyConfig{})
+	if err != nil {
+		return err
+	}
+
+	// Users created meanwhile would be missed, or could take a skeleton computed here
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE users IN SHARE MODE`); err != nil {
+		return fmt.Errorf("error locking users: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, username, username_skeleton FROM users`)
+	if err != nil {
+		return fmt.Errorf("error listing users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []skeletonRow
+	for rows.Next() {
+		var user skeletonRow
+		if err := rows.Scan(&user.ID, &user.Username, &user.Skeleton); err != nil {
+			return fmt.Errorf("error reading user: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error listing users: %w", err)
+	}
+
+	missing, err := missingSkeletons(users, policy.Skeleton)
+	if err != nil {
+		return err
+	}
+
+	for id, skeleton := range missing {
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET username_skeleton = $1 WHERE id = $2`, skeleton, id); err != nil {
+			return fmt.Errorf("error backfilling the username skeleton of user %s: %w", id, err)
+		}
+	}
+
+	slog.InfoContext(ctx, "Username skeletons backfilled", "users", len(missing))
+	return nil
+}
+
+// missingSkeletons returns the skeleton of every user without one, by ID. Soft deleted users count too, as the unique
+// index covers them. It fails listing every pair of confusable usernames, one of each has to be renamed first.
+func missingSkeletons(users []skeletonRow, skeleton func(username string) string) (map[string]string, error) {
+	missing := make(map[string]string)
+	owners := make(map[string]string)
+	var conflicts []string
+
+	for _, user := range users {
+		userSkeleton := user.Skeleton.String
+		if !user.Skeleton.Valid {
+			userSkeleton = skeleton(user.Username)
+			missing[user.ID] = userSkeleton
+		}
+
+		if owner, ok := owners[userSkeleton]; ok {
+			conflicts = append(conflicts, fmt.Sprintf("%q and %q", owner, user.Username))
+			continue
+		}
+		owners[userSkeleton] = user.Username
+	}
+
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("confusable usernames must be renamed before backfilling their skeletons: %s", strings.Join(conflicts, ", "))
+	}
+
+	return missing, nil
+}
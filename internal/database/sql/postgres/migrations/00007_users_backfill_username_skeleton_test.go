@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMissingSkeletons(t *testing.T) {
+	skeleton := strings.ToLower
+
+	t.Logf("Running success case: Testing only users without a skeleton get one")
+	missing, err := missingSkeletons([]skeletonRow{
+		{ID: "1", Username: "Alice", Skeleton: sql.NullString{String: "alice", Valid: true}},
+		{ID: "2", Username: "Bob"},
+	}, skeleton)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"2": "bob"}, missing)
+
+	t.Logf("Running failure case: Testing confusable usernames fail the backfill")
+	_, err = missingSkeletons([]skeletonRow{
+		{ID: "1", Username: "alice", Skeleton: sql.NullString{String: "alice", Valid: true}},
+		{ID: "2", Username: "ALICE"},
+		{ID: "3", Username: "bob"},
+	}, skeleton)
+	assert.ErrorContains(t, err, `"alice" and "ALICE"`)
+}
@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateMigrations(t *testing.T) {
+	t.Logf("Running success case: Testing the embedded migrations are valid")
+	assert.NoError(t, ValidateMigrations())
+}
+
+func TestNewMigrator(t *testing.T) {
+	// Not connected to, loading the migrations doesn't need a server
+	db, err := sql.Open("postgres", "postgres://localhost:1/unused?sslmode=disable")
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	t.Logf("Running success case: Testing the Go migrations are loaded along with the SQL ones")
+	migrator, err := NewMigrator(db)
+	if assert.NoError(t, err) {
+		types := make(map[int64]goose.MigrationType)
+		for _, source := range migrator.ListSources() {
+			types[source.Version] = source.Type
+		}
+		assert.Equal(t, goose.TypeGo, types[7])
+		assert.Equal(t, goose.TypeSQL, types[6])
+	}
+}
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/vinofsteel/grpc-management/pkg"
+)
+
+// Notifier sends and receives Postgres notifications (NOTIFY/LISTEN), reaching every server connected to the database
+type Notifier interface {
+	Notify(ctx context.Context, channel, payload string) error
+	// Listen calls fn with the payload of every notification on channel until ctx is done. After the connection is
+	// lost and restored fn is called with an empty payload, as notifications sent meanwhile were missed.
+	Listen(ctx context.Context, channel string, fn func(payload string)) error
+}
+
+type notifier struct {
+	provider *PostgresProvider
+}
+
+// NewNotifier needs the Postgres provider, as notifications are specific to Postgres
+func NewNotifier(provider pkg.DBProvider) (Notifier, error) {
+	p, ok := provider.(*PostgresProvider)
+	if !ok {
+		return nil, errors.New("notifications need the postgres database provider")
+	}
+
+	return &notifier{provider: p}, nil
+}
+
+func (n *notifier) Notify(ctx context.Context, channel, payload string) error {
+	db, err := n.provider.pool()
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, channel, payload); err != nil {
+		return fmt.Errorf("error sending notification on %s: %w", channel, err)
+	}
+
+	return nil
+}
+
+func (n *notifier) Listen(ctx context.Context, channel string, fn func(payload string)) error {
+	// The listener holds a connection of its own, outside the pool, and reconnects with backoff when it's lost
+	listener := pq.NewListener(n.provider.connStr, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.WarnContext(ctx, "Notification listener connection error", "error", err, "channel", channel)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(channel); err != nil {
+		return fmt.Errorf("error listening on %s: %w", channel, err)
+	}
+
+	for {
+		select {
+		case notification := <-listener.Notify:
+			// A nil notification follows a reconnection
+			if notification == nil {
+				fn("")
+				continue
+			}
+			fn(notification.Extra)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vinofsteel/grpc-management/internal/database"
+)
+
+const outboxColumns = `id, event_id, aggregate_id, event_type, event_version, payload, created_at, attempts,
+	next_attempt_at, last_error, published_at`
+
+func (q *PSQLQueries) InsertOutboxEvent(ctx context.Context, params database.InsertOutboxEventParams) (*database.OutboxEvent, error) {
+	slog.InfoContext(ctx, "Inserting outbox event", "event_id", params.EventID, "event_type", params.EventType, "aggregate_id", params.AggregateID, "layer", "repository", "driver", "psql")
+
+	query := `INSERT INTO outbox
+		(event_id, aggregate_id, event_type, event_version, payload, created_at, next_attempt_at)
+			VALUES (:event_id, :aggregate_id, :event_type, :event_version, :payload, :created_at, :created_at)
+			RETURNING ` + outboxColumns
+
+	ctx, span := startSpan(ctx, "InsertOutboxEvent", query)
+	defer span.End()
+
+	events, err := q.queryOutboxEvents(ctx, query, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error inserting outbox event", "error", err, "event_id", params.EventID)
+		recordSpanError(span, err)
+		return nil, translateError(err)
+	}
+
+	return events[0], nil
+}
+
+func (q *PSQLQueries) ClaimOutboxEvents(ctx context.Context, params database.ClaimOutboxEventsParams) ([]*database.OutboxEvent, error) {
+	// The conditions are repeated outside the subquery, so a relay waiting on a row another relay just claimed skips
+	// it once the other commits, instead of claiming it too
+	query := `UPDATE outbox SET next_attempt_at = :lease_until
+		WHERE id IN (
+			SELECT o.id FROM outbox o
+				WHERE o.published_at IS NULL AND o.next_attempt_at <= :now
+				AND NOT EXISTS (
+					SELECT 1 FROM outbox p WHERE p.aggregate_id = o.aggregate_id AND p.published_at IS NULL AND p.id < o.id
+				)
+				ORDER BY o.id
+				LIMIT :limit
+		)
+		AND published_at IS NULL AND next_attempt_at <= :now
+		RETURNING ` + outboxColumns
+
+	ctx, span := startSpan(ctx, "ClaimOutboxEvents", query)
+	defer span.End()
+
+	events, err := q.queryOutboxEvents(ctx, query, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error claiming outbox events", "error", err)
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	// RETURNING follows no particular order
+	slices.SortFunc(events, func(a, b *database.OutboxEvent) int { return cmp.Compare(a.ID, b.ID) })
+	return events, nil
+}
+
+func (q *PSQLQueries) MarkOutboxEventPublished(ctx context.Context, params database.MarkOutboxEventPublishedParams) error {
+	query := `UPDATE outbox SET published_at = :published_at, attempts = attempts + 1, last_error = NULL WHERE id = :id`
+
+	ctx, span := startSpan(ctx, "MarkOutboxEventPublished", query)
+	defer span.End()
+
+	if _, err := sqlx.NamedExecContext(ctx, q.db, query, params); err != nil {
+		slog.ErrorContext(ctx, "Error marking outbox event as published", "error", err, "id", params.ID)
+		recordSpanError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+func (q *PSQLQueries) MarkOutboxEventFailed(ctx context.Context, params database.MarkOutboxEventFailedParams) error {
+	query := `UPDATE outbox SET attempts = attempts + 1, last_error = :error, next_attempt_at = :next_attempt_at
+		WHERE id = :id AND published_at IS NULL`
+
+	ctx, span := startSpan(ctx, "MarkOutboxEventFailed", query)
+	defer span.End()
+
+	if _, err := sqlx.NamedExecContext(ctx, q.db, query, params); err != nil {
+		slog.ErrorContext(ctx, "Error marking outbox event as failed", "error", err, "id", params.ID)
+		recordSpanError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+func (q *PSQLQueries) DeleteOutboxEvents(ctx context.Context, params database.DeleteOutboxEventsParams) (int64, error) {
+	query := `DELETE FROM outbox WHERE published_at < :published_before`
+
+	ctx, span := startSpan(ctx, "DeleteOutboxEvents", query)
+	defer span.End()
+
+	result, err := sqlx.NamedExecContext(ctx, q.db, query, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting published outbox events", "error", err)
+		recordSpanError(span, err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// Utilities
+func (q *PSQLQueries) queryOutboxEvents(ctx context.Context, query string, arg any) ([]*database.OutboxEvent, error) {
+	rows, err := sqlx.NamedQueryContext(ctx, q.db, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*database.OutboxEvent
+	for rows.Next() {
+		var event database.OutboxEvent
+		if err := rows.StructScan(&event); err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+
+	return events, rows.Err()
+}
@@ -3,43 +3,99 @@ package postgres
 import (
 	"context"
 	"fmt"
-	"os"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
+	"github.com/vinofsteel/grpc-management/internal/config"
 	"github.com/vinofsteel/grpc-management/pkg"
 )
 
-// Database provider for postgres connections
+// Database provider for postgres connections, to the primary and its read replicas
 type PostgresProvider struct {
 	connStr string
+	config  config.DatabaseConfig
+
+	mu sync.Mutex
+	db *sqlx.DB
+
+	replicas []*replica
+	// next spreads reads over the healthy replicas in turn
+	next atomic.Uint64
+}
+
+type replica struct {
+	host    string
+	connStr string
+	// healthy is set by the health checks of WatchReplicas, replicas don't get reads until they pass one
+	healthy atomic.Bool
 	db      *sqlx.DB
 }
 
+// GetConnection returns the connection pool once the database answers a ping. The pool replaces broken connections on
+// its own, so it's never closed when a ping fails: callers holding on to it keep working once the database is back.
 func (p *PostgresProvider) GetConnection(ctx context.Context) (*sqlx.DB, error) {
-	if p.db != nil {
-		if err := p.db.PingContext(ctx); err != nil {
-			err := p.db.Close()
-			return nil, err
-		}
+	db, err := p.pool()
+	if err != nil {
+		return nil, err
+	}
 
-		return p.db, nil
+	if err := db.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("error reaching database: %w", err)
 	}
 
-	db, err := sqlx.ConnectContext(ctx, "postgres", p.connStr)
-	if err != nil {
-		return nil, fmt.Errorf("error opening DB connection: %w", err)
+	return db, nil
+}
+
+// Replica returns the pool of a healthy read replica, taking turns between them, or nil when none is healthy
+func (p *PostgresProvider) Replica() *sqlx.DB {
+	for range p.replicas {
+		r := p.replicas[p.next.Add(1)%uint64(len(p.replicas))]
+		if r.healthy.Load() {
+			return r.db
+		}
 	}
 
-	db.SetMaxIdleConns(25)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	return nil
+}
 
-	p.db = db
-	return db, nil
+// WatchReplicas health checks the read replicas every interval until ctx is done, returning right away without any
+func (p *PostgresProvider) WatchReplicas(ctx context.Context, interval time.Duration) {
+	if len(p.replicas) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, r := range p.replicas {
+			p.checkReplica(ctx, r, interval)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
 }
 
 func (p *PostgresProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, r := range p.replicas {
+		if r.db != nil {
+			_ = r.db.Close()
+		}
+	}
+
 	if p.db != nil {
 		return p.db.Close()
 	}
@@ -47,21 +103,95 @@ func (p *PostgresProvider) Close() error {
 	return nil
 }
 
+// pool opens the connection pool on first use, opening doesn't connect so it only fails on a malformed connection string
+func (p *PostgresProvider) pool() (*sqlx.DB, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.db != nil {
+		return p.db, nil
+	}
+
+	db, err := p.open(p.connStr)
+	if err != nil {
+		return nil, err
+	}
+
+	p.db = db
+	return db, nil
+}
+
 // Create a New PSQL db provider where needed
-func newPostgresProvider(user, password, host, port, dbName string) *PostgresProvider {
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", user, password, host, port, dbName)
+func NewPostgresDatabaseProvider(config config.DatabaseConfig) pkg.DBProvider {
+	provider := &PostgresProvider{
+		connStr: connectionString(config, config.Host, config.Port),
+		config:  config,
+	}
+
+	for _, host := range config.ReplicaHosts {
+		port := config.Port
+		if h, p, err := net.SplitHostPort(host); err == nil {
+			if parsed, err := strconv.Atoi(p); err == nil {
+				host, port = h, parsed
+			}
+		}
+
+		provider.replicas = append(provider.replicas, &replica{
+			host:    host,
+			connStr: connectionString(config, host, port),
+		})
+	}
+
+	return provider
+}
+
+// Utilities
+// open opens a pool with the configured settings
+func (p *PostgresProvider) open(connStr string) (*sqlx.DB, error) {
+	db, err := sqlx.Open("postgres", connStr)
+	if err != nil {
+		return nil, fmt.Errorf("error opening DB connection: %w", err)
+	}
+
+	db.SetMaxOpenConns(p.config.MaxOpenConns)
+	db.SetMaxIdleConns(p.config.MaxIdleConns)
+	db.SetConnMaxLifetime(p.config.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(p.config.ConnMaxIdleTime)
+
+	return db, nil
+}
+
+// checkReplica marks r healthy when it answers a ping within timeout, logging when that changes
+func (p *PostgresProvider) checkReplica(ctx context.Context, r *replica, timeout time.Duration) {
+	p.mu.Lock()
+	if r.db == nil {
+		db, err := p.open(r.connStr)
+		if err != nil {
+			p.mu.Unlock()
+			slog.ErrorContext(ctx, "Error opening replica connection", "error", err, "replica", r.host)
+			return
+		}
+		r.db = db
+	}
+	p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := r.db.PingContext(ctx)
+	healthy := err == nil
+	if r.healthy.Swap(healthy) == healthy {
+		return
+	}
 
-	return &PostgresProvider{
-		connStr: connStr,
+	if healthy {
+		slog.InfoContext(ctx, "Replica is healthy, sending reads to it", "replica", r.host)
+	} else {
+		slog.WarnContext(ctx, "Replica is unhealthy, no longer sending reads to it", "error", err, "replica", r.host)
 	}
 }
 
-func NewPostgresDatabaseProvider() pkg.DBProvider {
-	return newPostgresProvider(
-		os.Getenv("PGUSER"),
-		os.Getenv("PGPASSWORD"),
-		os.Getenv("PGHOST"),
-		os.Getenv("PGPORT"),
-		os.Getenv("PGDATABASE"),
-	)
+func connectionString(config config.DatabaseConfig, host string, port int) string {
+	return fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=disable",
+		config.User, config.Password.Value(), net.JoinHostPort(host, strconv.Itoa(port)), config.Name)
 }
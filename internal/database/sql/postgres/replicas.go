@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vinofsteel/grpc-management/internal/database"
+)
+
+// Sessions whose window passed are dropped once there are more than this many, so the map can't grow unbounded
+const maxStickySessions = 10000
+
+// replicaSource returns the pool of a healthy read replica, nil when there's none
+type replicaSource interface {
+	Replica() *sqlx.DB
+}
+
+// readRouter decides where reads go: to a replica, unless the context asks for the primary or its session wrote
+// within the sticky window
+type readRouter struct {
+	replicas replicaSource
+	window   time.Duration
+	now      func() time.Time
+
+	mu         sync.Mutex
+	lastWrites map[string]time.Time
+}
+
+func newReadRouter(replicas replicaSource, window time.Duration) *readRouter {
+	return &readRouter{
+		replicas:   replicas,
+		window:     window,
+		now:        time.Now,
+		lastWrites: make(map[string]time.Time),
+	}
+}
+
+// replica returns the replica the reads of ctx go to, nil when they go to the primary
+func (r *readRouter) replica(ctx context.Context) *sqlx.DB {
+	if database.ReadPrimaryFromContext(ctx) {
+		return nil
+	}
+
+	if session := database.SessionFromContext(ctx); session != "" && r.window > 0 {
+		r.mu.Lock()
+		lastWrite, ok := r.lastWrites[session]
+		r.mu.Unlock()
+
+		if ok && r.now().Sub(lastWrite) < r.window {
+			return nil
+		}
+	}
+
+	return r.replicas.Replica()
+}
+
+// wrote starts the sticky window of the session of ctx
+func (r *readRouter) wrote(ctx context.Context) {
+	session := database.SessionFromContext(ctx)
+	if session == "" || r.window <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if len(r.lastWrites) >= maxStickySessions {
+		for s, lastWrite := range r.lastWrites {
+			if now.Sub(lastWrite) >= r.window {
+				delete(r.lastWrites, s)
+			}
+		}
+	}
+
+	r.lastWrites[session] = now
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/vinofsteel/grpc-management/internal/config"
+	"github.com/vinofsteel/grpc-management/internal/database"
+)
+
+type fakeReplicas struct {
+	db *sqlx.DB
+}
+
+func (f *fakeReplicas) Replica() *sqlx.DB {
+	return f.db
+}
+
+func TestReadRouter(t *testing.T) {
+	replicaDB := &sqlx.DB{}
+	router := newReadRouter(&fakeReplicas{db: replicaDB}, 5*time.Second)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	router.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	sessionCtx := database.WithSession(ctx, "session-a")
+
+	t.Logf("Running success case: Testing reads go to a replica by default")
+	assert.Same(t, replicaDB, router.replica(ctx))
+	assert.Same(t, replicaDB, router.replica(sessionCtx))
+
+	t.Logf("Running success case: Testing the read primary override sends reads to the primary")
+	assert.Nil(t, router.replica(database.WithReadPrimary(ctx)))
+
+	t.Logf("Running success case: Testing a session's reads stick to the primary after it writes")
+	router.wrote(sessionCtx)
+	now = now.Add(4 * time.Second)
+	assert.Nil(t, router.replica(sessionCtx))
+	assert.Same(t, replicaDB, router.replica(database.WithSession(ctx, "session-b")))
+
+	t.Logf("Running success case: Testing reads go back to the replicas once the window passes")
+	now = now.Add(time.Second)
+	assert.Same(t, replicaDB, router.replica(sessionCtx))
+
+	t.Logf("Running failure case: Testing writes without a session don't make reads stick")
+	router.wrote(ctx)
+	assert.Len(t, router.lastWrites, 1)
+}
+
+func TestReplica(t *testing.T) {
+	provider := NewPostgresDatabaseProvider(config.DatabaseConfig{
+		Host:         "primary",
+		Port:         5432,
+		ReplicaHosts: []string{"replica-a", "replica-b:5433", "replica-c"},
+	}).(*PostgresProvider)
+
+	t.Logf("Running success case: Testing replica hosts default to the primary's port")
+	assert.Contains(t, provider.replicas[0].connStr, "@replica-a:5432/")
+	assert.Contains(t, provider.replicas[1].connStr, "@replica-b:5433/")
+
+	t.Logf("Running failure case: Testing no replica is returned before any passes a health check")
+	assert.Nil(t, provider.Replica())
+
+	t.Logf("Running success case: Testing reads take turns between healthy replicas only")
+	for _, r := range provider.replicas {
+		r.db = &sqlx.DB{}
+	}
+	provider.replicas[0].healthy.Store(true)
+	provider.replicas[2].healthy.Store(true)
+
+	seen := make(map[*sqlx.DB]int)
+	for i := 0; i < 4; i++ {
+		seen[provider.Replica()]++
+	}
+	assert.Equal(t, map[*sqlx.DB]int{provider.replicas[0].db: 2, provider.replicas[2].db: 2}, seen)
+}
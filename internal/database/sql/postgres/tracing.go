@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/vinofsteel/grpc-management/internal/database/sql/postgres")
+
+// startSpan starts the span of a repository operation, as a child of the span of the call being handled
+func startSpan(ctx context.Context, operation, statement string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", operation),
+			attribute.String("db.statement", statement),
+		),
+	)
+}
+
+// recordSpanError marks the span as failed. sql.ErrNoRows is left out, since a missing user is an expected outcome.
+func recordSpanError(span trace.Span, err error) {
+	if err == nil || errors.Is(err, sql.ErrNoRows) {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
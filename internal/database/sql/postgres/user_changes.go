@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vinofsteel/grpc-management/internal/database"
+)
+
+const userChangeColumns = `seq, operation, user_id, email, username, created_at, updated_at, deleted_at, changed_at`
+
+func (q *PSQLQueries) SequenceUserChanges(ctx context.Context) (int64, error) {
+	// Transactions with an ID below the snapshot's xmin have all finished, and any change still to be committed belongs
+	// to a transaction from xmin onwards, so it will be sequenced after these. Ordering by transaction ID isn't the
+	// commit order: a transaction that got its ID first, then waited on a user another one updated, commits last yet
+	// sorts first.
+	query := `UPDATE user_changes SET seq = ready.seq
+		FROM (
+			SELECT id, (SELECT COALESCE(MAX(seq), 0) FROM user_changes) + row_number() OVER (ORDER BY txid, id) AS seq
+				FROM user_changes
+				WHERE seq IS NULL AND txid < pg_snapshot_xmin(pg_current_snapshot())
+		) ready
+		WHERE user_changes.id = ready.id`
+
+	ctx, span := startSpan(ctx, "SequenceUserChanges", query)
+	defer span.End()
+
+	var sequenced int64
+	err := q.withTx(ctx, func(tx *PSQLQueries) error {
+		// The feeds of every server sequence the same changes, they take turns
+		if _, err := tx.db.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('user_changes'))`); err != nil {
+			return err
+		}
+
+		result, err := tx.db.ExecContext(ctx, query)
+		if err != nil {
+			return err
+		}
+
+		sequenced, err = result.RowsAffected()
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error sequencing user changes", "error", err)
+		recordSpanError(span, err)
+		return 0, err
+	}
+
+	return sequenced, nil
+}
+
+func (q *PSQLQueries) ListUserChanges(ctx context.Context, params database.ListUserChangesParams) ([]*database.UserChange, error) {
+	query := `SELECT ` + userChangeColumns + ` FROM user_changes WHERE seq > :after_sequence ORDER BY seq`
+	if params.Limit > 0 {
+		query += ` LIMIT :limit`
+	}
+
+	ctx, span := startSpan(ctx, "ListUserChanges", query)
+	defer span.End()
+
+	rows, err := sqlx.NamedQueryContext(ctx, q.db, query, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error querying user changes", "error", err, "after_sequence", params.AfterSequence)
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []*database.UserChange
+	for rows.Next() {
+		var change database.UserChange
+		if err := rows.StructScan(&change); err != nil {
+			slog.ErrorContext(ctx, "Error scanning user change", "error", err)
+			recordSpanError(span, err)
+			return nil, err
+		}
+		changes = append(changes, &change)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "Error iterating over user change rows", "error", err)
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func (q *PSQLQueries) ListUserChangesBounds(ctx context.Context) (*database.UserChangesBounds, error) {
+	query := `SELECT COALESCE(MIN(seq), 0) AS first, COALESCE(MAX(seq), 0) AS last FROM user_changes`
+
+	ctx, span := startSpan(ctx, "ListUserChangesBounds", query)
+	defer span.End()
+
+	var bounds database.UserChangesBounds
+	if err := sqlx.GetContext(ctx, q.db, &bounds, query); err != nil {
+		slog.ErrorContext(ctx, "Error querying user changes bounds", "error", err)
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	return &bounds, nil
+}
+
+func (q *PSQLQueries) DeleteUserChanges(ctx context.Context, params database.DeleteUserChangesParams) (int64, error) {
+	query := `DELETE FROM user_changes
+		WHERE changed_at < :changed_before AND seq < (SELECT MAX(seq) FROM user_changes)`
+
+	ctx, span := startSpan(ctx, "DeleteUserChanges", query)
+	defer span.End()
+
+	result, err := sqlx.NamedExecContext(ctx, q.db, query, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting user changes", "error", err)
+		recordSpanError(span, err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/vinofsteel/grpc-management/internal/database"
+)
+
+// TestSequenceUserChanges, like the contract suite, needs the disposable TEST_DATABASE_URL database
+func TestSequenceUserChanges(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDatabase(t)
+	q := &PSQLQueries{db: db, pool: db}
+
+	bounds, err := q.ListUserChangesBounds(ctx)
+	assert.NoError(t, err)
+
+	insert := `INSERT INTO users (email, username, username_skeleton, password) VALUES ($1, $1, $1, 'hash')`
+	first, err := db.BeginTxx(ctx, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = first.Rollback() }()
+	firstUsername := "first_" + uuid.NewString()[:8]
+	_, err = first.ExecContext(ctx, insert, firstUsername)
+	assert.NoError(t, err)
+
+	secondUsername := "second_" + uuid.NewString()[:8]
+	_, err = db.ExecContext(ctx, insert, secondUsername)
+	assert.NoError(t, err)
+
+	t.Logf("Running success case: Testing a change isn't sequenced while an older transaction may still commit")
+	_, err = q.SequenceUserChanges(ctx)
+	assert.NoError(t, err)
+	changes, err := q.ListUserChanges(ctx, database.ListUserChangesParams{AfterSequence: bounds.Last})
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	t.Logf("Running success case: Testing changes are sequenced once the older transaction committed")
+	assert.NoError(t, first.Commit())
+	_, err = q.SequenceUserChanges(ctx)
+	assert.NoError(t, err)
+	changes, err = q.ListUserChanges(ctx, database.ListUserChangesParams{AfterSequence: bounds.Last})
+	assert.NoError(t, err)
+	if assert.Len(t, changes, 2) {
+		assert.Equal(t, firstUsername, changes[0].Username)
+		assert.Equal(t, secondUsername, changes[1].Username)
+		assert.Less(t, changes[0].Sequence, changes[1].Sequence)
+	}
+}
+
+// BenchmarkConcurrentUserWrites measures users written by concurrent clients, which the change log trigger used to
+// serialize until commit
+func BenchmarkConcurrentUserWrites(b *testing.B) {
+	ctx := context.Background()
+	db := openTestDatabase(b)
+	q := &PSQLQueries{db: db, pool: db}
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			username := "bench_" + uuid.NewString()[:18]
+			_, err := q.InsertUser(ctx, database.InsertUserParams{
+				Email:            username + "@example.com",
+				Username:         username,
+				UsernameSkeleton: username,
+				Password:         "hash",
+			})
+			if err != nil {
+				b.Fatalf("error inserting user: %v", err)
+			}
+		}
+	})
+}
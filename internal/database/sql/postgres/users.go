@@ -7,14 +7,17 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	"github.com/vinofsteel/grpc-management/internal/database"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func (q *PSQLQueries) ListUserByEmail(ctx context.Context, params database.ListUserByEmailParams) (*database.User, error) {
 	slog.InfoContext(ctx, "Listing user by email", "email", params.Email, "layer", "repository", "driver", "psql")
 
 	query := `SELECT
-		id, created_at, updated_at, email, username, password 
+		id, created_at, updated_at, email, username, username_skeleton, password 
 			FROM users 
 			WHERE email = :email`
 
@@ -22,10 +25,14 @@ func (q *PSQLQueries) ListUserByEmail(ctx context.Context, params database.ListU
 		query += ` AND deleted_at IS NULL`
 	}
 
+	ctx, span := startSpan(ctx, "ListUserByEmail", query)
+	defer span.End()
+
 	var user database.User
-	rows, err := q.db.NamedQueryContext(ctx, query, params)
+	rows, err := sqlx.NamedQueryContext(ctx, q.reader(ctx), query, params)
 	if err != nil {
 		slog.ErrorContext(ctx, "Error querying user by email", "error", err, "email", params.Email)
+		recordSpanError(span, err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -34,6 +41,7 @@ func (q *PSQLQueries) ListUserByEmail(ctx context.Context, params database.ListU
 		err = rows.StructScan(&user)
 		if err != nil {
 			slog.ErrorContext(ctx, "Error scanning user by email", "error", err, "email", params.Email)
+			recordSpanError(span, err)
 			return nil, err
 		}
 		return &user, nil
@@ -46,7 +54,7 @@ func (q *PSQLQueries) ListUserByUsername(ctx context.Context, params database.Li
 	slog.InfoContext(ctx, "Listing user by username", "username", params.Username, "layer", "repository", "driver", "psql")
 
 	query := `SELECT
-		id, created_at, updated_at, email, username, password 
+		id, created_at, updated_at, email, username, username_skeleton, password 
 			FROM users 
 			WHERE username = :username`
 
@@ -54,10 +62,14 @@ func (q *PSQLQueries) ListUserByUsername(ctx context.Context, params database.Li
 		query += ` AND deleted_at IS NULL`
 	}
 
+	ctx, span := startSpan(ctx, "ListUserByUsername", query)
+	defer span.End()
+
 	var user database.User
-	rows, err := q.db.NamedQueryContext(ctx, query, params)
+	rows, err := sqlx.NamedQueryContext(ctx, q.reader(ctx), query, params)
 	if err != nil {
 		slog.ErrorContext(ctx, "Error querying user by username", "error", err, "username", params.Username)
+		recordSpanError(span, err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -66,6 +78,44 @@ func (q *PSQLQueries) ListUserByUsername(ctx context.Context, params database.Li
 		err = rows.StructScan(&user)
 		if err != nil {
 			slog.ErrorContext(ctx, "Error scanning user by username", "error", err, "username", params.Username)
+			recordSpanError(span, err)
+			return nil, err
+		}
+		return &user, nil
+	}
+
+	return nil, sql.ErrNoRows
+}
+
+func (q *PSQLQueries) ListUserByUsernameSkeleton(ctx context.Context, params database.ListUserByUsernameSkeletonParams) (*database.User, error) {
+	slog.InfoContext(ctx, "Listing user by username skeleton", "username_skeleton", params.UsernameSkeleton, "layer", "repository", "driver", "psql")
+
+	query := `SELECT
+		id, created_at, updated_at, email, username, username_skeleton, password 
+			FROM users 
+			WHERE username_skeleton = :username_skeleton`
+
+	if !params.ListDeleted {
+		query += ` AND deleted_at IS NULL`
+	}
+
+	ctx, span := startSpan(ctx, "ListUserByUsernameSkeleton", query)
+	defer span.End()
+
+	var user database.User
+	rows, err := sqlx.NamedQueryContext(ctx, q.reader(ctx), query, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error querying user by username skeleton", "error", err, "username_skeleton", params.UsernameSkeleton)
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		err = rows.StructScan(&user)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning user by username skeleton", "error", err, "username_skeleton", params.UsernameSkeleton)
+			recordSpanError(span, err)
 			return nil, err
 		}
 		return &user, nil
@@ -78,7 +128,7 @@ func (q *PSQLQueries) ListUserById(ctx context.Context, params database.ListUser
 	slog.InfoContext(ctx, "Listing user by id", "id", params.ID, "layer", "repository", "driver", "psql")
 
 	query := `SELECT
-		id, created_at, updated_at, email, username, password 
+		id, created_at, updated_at, email, username, username_skeleton, password 
 			FROM users 
 			WHERE id = :id`
 
@@ -86,10 +136,14 @@ func (q *PSQLQueries) ListUserById(ctx context.Context, params database.ListUser
 		query += ` AND deleted_at IS NULL`
 	}
 
+	ctx, span := startSpan(ctx, "ListUserById", query)
+	defer span.End()
+
 	var user database.User
-	rows, err := q.db.NamedQueryContext(ctx, query, params)
+	rows, err := sqlx.NamedQueryContext(ctx, q.reader(ctx), query, params)
 	if err != nil {
 		slog.ErrorContext(ctx, "Error querying user by id", "error", err, "id", params.ID)
+		recordSpanError(span, err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -98,6 +152,7 @@ func (q *PSQLQueries) ListUserById(ctx context.Context, params database.ListUser
 		err = rows.StructScan(&user)
 		if err != nil {
 			slog.ErrorContext(ctx, "Error scanning user by id", "error", err, "id", params.ID)
+			recordSpanError(span, err)
 			return nil, err
 		}
 		return &user, nil
@@ -110,7 +165,7 @@ func (q *PSQLQueries) ListUsers(ctx context.Context, params database.ListUsersPa
 	slog.InfoContext(ctx, "Listing users", "limit", params.Limit, "offset", params.Offset, "list_deleted", params.ListDeleted, "layer", "repository", "driver", "psql")
 
 	query := `SELECT
-		id, created_at, updated_at, email, username, password 
+		id, created_at, updated_at, email, username, username_skeleton, password 
 		FROM users`
 
 	if !params.ListDeleted {
@@ -127,10 +182,14 @@ func (q *PSQLQueries) ListUsers(ctx context.Context, params database.ListUsersPa
 		query += ` OFFSET :offset`
 	}
 
+	ctx, span := startSpan(ctx, "ListUsers", query)
+	defer span.End()
+
 	var users []*database.User
-	rows, err := q.db.NamedQueryContext(ctx, query, params)
+	rows, err := sqlx.NamedQueryContext(ctx, q.reader(ctx), query, params)
 	if err != nil {
 		slog.ErrorContext(ctx, "Error querying users", "error", err, "limit", params.Limit, "offset", params.Offset)
+		recordSpanError(span, err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -139,6 +198,7 @@ func (q *PSQLQueries) ListUsers(ctx context.Context, params database.ListUsersPa
 		var user database.User
 		if err := rows.StructScan(&user); err != nil {
 			slog.ErrorContext(ctx, "Error scanning user from rows", "error", err)
+			recordSpanError(span, err)
 			return nil, err
 		}
 		users = append(users, &user)
@@ -146,6 +206,7 @@ func (q *PSQLQueries) ListUsers(ctx context.Context, params database.ListUsersPa
 
 	if err := rows.Err(); err != nil {
 		slog.ErrorContext(ctx, "Error iterating over user rows", "error", err)
+		recordSpanError(span, err)
 		return nil, err
 	}
 
@@ -157,14 +218,18 @@ func (q *PSQLQueries) InsertUser(ctx context.Context, params database.InsertUser
 	slog.InfoContext(ctx, "Creating user", "email", params.Email, "username", params.Username, "layer", "repository", "driver", "psql")
 
 	query := `INSERT INTO users 
-		(email, username, password) VALUES (:email, :username, :password) 
-			RETURNING id, created_at, updated_at, email, username, password`
+		(email, username, username_skeleton, password) VALUES (:email, :username, :username_skeleton, :password) 
+			RETURNING id, created_at, updated_at, email, username, username_skeleton, password`
+
+	ctx, span := startSpan(ctx, "InsertUser", query)
+	defer span.End()
 
 	var user database.User
-	rows, err := q.db.NamedQueryContext(ctx, query, params)
+	rows, err := sqlx.NamedQueryContext(ctx, q.db, query, params)
 	if err != nil {
 		slog.ErrorContext(ctx, "Error inserting user", "error", err, "email", params.Email, "username", params.Username)
-		return nil, err
+		recordSpanError(span, err)
+		return nil, translateError(err)
 	}
 	defer rows.Close()
 
@@ -172,8 +237,10 @@ func (q *PSQLQueries) InsertUser(ctx context.Context, params database.InsertUser
 		err = rows.StructScan(&user)
 		if err != nil {
 			slog.ErrorContext(ctx, "Error scanning inserted user", "error", err, "email", params.Email, "username", params.Username)
+			recordSpanError(span, err)
 			return nil, err
 		}
+		q.wrote(ctx)
 		return &user, nil
 	}
 
@@ -184,12 +251,16 @@ func (q *PSQLQueries) UpdateUserPassword(ctx context.Context, params database.Up
 	slog.InfoContext(ctx, "Updating user password", "user_id", params.UserID, "layer", "repository", "driver", "psql")
 
 	query := `UPDATE users SET password = :password, updated_at = CURRENT_TIMESTAMP WHERE id = :user_id
-		RETURNING id, created_at, updated_at, email, username, password`
+		RETURNING id, created_at, updated_at, email, username, username_skeleton, password`
+
+	ctx, span := startSpan(ctx, "UpdateUserPassword", query)
+	defer span.End()
 
 	var user database.User
-	rows, err := q.db.NamedQueryContext(ctx, query, params)
+	rows, err := sqlx.NamedQueryContext(ctx, q.db, query, params)
 	if err != nil {
 		slog.ErrorContext(ctx, "Error updating user password", "error", err, "user_id", params.UserID)
+		recordSpanError(span, err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -198,41 +269,68 @@ func (q *PSQLQueries) UpdateUserPassword(ctx context.Context, params database.Up
 		err = rows.StructScan(&user)
 		if err != nil {
 			slog.ErrorContext(ctx, "Error scanning updated user", "error", err, "user_id", params.UserID)
+			recordSpanError(span, err)
 			return nil, err
 		}
+		q.wrote(ctx)
 		return &user, nil
 	}
 
-	return nil, err
+	return nil, sql.ErrNoRows
+}
+
+func (q *PSQLQueries) UpdateUserUsername(ctx context.Context, params database.UpdateUserUsernameParams) (*database.User, error) {
+	slog.InfoContext(ctx, "Updating user username", "user_id", params.UserID, "username", params.Username, "layer", "repository", "driver", "psql")
+
+	query := `UPDATE users SET username = :username, username_skeleton = :username_skeleton, updated_at = CURRENT_TIMESTAMP
+		WHERE id = :user_id AND deleted_at IS NULL
+		RETURNING id, created_at, updated_at, email, username, username_skeleton, password`
+
+	ctx, span := startSpan(ctx, "UpdateUserUsername", query)
+	defer span.End()
+
+	var user database.User
+	rows, err := sqlx.NamedQueryContext(ctx, q.db, query, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error updating user username", "error", err, "user_id", params.UserID)
+		recordSpanError(span, err)
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		err = rows.StructScan(&user)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning updated user", "error", err, "user_id", params.UserID)
+			recordSpanError(span, err)
+			return nil, err
+		}
+		q.wrote(ctx)
+		return &user, nil
+	}
+
+	return nil, sql.ErrNoRows
 }
 
 func (q *PSQLQueries) DeleteUser(ctx context.Context, params database.DeleteUserParams) error {
 	slog.InfoContext(ctx, "Deleting user", "id", params.ID, "hard", params.Hard, "layer", "repository", "driver", "psql")
 
-	tx, err := q.db.BeginTxx(ctx, nil)
+	// The statements depend on the kind of delete, they're added to the span once chosen
+	ctx, span := startSpan(ctx, "DeleteUser", "")
+	defer span.End()
+
+	err := q.withTx(ctx, func(tx *PSQLQueries) error {
+		return tx.deleteUser(ctx, params, span)
+	})
 	if err != nil {
-		slog.ErrorContext(ctx, "Error beginning transaction on DeleteUser", "error", err, "id", params.ID)
 		return err
 	}
 
-	defer func() {
-		if p := recover(); p != nil {
-			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				slog.ErrorContext(ctx, "Could not rollback in DeleteUser after panic", "error", rollbackErr, "id", params.ID)
-			}
-			panic(p)
-		} else if err != nil {
-			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				slog.ErrorContext(ctx, "Could not rollback in DeleteUser", "error", rollbackErr, "id", params.ID)
-			}
-		} else {
-			if commitErr := tx.Commit(); commitErr != nil {
-				slog.ErrorContext(ctx, "Could not commit in DeleteUser", "error", commitErr, "id", params.ID)
-				err = commitErr
-			}
-		}
-	}()
+	q.wrote(ctx)
+	return nil
+}
 
+func (q *PSQLQueries) deleteUser(ctx context.Context, params database.DeleteUserParams, span trace.Span) error {
 	if !params.Hard {
 		softDeleteParams := struct {
 			ID        uuid.UUID `db:"id"`
@@ -245,9 +343,11 @@ func (q *PSQLQueries) DeleteUser(ctx context.Context, params database.DeleteUser
 		softQuery := `UPDATE users 
             SET deleted_at = :deleted_at WHERE id = :id`
 
-		_, err = tx.NamedExecContext(ctx, softQuery, softDeleteParams)
+		span.SetAttributes(attribute.String("db.statement", softQuery))
+		_, err := sqlx.NamedExecContext(ctx, q.db, softQuery, softDeleteParams)
 		if err != nil {
 			slog.ErrorContext(ctx, "Error executing soft delete", "error", err, "id", params.ID)
+			recordSpanError(span, err)
 			return err
 		}
 	}
@@ -268,15 +368,18 @@ func (q *PSQLQueries) DeleteUser(ctx context.Context, params database.DeleteUser
 		hardQuerySessions := `DELETE FROM sessions WHERE user_id = :user_id`
 		hardQueryUsers := `DELETE FROM users WHERE id = :id`
 
-		_, err = tx.NamedExecContext(ctx, hardQuerySessions, hardDeleteParams)
+		span.SetAttributes(attribute.String("db.statement", hardQuerySessions+"; "+hardQueryUsers))
+		_, err := sqlx.NamedExecContext(ctx, q.db, hardQuerySessions, hardDeleteParams)
 		if err != nil {
 			slog.ErrorContext(ctx, "Error executing hard delete on sessions", "error", err, "id", params.ID)
+			recordSpanError(span, err)
 			return err
 		}
 
-		_, err = tx.NamedExecContext(ctx, hardQueryUsers, userDeleteParams)
+		_, err = sqlx.NamedExecContext(ctx, q.db, hardQueryUsers, userDeleteParams)
 		if err != nil {
 			slog.ErrorContext(ctx, "Error executing hard delete on users", "error", err, "id", params.ID)
+			recordSpanError(span, err)
 			return err
 		}
 	}
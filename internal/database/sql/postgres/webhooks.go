@@ -0,0 +1,276 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"slices"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vinofsteel/grpc-management/internal/database"
+)
+
+const (
+	webhookSubscriptionColumns = `id, url, event_types, secret, created_at`
+	webhookDeliveryColumns     = `id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+	response_code, latency_ms, last_error, last_attempt_at, created_at`
+)
+
+func (q *PSQLQueries) InsertWebhookSubscription(ctx context.Context, params database.InsertWebhookSubscriptionParams) (*database.WebhookSubscription, error) {
+	slog.InfoContext(ctx, "Inserting webhook subscription", "id", params.ID, "url", params.URL, "event_types", params.EventTypes, "layer", "repository", "driver", "psql")
+
+	query := `INSERT INTO webhook_subscriptions (id, url, event_types, secret, created_at)
+		VALUES (:id, :url, :event_types, :secret, :created_at)
+		RETURNING ` + webhookSubscriptionColumns
+
+	ctx, span := startSpan(ctx, "InsertWebhookSubscription", query)
+	defer span.End()
+
+	subscriptions, err := q.queryWebhookSubscriptions(ctx, q.db, query, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error inserting webhook subscription", "error", err, "id", params.ID)
+		recordSpanError(span, err)
+		return nil, translateError(err)
+	}
+
+	q.wrote(ctx)
+	return subscriptions[0], nil
+}
+
+func (q *PSQLQueries) ListWebhookSubscriptions(ctx context.Context) ([]*database.WebhookSubscription, error) {
+	// Read from the primary, as the deliveries of an event go to the subscriptions listed here
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions ORDER BY created_at, id`
+
+	ctx, span := startSpan(ctx, "ListWebhookSubscriptions", query)
+	defer span.End()
+
+	subscriptions, err := q.queryWebhookSubscriptions(ctx, q.db, query, struct{}{})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error querying webhook subscriptions", "error", err)
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+func (q *PSQLQueries) ListWebhookSubscriptionById(ctx context.Context, params database.ListWebhookSubscriptionByIdParams) (*database.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE id = :id`
+
+	ctx, span := startSpan(ctx, "ListWebhookSubscriptionById", query)
+	defer span.End()
+
+	subscriptions, err := q.queryWebhookSubscriptions(ctx, q.db, query, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error querying webhook subscription", "error", err, "id", params.ID)
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	if len(subscriptions) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return subscriptions[0], nil
+}
+
+func (q *PSQLQueries) DeleteWebhookSubscription(ctx context.Context, params database.DeleteWebhookSubscriptionParams) error {
+	slog.InfoContext(ctx, "Deleting webhook subscription", "id", params.ID, "layer", "repository", "driver", "psql")
+
+	query := `DELETE FROM webhook_subscriptions WHERE id = :id`
+
+	ctx, span := startSpan(ctx, "DeleteWebhookSubscription", query)
+	defer span.End()
+
+	result, err := sqlx.NamedExecContext(ctx, q.db, query, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting webhook subscription", "error", err, "id", params.ID)
+		recordSpanError(span, err)
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+
+	q.wrote(ctx)
+	return nil
+}
+
+func (q *PSQLQueries) InsertWebhookDelivery(ctx context.Context, params database.InsertWebhookDeliveryParams) error {
+	query := `INSERT INTO webhook_deliveries
+		(id, subscription_id, event_id, event_type, payload, status, next_attempt_at, created_at)
+			VALUES (:id, :subscription_id, :event_id, :event_type, :payload, 'pending', :created_at, :created_at)
+			ON CONFLICT (subscription_id, event_id) DO NOTHING`
+
+	ctx, span := startSpan(ctx, "InsertWebhookDelivery", query)
+	defer span.End()
+
+	if _, err := sqlx.NamedExecContext(ctx, q.db, query, params); err != nil {
+		slog.ErrorContext(ctx, "Error inserting webhook delivery", "error", err, "subscription_id", params.SubscriptionID, "event_id", params.EventID)
+		recordSpanError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+func (q *PSQLQueries) ClaimWebhookDeliveries(ctx context.Context, params database.ClaimWebhookDeliveriesParams) ([]*database.WebhookDelivery, error) {
+	// Rows claimed by another dispatcher in the meantime are skipped rather than waited on
+	query := `UPDATE webhook_deliveries SET next_attempt_at = :lease_until
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+				WHERE status IN ('pending', 'failed') AND next_attempt_at <= :now
+				ORDER BY created_at, id
+				LIMIT :limit
+				FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + webhookDeliveryColumns
+
+	ctx, span := startSpan(ctx, "ClaimWebhookDeliveries", query)
+	defer span.End()
+
+	deliveries, err := q.queryWebhookDeliveries(ctx, q.db, query, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error claiming webhook deliveries", "error", err)
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	// RETURNING follows no particular order
+	slices.SortFunc(deliveries, func(a, b *database.WebhookDelivery) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return deliveries, nil
+}
+
+func (q *PSQLQueries) RecordWebhookDeliveryAttempt(ctx context.Context, params database.RecordWebhookDeliveryAttemptParams) error {
+	query := `UPDATE webhook_deliveries SET status = :status, attempts = attempts + 1, response_code = :response_code,
+		latency_ms = :latency_ms, last_error = :error, last_attempt_at = :attempted_at, next_attempt_at = :next_attempt_at
+		WHERE id = :id`
+
+	ctx, span := startSpan(ctx, "RecordWebhookDeliveryAttempt", query)
+	defer span.End()
+
+	if _, err := sqlx.NamedExecContext(ctx, q.db, query, params); err != nil {
+		slog.ErrorContext(ctx, "Error recording webhook delivery attempt", "error", err, "id", params.ID)
+		recordSpanError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+func (q *PSQLQueries) ListWebhookDeliveries(ctx context.Context, params database.ListWebhookDeliveriesParams) ([]*database.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE subscription_id = :subscription_id`
+
+	if params.Status != "" {
+		query += ` AND status = :status`
+	}
+
+	query += ` ORDER BY created_at DESC, id DESC`
+
+	if params.Limit > 0 {
+		query += ` LIMIT :limit`
+	}
+
+	if params.Offset > 0 {
+		query += ` OFFSET :offset`
+	}
+
+	ctx, span := startSpan(ctx, "ListWebhookDeliveries", query)
+	defer span.End()
+
+	deliveries, err := q.queryWebhookDeliveries(ctx, q.reader(ctx), query, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error querying webhook deliveries", "error", err, "subscription_id", params.SubscriptionID)
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (q *PSQLQueries) ListWebhookDeliveryById(ctx context.Context, params database.ListWebhookDeliveryByIdParams) (*database.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = :id`
+
+	ctx, span := startSpan(ctx, "ListWebhookDeliveryById", query)
+	defer span.End()
+
+	deliveries, err := q.queryWebhookDeliveries(ctx, q.reader(ctx), query, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error querying webhook delivery", "error", err, "id", params.ID)
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	if len(deliveries) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return deliveries[0], nil
+}
+
+func (q *PSQLQueries) RedeliverWebhookDelivery(ctx context.Context, params database.RedeliverWebhookDeliveryParams) (*database.WebhookDelivery, error) {
+	slog.InfoContext(ctx, "Redelivering webhook delivery", "id", params.ID, "layer", "repository", "driver", "psql")
+
+	query := `UPDATE webhook_deliveries SET status = 'pending', attempts = 0, next_attempt_at = :now
+		WHERE id = :id
+		RETURNING ` + webhookDeliveryColumns
+
+	ctx, span := startSpan(ctx, "RedeliverWebhookDelivery", query)
+	defer span.End()
+
+	deliveries, err := q.queryWebhookDeliveries(ctx, q.db, query, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error redelivering webhook delivery", "error", err, "id", params.ID)
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	if len(deliveries) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	q.wrote(ctx)
+	return deliveries[0], nil
+}
+
+// Utilities
+func (q *PSQLQueries) queryWebhookSubscriptions(ctx context.Context, db sqlx.ExtContext, query string, arg any) ([]*database.WebhookSubscription, error) {
+	rows, err := sqlx.NamedQueryContext(ctx, db, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []*database.WebhookSubscription
+	for rows.Next() {
+		var subscription database.WebhookSubscription
+		if err := rows.StructScan(&subscription); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, &subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+func (q *PSQLQueries) queryWebhookDeliveries(ctx context.Context, db sqlx.ExtContext, query string, arg any) ([]*database.WebhookDelivery, error) {
+	rows, err := sqlx.NamedQueryContext(ctx, db, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*database.WebhookDelivery
+	for rows.Next() {
+		var delivery database.WebhookDelivery
+		if err := rows.StructScan(&delivery); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+
+	return deliveries, rows.Err()
+}
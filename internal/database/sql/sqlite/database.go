@@ -0,0 +1,123 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vinofsteel/grpc-management/internal/database"
+	"github.com/vinofsteel/grpc-management/pkg"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+type SQLiteQueries struct {
+	// db runs the queries, either the pool or the transaction in progress
+	db sqlx.ExtContext
+	// pool starts transactions, nil inside one
+	pool *sqlx.DB
+
+	database.UsersRepository
+}
+
+func NewSQLiteQueries(ctx context.Context, provider pkg.DBProvider) (*SQLiteQueries, error) {
+	db, err := provider.GetConnection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SQLiteQueries{
+		db:   db,
+		pool: db,
+	}, nil
+}
+
+// WithTx runs fn in a transaction. SQLite transactions are always serializable, so the isolation level is ignored.
+func (q *SQLiteQueries) WithTx(ctx context.Context, fn func(q database.Queries) error, options ...database.TxOption) error {
+	// Already inside a transaction, which fn joins
+	if q.pool == nil {
+		return fn(q)
+	}
+
+	txOptions := database.NewTxOptions(options...)
+
+	for attempt := 0; ; attempt++ {
+		err := q.runTx(ctx, fn, txOptions)
+		if err == nil || attempt >= txOptions.MaxRetries || !isRetryable(err) {
+			return err
+		}
+
+		// A short random pause, so the writers that conflicted don't collide again right away
+		wait := rand.N(time.Duration(attempt+1) * 10 * time.Millisecond)
+		slog.WarnContext(ctx, "Retrying transaction", "attempt", attempt+1, "error", err, "layer", "repository", "driver", "sqlite")
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+func (q *SQLiteQueries) runTx(ctx context.Context, fn func(q database.Queries) error, txOptions database.TxOptions) (err error) {
+	tx, err := q.pool.BeginTxx(ctx, &sql.TxOptions{ReadOnly: txOptions.ReadOnly})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error beginning transaction", "error", err)
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				slog.ErrorContext(ctx, "Could not rollback transaction after panic", "error", rollbackErr)
+			}
+			panic(p)
+		}
+	}()
+
+	if err := fn(&SQLiteQueries{db: tx}); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			slog.ErrorContext(ctx, "Could not rollback transaction", "error", rollbackErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Could not commit transaction", "error", err)
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Utilities
+// translateError wraps driver errors the callers care about in the errors of the database package
+func translateError(err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return fmt.Errorf("%w: %w", database.ErrUniqueViolation, err)
+	}
+
+	return err
+}
+
+// isRetryable reports whether the database was locked by another writer for longer than the busy timeout
+func isRetryable(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+
+	// Extended result codes keep the primary code in their lowest byte
+	return sqliteErr.Code()&0xff == sqlite3.SQLITE_BUSY
+}
+
+// timestamp matches the microsecond precision of the Postgres timestamps
+func timestamp() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
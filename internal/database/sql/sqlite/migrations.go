@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+
+	"github.com/pressly/goose/v3"
+	"github.com/vinofsteel/grpc-management/internal/database/sql/migrate"
+)
+
+//go:embed migrations/*.sql
+var embeddedMigrations embed.FS
+
+// Migrations holds the SQLite migrations embedded in the binary. They're versioned on their own, apart from the
+// Postgres ones.
+func Migrations() fs.FS {
+	migrations, _ := fs.Sub(embeddedMigrations, "migrations")
+	return migrations
+}
+
+// NewMigrator runs the embedded migrations against db. SQLite locks the whole file while writing, so no advisory
+// lock is needed.
+func NewMigrator(db *sql.DB) (*goose.Provider, error) {
+	provider, err := goose.NewProvider(goose.DialectSQLite3, db, Migrations())
+	if err != nil {
+		return nil, fmt.Errorf("error loading migrations: %w", err)
+	}
+
+	return provider, nil
+}
+
+// MigrateUp applies every pending migration, logging each one
+func MigrateUp(ctx context.Context, db *sql.DB) error {
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	results, err := migrator.Up(ctx)
+	if err != nil {
+		return fmt.Errorf("error applying migrations: %w", err)
+	}
+
+	for _, result := range results {
+		slog.InfoContext(ctx, "Migration applied", "migration", result.Source.Path, "duration", result.Duration)
+	}
+
+	return nil
+}
+
+// ValidateMigrations checks the embedded migrations without a database, see migrate.Validate
+func ValidateMigrations() error {
+	return migrate.Validate(Migrations())
+}
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Same columns as the Postgres users table. IDs are generated by the application, and timestamps are written by it
+-- in UTC so they sort as text.
+CREATE TABLE users (
+    id TEXT PRIMARY KEY NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    deleted_at DATETIME,
+    email TEXT UNIQUE NOT NULL CHECK (email <> ''),
+    username TEXT UNIQUE NOT NULL CHECK (username <> '' AND length(username) <= 25),
+    username_skeleton TEXT UNIQUE,
+    password TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE users;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- Same columns as the Postgres outbox table, with the payload being the encoded event
+CREATE TABLE outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id TEXT UNIQUE NOT NULL,
+    aggregate_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    event_version INTEGER NOT NULL,
+    payload BLOB NOT NULL,
+    created_at DATETIME NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    last_error TEXT,
+    published_at DATETIME
+);
+
+CREATE INDEX outbox_unpublished_idx ON outbox (aggregate_id, id) WHERE published_at IS NULL;
+CREATE INDEX outbox_published_at_idx ON outbox (published_at) WHERE published_at IS NOT NULL;
+
+-- +goose Down
+DROP TABLE outbox;
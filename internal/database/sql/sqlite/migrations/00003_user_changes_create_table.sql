@@ -0,0 +1,52 @@
+-- +goose Up
+-- Same change log as the Postgres user_changes table, appended to by triggers. SQLite has no notifications, so
+-- readers poll it. Timestamps are written in UTC in the format of the application's, so they compare as text.
+CREATE TABLE user_changes (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    operation TEXT NOT NULL CHECK (operation IN ('created', 'updated', 'deleted')),
+    user_id TEXT NOT NULL,
+    email TEXT NOT NULL,
+    username TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    deleted_at DATETIME,
+    changed_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX user_changes_changed_at_idx ON user_changes (changed_at);
+
+-- +goose StatementBegin
+CREATE TRIGGER users_record_insert AFTER INSERT ON users
+BEGIN
+    INSERT INTO user_changes (operation, user_id, email, username, created_at, updated_at, deleted_at)
+        VALUES ('created', NEW.id, NEW.email, NEW.username, NEW.created_at, NEW.updated_at, NEW.deleted_at);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER users_record_update AFTER UPDATE ON users
+BEGIN
+    INSERT INTO user_changes (operation, user_id, email, username, created_at, updated_at, deleted_at)
+        VALUES (
+            CASE WHEN OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN 'deleted' ELSE 'updated' END,
+            NEW.id, NEW.email, NEW.username, NEW.created_at, NEW.updated_at, NEW.deleted_at
+        );
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER users_record_delete AFTER DELETE ON users
+BEGIN
+    INSERT INTO user_changes (operation, user_id, email, username, created_at, updated_at, deleted_at)
+        VALUES ('deleted', OLD.id, OLD.email, OLD.username, OLD.created_at, OLD.updated_at, OLD.deleted_at);
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER users_record_delete;
+
+DROP TRIGGER users_record_update;
+
+DROP TRIGGER users_record_insert;
+
+DROP TABLE user_changes;
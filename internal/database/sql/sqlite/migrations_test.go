@@ -0,0 +1,12 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateMigrations(t *testing.T) {
+	t.Logf("Running success case: Testing the embedded migrations are valid")
+	assert.NoError(t, ValidateMigrations())
+}
@@ -0,0 +1,89 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vinofsteel/grpc-management/internal/config"
+	"github.com/vinofsteel/grpc-management/pkg"
+	_ "modernc.org/sqlite"
+)
+
+func init() {
+	// sqlx only knows the bind type of the cgo driver's name
+	sqlx.BindDriver("sqlite", sqlx.QUESTION)
+}
+
+// Database provider for SQLite files
+type SQLiteProvider struct {
+	dsn string
+
+	mu sync.Mutex
+	db *sqlx.DB
+}
+
+// GetConnection returns the connection pool once the database answers a ping, opening it on first use
+func (p *SQLiteProvider) GetConnection(ctx context.Context) (*sqlx.DB, error) {
+	db, err := p.pool()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("error reaching database: %w", err)
+	}
+
+	return db, nil
+}
+
+func (p *SQLiteProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.db != nil {
+		return p.db.Close()
+	}
+
+	return nil
+}
+
+func (p *SQLiteProvider) pool() (*sqlx.DB, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.db != nil {
+		return p.db, nil
+	}
+
+	db, err := sqlx.Open("sqlite", p.dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error opening DB connection: %w", err)
+	}
+
+	// SQLite has a single writer, so the pool settings are ignored: one connection serializes writes instead of
+	// failing them with SQLITE_BUSY, and keeping it open forever keeps the per-connection pragmas applied
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+
+	p.db = db
+	return db, nil
+}
+
+// Create a New SQLite db provider where needed, the file at config.Path is created on first use
+func NewSQLiteDatabaseProvider(config config.DatabaseConfig) pkg.DBProvider {
+	query := url.Values{}
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_pragma", "busy_timeout(5000)")
+	// Timestamps are written as "2006-01-02 15:04:05.999999999-07:00", which SQLite's date functions understand
+	query.Set("_time_format", "sqlite")
+
+	return &SQLiteProvider{
+		dsn: "file:" + config.Path + "?" + query.Encode(),
+	}
+}
@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vinofsteel/grpc-management/internal/database"
+)
+
+const userChangeColumns = `seq, operation, user_id, email, username, created_at, updated_at, deleted_at, changed_at`
+
+// SequenceUserChanges has nothing to do, SQLite commits one write transaction at a time so the change log's
+// AUTOINCREMENT sequences are already in commit order
+func (q *SQLiteQueries) SequenceUserChanges(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (q *SQLiteQueries) ListUserChanges(ctx context.Context, params database.ListUserChangesParams) ([]*database.UserChange, error) {
+	query := `SELECT ` + userChangeColumns + ` FROM user_changes WHERE seq > :after_sequence ORDER BY seq`
+	if params.Limit > 0 {
+		query += ` LIMIT :limit`
+	}
+
+	ctx, span := startSpan(ctx, "ListUserChanges", query)
+	defer span.End()
+
+	rows, err := sqlx.NamedQueryContext(ctx, q.db, query, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error querying user changes", "error", err, "after_sequence", params.AfterSequence)
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []*database.UserChange
+	for rows.Next() {
+		var change database.UserChange
+		if err := rows.StructScan(&change); err != nil {
+			slog.ErrorContext(ctx, "Error scanning user change", "error", err)
+			recordSpanError(span, err)
+			return nil, err
+		}
+		changes = append(changes, &change)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "Error iterating over user change rows", "error", err)
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func (q *SQLiteQueries) ListUserChangesBounds(ctx context.Context) (*database.UserChangesBounds, error) {
+	query := `SELECT COALESCE(MIN(seq), 0) AS first, COALESCE(MAX(seq), 0) AS last FROM user_changes`
+
+	ctx, span := startSpan(ctx, "ListUserChangesBounds", query)
+	defer span.End()
+
+	var bounds database.UserChangesBounds
+	if err := sqlx.GetContext(ctx, q.db, &bounds, query); err != nil {
+		slog.ErrorContext(ctx, "Error querying user changes bounds", "error", err)
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	return &bounds, nil
+}
+
+func (q *SQLiteQueries) DeleteUserChanges(ctx context.Context, params database.DeleteUserChangesParams) (int64, error) {
+	query := `DELETE FROM user_changes
+		WHERE changed_at < :changed_before AND seq < (SELECT MAX(seq) FROM user_changes)`
+
+	ctx, span := startSpan(ctx, "DeleteUserChanges", query)
+	defer span.End()
+
+	result, err := sqlx.NamedExecContext(ctx, q.db, query, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting user changes", "error", err)
+		recordSpanError(span, err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,281 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/vinofsteel/grpc-management/internal/database"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const userColumns = `id, created_at, updated_at, email, username, username_skeleton, password`
+
+func (q *SQLiteQueries) ListUserByEmail(ctx context.Context, params database.ListUserByEmailParams) (*database.User, error) {
+	slog.InfoContext(ctx, "Listing user by email", "email", params.Email, "layer", "repository", "driver", "sqlite")
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = :email`
+	if !params.ListDeleted {
+		query += ` AND deleted_at IS NULL`
+	}
+
+	ctx, span := startSpan(ctx, "ListUserByEmail", query)
+	defer span.End()
+
+	user, err := q.getUser(ctx, span, query, params)
+	if err != nil && err != sql.ErrNoRows {
+		slog.ErrorContext(ctx, "Error querying user by email", "error", err, "email", params.Email)
+	}
+
+	return user, err
+}
+
+func (q *SQLiteQueries) ListUserByUsername(ctx context.Context, params database.ListUserByUsernameParams) (*database.User, error) {
+	slog.InfoContext(ctx, "Listing user by username", "username", params.Username, "layer", "repository", "driver", "sqlite")
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE username = :username`
+	if !params.ListDeleted {
+		query += ` AND deleted_at IS NULL`
+	}
+
+	ctx, span := startSpan(ctx, "ListUserByUsername", query)
+	defer span.End()
+
+	user, err := q.getUser(ctx, span, query, params)
+	if err != nil && err != sql.ErrNoRows {
+		slog.ErrorContext(ctx, "Error querying user by username", "error", err, "username", params.Username)
+	}
+
+	return user, err
+}
+
+func (q *SQLiteQueries) ListUserByUsernameSkeleton(ctx context.Context, params database.ListUserByUsernameSkeletonParams) (*database.User, error) {
+	slog.InfoContext(ctx, "Listing user by username skeleton", "username_skeleton", params.UsernameSkeleton, "layer", "repository", "driver", "sqlite")
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE username_skeleton = :username_skeleton`
+	if !params.ListDeleted {
+		query += ` AND deleted_at IS NULL`
+	}
+
+	ctx, span := startSpan(ctx, "ListUserByUsernameSkeleton", query)
+	defer span.End()
+
+	user, err := q.getUser(ctx, span, query, params)
+	if err != nil && err != sql.ErrNoRows {
+		slog.ErrorContext(ctx, "Error querying user by username skeleton", "error", err, "username_skeleton", params.UsernameSkeleton)
+	}
+
+	return user, err
+}
+
+func (q *SQLiteQueries) ListUserById(ctx context.Context, params database.ListUserByIdParams) (*database.User, error) {
+	slog.InfoContext(ctx, "Listing user by id", "id", params.ID, "layer", "repository", "driver", "sqlite")
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = :id`
+	if !params.ListDeleted {
+		query += ` AND deleted_at IS NULL`
+	}
+
+	ctx, span := startSpan(ctx, "ListUserById", query)
+	defer span.End()
+
+	user, err := q.getUser(ctx, span, query, params)
+	if err != nil && err != sql.ErrNoRows {
+		slog.ErrorContext(ctx, "Error querying user by id", "error", err, "id", params.ID)
+	}
+
+	return user, err
+}
+
+func (q *SQLiteQueries) ListUsers(ctx context.Context, params database.ListUsersParams) ([]*database.User, error) {
+	slog.InfoContext(ctx, "Listing users", "limit", params.Limit, "offset", params.Offset, "list_deleted", params.ListDeleted, "layer", "repository", "driver", "sqlite")
+
+	query := `SELECT ` + userColumns + ` FROM users`
+	if !params.ListDeleted {
+		query += ` WHERE deleted_at IS NULL`
+	}
+
+	query += ` ORDER BY created_at DESC`
+
+	// SQLite only accepts OFFSET after a LIMIT, -1 meaning no limit
+	if params.Limit > 0 {
+		query += ` LIMIT :limit`
+	} else if params.Offset > 0 {
+		query += ` LIMIT -1`
+	}
+
+	if params.Offset > 0 {
+		query += ` OFFSET :offset`
+	}
+
+	ctx, span := startSpan(ctx, "ListUsers", query)
+	defer span.End()
+
+	var users []*database.User
+	rows, err := sqlx.NamedQueryContext(ctx, q.db, query, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error querying users", "error", err, "limit", params.Limit, "offset", params.Offset)
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user database.User
+		if err := rows.StructScan(&user); err != nil {
+			slog.ErrorContext(ctx, "Error scanning user from rows", "error", err)
+			recordSpanError(span, err)
+			return nil, err
+		}
+		users = append(users, &user)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "Error iterating over user rows", "error", err)
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Successfully listed users", "count", len(users), "limit", params.Limit, "offset", params.Offset)
+	return users, nil
+}
+
+func (q *SQLiteQueries) InsertUser(ctx context.Context, params database.InsertUserParams) (*database.User, error) {
+	slog.InfoContext(ctx, "Creating user", "email", params.Email, "username", params.Username, "layer", "repository", "driver", "sqlite")
+
+	query := `INSERT INTO users
+		(id, created_at, updated_at, email, username, username_skeleton, password)
+			VALUES (:id, :now, :now, :email, :username, :username_skeleton, :password)
+			RETURNING ` + userColumns
+
+	ctx, span := startSpan(ctx, "InsertUser", query)
+	defer span.End()
+
+	insertParams := struct {
+		database.InsertUserParams
+		ID  uuid.UUID `db:"id"`
+		Now time.Time `db:"now"`
+	}{
+		InsertUserParams: params,
+		ID:               uuid.New(),
+		Now:              timestamp(),
+	}
+
+	user, err := q.getUser(ctx, span, query, insertParams)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error inserting user", "error", err, "email", params.Email, "username", params.Username)
+		return nil, translateError(err)
+	}
+
+	return user, nil
+}
+
+func (q *SQLiteQueries) UpdateUserPassword(ctx context.Context, params database.UpdateUserPasswordParams) (*database.User, error) {
+	slog.InfoContext(ctx, "Updating user password", "user_id", params.UserID, "layer", "repository", "driver", "sqlite")
+
+	query := `UPDATE users SET password = :password, updated_at = :now WHERE id = :user_id
+		RETURNING ` + userColumns
+
+	ctx, span := startSpan(ctx, "UpdateUserPassword", query)
+	defer span.End()
+
+	updateParams := struct {
+		database.UpdateUserPasswordParams
+		Now time.Time `db:"now"`
+	}{
+		UpdateUserPasswordParams: params,
+		Now:                      timestamp(),
+	}
+
+	user, err := q.getUser(ctx, span, query, updateParams)
+	if err != nil && err != sql.ErrNoRows {
+		slog.ErrorContext(ctx, "Error updating user password", "error", err, "user_id", params.UserID)
+	}
+
+	return user, err
+}
+
+func (q *SQLiteQueries) UpdateUserUsername(ctx context.Context, params database.UpdateUserUsernameParams) (*database.User, error) {
+	slog.InfoContext(ctx, "Updating user username", "user_id", params.UserID, "username", params.Username, "layer", "repository", "driver", "sqlite")
+
+	query := `UPDATE users SET username = :username, username_skeleton = :username_skeleton, updated_at = :now
+		WHERE id = :user_id AND deleted_at IS NULL
+		RETURNING ` + userColumns
+
+	ctx, span := startSpan(ctx, "UpdateUserUsername", query)
+	defer span.End()
+
+	updateParams := struct {
+		database.UpdateUserUsernameParams
+		Now time.Time `db:"now"`
+	}{
+		UpdateUserUsernameParams: params,
+		Now:                      timestamp(),
+	}
+
+	user, err := q.getUser(ctx, span, query, updateParams)
+	if err != nil && err != sql.ErrNoRows {
+		slog.ErrorContext(ctx, "Error updating user username", "error", err, "user_id", params.UserID)
+		return nil, translateError(err)
+	}
+
+	return user, err
+}
+
+func (q *SQLiteQueries) DeleteUser(ctx context.Context, params database.DeleteUserParams) error {
+	slog.InfoContext(ctx, "Deleting user", "id", params.ID, "hard", params.Hard, "layer", "repository", "driver", "sqlite")
+
+	query := `UPDATE users SET deleted_at = :deleted_at WHERE id = :id`
+	if params.Hard {
+		query = `DELETE FROM users WHERE id = :id`
+	}
+
+	ctx, span := startSpan(ctx, "DeleteUser", query)
+	defer span.End()
+
+	deleteParams := struct {
+		ID        uuid.UUID `db:"id"`
+		DeletedAt time.Time `db:"deleted_at"`
+	}{
+		ID:        params.ID,
+		DeletedAt: timestamp(),
+	}
+
+	if _, err := sqlx.NamedExecContext(ctx, q.db, query, deleteParams); err != nil {
+		slog.ErrorContext(ctx, "Error deleting user", "error", err, "id", params.ID, "hard", params.Hard)
+		recordSpanError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+// Utilities
+// getUser runs a query returning at most one user, sql.ErrNoRows when it returns none
+func (q *SQLiteQueries) getUser(ctx context.Context, span trace.Span, query string, arg any) (*database.User, error) {
+	rows, err := sqlx.NamedQueryContext(ctx, q.db, query, arg)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			recordSpanError(span, err)
+			return nil, err
+		}
+		return nil, sql.ErrNoRows
+	}
+
+	var user database.User
+	if err := rows.StructScan(&user); err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,36 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/vinofsteel/grpc-management/internal/config"
+	"github.com/vinofsteel/grpc-management/internal/database"
+	"github.com/vinofsteel/grpc-management/internal/database/databasetest"
+)
+
+func TestSQLiteQueriesContract(t *testing.T) {
+	databasetest.RunQueriesContract(t, func(t *testing.T) database.Queries {
+		ctx := context.Background()
+
+		provider := NewSQLiteDatabaseProvider(config.DatabaseConfig{Path: filepath.Join(t.TempDir(), "test.db")})
+		t.Cleanup(func() { _ = provider.Close() })
+
+		db, err := provider.GetConnection(ctx)
+		if err != nil {
+			t.Fatalf("error opening test database: %v", err)
+		}
+
+		if err := MigrateUp(ctx, db.DB); err != nil {
+			t.Fatalf("error migrating test database: %v", err)
+		}
+
+		queries, err := NewSQLiteQueries(ctx, provider)
+		if err != nil {
+			t.Fatalf("error creating queries: %v", err)
+		}
+
+		return queries
+	})
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// Operations of a UserChange. Soft deletes are deletions too, while any other update of a deleted user is an update.
+const (
+	UserChangeCreated = "created"
+	UserChangeUpdated = "updated"
+	UserChangeDeleted = "deleted"
+)
+
+// Parameters
+type ListUserChangesParams struct {
+	AfterSequence int64 `json:"after_sequence" db:"after_sequence"`
+	Limit         int32 `json:"limit" db:"limit"`
+}
+
+type DeleteUserChangesParams struct {
+	ChangedBefore time.Time `json:"changed_before" db:"changed_before"`
+}
+
+// Interface
+// UserChangesRepository reads the change log of the users table, which every write to it appends to
+type UserChangesRepository interface {
+	// SequenceUserChanges assigns gap-free, increasing sequences to the changes recorded without one and returns how
+	// many it assigned. Changes are listed only once sequenced, which Postgres defers until every transaction with a
+	// lower ID has finished, ordering them by transaction ID rather than by commit. The other drivers sequence changes
+	// as they're recorded, in commit order.
+	SequenceUserChanges(ctx context.Context) (int64, error)
+	// ListUserChanges lists the changes after AfterSequence, oldest first. They're read from the primary, as a
+	// lagging replica would skip changes.
+	ListUserChanges(ctx context.Context, params ListUserChangesParams) ([]*UserChange, error)
+	// ListUserChangesBounds returns the sequences of the oldest and newest retained changes, zero when there are none
+	ListUserChangesBounds(ctx context.Context) (*UserChangesBounds, error)
+	// DeleteUserChanges deletes the changes made before ChangedBefore, except the newest, which keeps the bounds of
+	// the log meaningful. It returns how many were deleted.
+	DeleteUserChanges(ctx context.Context, params DeleteUserChangesParams) (int64, error)
+}
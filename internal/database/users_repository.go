@@ -17,6 +17,11 @@ type ListUserByUsernameParams struct {
 	ListDeleted bool   `json:"list_deleted" db:"list_deleted"`
 }
 
+type ListUserByUsernameSkeletonParams struct {
+	UsernameSkeleton string `json:"username_skeleton" db:"username_skeleton"`
+	ListDeleted      bool   `json:"list_deleted" db:"list_deleted"`
+}
+
 type ListUserByIdParams struct {
 	ID          uuid.UUID `json:"id" db:"id"`
 	ListDeleted bool      `json:"list_deleted" db:"list_deleted"`
@@ -29,9 +34,10 @@ type ListUsersParams struct {
 }
 
 type InsertUserParams struct {
-	Email    string `json:"email" db:"email"`
-	Username string `json:"username" db:"username"`
-	Password string `json:"password" db:"password"`
+	Email            string `json:"email" db:"email"`
+	Username         string `json:"username" db:"username"`
+	UsernameSkeleton string `json:"username_skeleton" db:"username_skeleton"`
+	Password         string `json:"password" db:"password"`
 }
 
 type UpdateUserPasswordParams struct {
@@ -39,6 +45,12 @@ type UpdateUserPasswordParams struct {
 	Password string    `json:"password" db:"password"`
 }
 
+type UpdateUserUsernameParams struct {
+	UserID           uuid.UUID `json:"user_id" db:"user_id"`
+	Username         string    `json:"username" db:"username"`
+	UsernameSkeleton string    `json:"username_skeleton" db:"username_skeleton"`
+}
+
 type DeleteUserParams struct {
 	ID   uuid.UUID `json:"id" db:"id"`
 	Hard bool      `json:"hard" db:"hard"`
@@ -48,9 +60,11 @@ type DeleteUserParams struct {
 type UsersRepository interface {
 	ListUserByEmail(ctx context.Context, params ListUserByEmailParams) (*User, error)
 	ListUserByUsername(ctx context.Context, params ListUserByUsernameParams) (*User, error)
+	ListUserByUsernameSkeleton(ctx context.Context, params ListUserByUsernameSkeletonParams) (*User, error)
 	ListUserById(ctx context.Context, params ListUserByIdParams) (*User, error)
 	ListUsers(ctx context.Context, params ListUsersParams) ([]*User, error)
 	InsertUser(ctx context.Context, params InsertUserParams) (*User, error)
 	UpdateUserPassword(ctx context.Context, params UpdateUserPasswordParams) (*User, error)
+	UpdateUserUsername(ctx context.Context, params UpdateUserUsernameParams) (*User, error)
 	DeleteUser(ctx context.Context, params DeleteUserParams) error
 }
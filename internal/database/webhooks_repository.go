@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of a WebhookDelivery. Failed deliveries are retried until they're delivered or, after too many attempts,
+// dead (dead-lettered), which only a redelivery retries.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryFailed    = "failed"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead"
+)
+
+// Parameters
+type InsertWebhookSubscriptionParams struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	URL        string     `json:"url" db:"url"`
+	EventTypes EventTypes `json:"event_types" db:"event_types"`
+	Secret     string     `json:"secret" db:"secret"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+type ListWebhookSubscriptionByIdParams struct {
+	ID uuid.UUID `json:"id" db:"id"`
+}
+
+type DeleteWebhookSubscriptionParams struct {
+	ID uuid.UUID `json:"id" db:"id"`
+}
+
+type InsertWebhookDeliveryParams struct {
+	ID             uuid.UUID `json:"id" db:"id"`
+	SubscriptionID uuid.UUID `json:"subscription_id" db:"subscription_id"`
+	EventID        uuid.UUID `json:"event_id" db:"event_id"`
+	EventType      string    `json:"event_type" db:"event_type"`
+	Payload        []byte    `json:"payload" db:"payload"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+type ClaimWebhookDeliveriesParams struct {
+	Now time.Time `json:"now" db:"now"`
+	// LeaseUntil is when the claimed deliveries can be claimed again, should their dispatcher stop before recording
+	// their attempt
+	LeaseUntil time.Time `json:"lease_until" db:"lease_until"`
+	Limit      int32     `json:"limit" db:"limit"`
+}
+
+type RecordWebhookDeliveryAttemptParams struct {
+	ID     uuid.UUID `json:"id" db:"id"`
+	Status string    `json:"status" db:"status"`
+	// ResponseCode is null when no response was received
+	ResponseCode  sql.NullInt32  `json:"response_code" db:"response_code"`
+	LatencyMs     int64          `json:"latency_ms" db:"latency_ms"`
+	Error         sql.NullString `json:"error" db:"error"`
+	AttemptedAt   time.Time      `json:"attempted_at" db:"attempted_at"`
+	NextAttemptAt time.Time      `json:"next_attempt_at" db:"next_attempt_at"`
+}
+
+type ListWebhookDeliveriesParams struct {
+	SubscriptionID uuid.UUID `json:"subscription_id" db:"subscription_id"`
+	// Status filters the deliveries when set
+	Status string `json:"status" db:"status"`
+	Limit  int32  `json:"limit" db:"limit"`
+	Offset int32  `json:"offset" db:"offset"`
+}
+
+type ListWebhookDeliveryByIdParams struct {
+	ID uuid.UUID `json:"id" db:"id"`
+}
+
+type RedeliverWebhookDeliveryParams struct {
+	ID  uuid.UUID `json:"id" db:"id"`
+	Now time.Time `json:"now" db:"now"`
+}
+
+// Interface
+// WebhooksRepository stores the webhook subscriptions and the log of the deliveries made to them
+type WebhooksRepository interface {
+	InsertWebhookSubscription(ctx context.Context, params InsertWebhookSubscriptionParams) (*WebhookSubscription, error)
+	// ListWebhookSubscriptions lists every subscription, oldest first
+	ListWebhookSubscriptions(ctx context.Context) ([]*WebhookSubscription, error)
+	ListWebhookSubscriptionById(ctx context.Context, params ListWebhookSubscriptionByIdParams) (*WebhookSubscription, error)
+	// DeleteWebhookSubscription deletes the subscription along with its deliveries, returning sql.ErrNoRows when
+	// there's none
+	DeleteWebhookSubscription(ctx context.Context, params DeleteWebhookSubscriptionParams) error
+
+	// InsertWebhookDelivery adds a pending delivery, due right away. A delivery of the same event to the same
+	// subscription is ignored, so an event published twice is delivered once.
+	InsertWebhookDelivery(ctx context.Context, params InsertWebhookDeliveryParams) error
+	// ClaimWebhookDeliveries leases up to Limit pending or failed deliveries that are due, oldest first
+	ClaimWebhookDeliveries(ctx context.Context, params ClaimWebhookDeliveriesParams) ([]*WebhookDelivery, error)
+	// RecordWebhookDeliveryAttempt records the outcome of an attempt, failed deliveries being claimable again from
+	// NextAttemptAt
+	RecordWebhookDeliveryAttempt(ctx context.Context, params RecordWebhookDeliveryAttemptParams) error
+	// ListWebhookDeliveries lists the deliveries of a subscription, newest first
+	ListWebhookDeliveries(ctx context.Context, params ListWebhookDeliveriesParams) ([]*WebhookDelivery, error)
+	ListWebhookDeliveryById(ctx context.Context, params ListWebhookDeliveryByIdParams) (*WebhookDelivery, error)
+	// RedeliverWebhookDelivery makes a delivery pending and due at Now again, with its attempts reset, whatever its
+	// status. It returns sql.ErrNoRows when there's none.
+	RedeliverWebhookDelivery(ctx context.Context, params RedeliverWebhookDeliveryParams) (*WebhookDelivery, error)
+}
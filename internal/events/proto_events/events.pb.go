@@ -0,0 +1,443 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v3.21.12
+// source: internal/events/proto_events/events.proto
+
+package proto_events
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Event is the envelope of every domain event. type and version name the payload's schema, so consumers can route
+// and decode events without unpacking them, and a new version is published when a payload changes incompatibly.
+type Event struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Unique, consumers receive events at least once and should deduplicate on it
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// e.g. "user.created"
+	Type    string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Version int32  `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	// User the event is about. Events of a user are published in the order they happened
+	UserId     string                 `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	OccurredAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*Event_UserCreated
+	//	*Event_UserUpdated
+	//	*Event_UserDeleted
+	Payload       isEvent_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_internal_events_proto_events_events_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_events_proto_events_events_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_internal_events_proto_events_events_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Event) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Event) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Event) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+func (x *Event) GetPayload() isEvent_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Event) GetUserCreated() *UserCreated {
+	if x != nil {
+		if x, ok := x.Payload.(*Event_UserCreated); ok {
+			return x.UserCreated
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetUserUpdated() *UserUpdated {
+	if x != nil {
+		if x, ok := x.Payload.(*Event_UserUpdated); ok {
+			return x.UserUpdated
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetUserDeleted() *UserDeleted {
+	if x != nil {
+		if x, ok := x.Payload.(*Event_UserDeleted); ok {
+			return x.UserDeleted
+		}
+	}
+	return nil
+}
+
+type isEvent_Payload interface {
+	isEvent_Payload()
+}
+
+type Event_UserCreated struct {
+	UserCreated *UserCreated `protobuf:"bytes,10,opt,name=user_created,json=userCreated,proto3,oneof"`
+}
+
+type Event_UserUpdated struct {
+	UserUpdated *UserUpdated `protobuf:"bytes,11,opt,name=user_updated,json=userUpdated,proto3,oneof"`
+}
+
+type Event_UserDeleted struct {
+	UserDeleted *UserDeleted `protobuf:"bytes,12,opt,name=user_deleted,json=userDeleted,proto3,oneof"`
+}
+
+func (*Event_UserCreated) isEvent_Payload() {}
+
+func (*Event_UserUpdated) isEvent_Payload() {}
+
+func (*Event_UserDeleted) isEvent_Payload() {}
+
+type UserCreated struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Email         string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Username      string                 `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserCreated) Reset() {
+	*x = UserCreated{}
+	mi := &file_internal_events_proto_events_events_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserCreated) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserCreated) ProtoMessage() {}
+
+func (x *UserCreated) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_events_proto_events_events_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserCreated.ProtoReflect.Descriptor instead.
+func (*UserCreated) Descriptor() ([]byte, []int) {
+	return file_internal_events_proto_events_events_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *UserCreated) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UserCreated) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *UserCreated) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *UserCreated) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type UserUpdated struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Names of the fields that changed, e.g. "password". Their values aren't part of the event
+	Fields        []string               `protobuf:"bytes,2,rep,name=fields,proto3" json:"fields,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserUpdated) Reset() {
+	*x = UserUpdated{}
+	mi := &file_internal_events_proto_events_events_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserUpdated) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserUpdated) ProtoMessage() {}
+
+func (x *UserUpdated) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_events_proto_events_events_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserUpdated.ProtoReflect.Descriptor instead.
+func (*UserUpdated) Descriptor() ([]byte, []int) {
+	return file_internal_events_proto_events_events_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *UserUpdated) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UserUpdated) GetFields() []string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+func (x *UserUpdated) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type UserDeleted struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// true when the user was removed from the database, false when only marked as deleted
+	Hard          bool `protobuf:"varint,2,opt,name=hard,proto3" json:"hard,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserDeleted) Reset() {
+	*x = UserDeleted{}
+	mi := &file_internal_events_proto_events_events_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserDeleted) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserDeleted) ProtoMessage() {}
+
+func (x *UserDeleted) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_events_proto_events_events_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserDeleted.ProtoReflect.Descriptor instead.
+func (*UserDeleted) Descriptor() ([]byte, []int) {
+	return file_internal_events_proto_events_events_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UserDeleted) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UserDeleted) GetHard() bool {
+	if x != nil {
+		return x.Hard
+	}
+	return false
+}
+
+var File_internal_events_proto_events_events_proto protoreflect.FileDescriptor
+
+const file_internal_events_proto_events_events_proto_rawDesc = "" +
+	"\n" +
+	")internal/events/proto_events/events.proto\x12\fproto_events\x1a\x1fgoogle/protobuf/timestamp.proto\"\xe6\x02\n" +
+	"\x05Event\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x05R\aversion\x12\x17\n" +
+	"\auser_id\x18\x04 \x01(\tR\x06userId\x12;\n" +
+	"\voccurred_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\x12>\n" +
+	"\fuser_created\x18\n" +
+	" \x01(\v2\x19.proto_events.UserCreatedH\x00R\vuserCreated\x12>\n" +
+	"\fuser_updated\x18\v \x01(\v2\x19.proto_events.UserUpdatedH\x00R\vuserUpdated\x12>\n" +
+	"\fuser_deleted\x18\f \x01(\v2\x19.proto_events.UserDeletedH\x00R\vuserDeletedB\t\n" +
+	"\apayload\"\x8a\x01\n" +
+	"\vUserCreated\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12\x1a\n" +
+	"\busername\x18\x03 \x01(\tR\busername\x129\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"p\n" +
+	"\vUserUpdated\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06fields\x18\x02 \x03(\tR\x06fields\x129\n" +
+	"\n" +
+	"updated_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"1\n" +
+	"\vUserDeleted\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04hard\x18\x02 \x01(\bR\x04hardB Z\x1e./internal/events/proto_eventsb\x06proto3"
+
+var (
+	file_internal_events_proto_events_events_proto_rawDescOnce sync.Once
+	file_internal_events_proto_events_events_proto_rawDescData []byte
+)
+
+func file_internal_events_proto_events_events_proto_rawDescGZIP() []byte {
+	file_internal_events_proto_events_events_proto_rawDescOnce.Do(func() {
+		file_internal_events_proto_events_events_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_internal_events_proto_events_events_proto_rawDesc), len(file_internal_events_proto_events_events_proto_rawDesc)))
+	})
+	return file_internal_events_proto_events_events_proto_rawDescData
+}
+
+var file_internal_events_proto_events_events_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_internal_events_proto_events_events_proto_goTypes = []any{
+	(*Event)(nil),                 // 0: proto_events.Event
+	(*UserCreated)(nil),           // 1: proto_events.UserCreated
+	(*UserUpdated)(nil),           // 2: proto_events.UserUpdated
+	(*UserDeleted)(nil),           // 3: proto_events.UserDeleted
+	(*timestamppb.Timestamp)(nil), // 4: google.protobuf.Timestamp
+}
+var file_internal_events_proto_events_events_proto_depIdxs = []int32{
+	4, // 0: proto_events.Event.occurred_at:type_name -> google.protobuf.Timestamp
+	1, // 1: proto_events.Event.user_created:type_name -> proto_events.UserCreated
+	2, // 2: proto_events.Event.user_updated:type_name -> proto_events.UserUpdated
+	3, // 3: proto_events.Event.user_deleted:type_name -> proto_events.UserDeleted
+	4, // 4: proto_events.UserCreated.created_at:type_name -> google.protobuf.Timestamp
+	4, // 5: proto_events.UserUpdated.updated_at:type_name -> google.protobuf.Timestamp
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_internal_events_proto_events_events_proto_init() }
+func file_internal_events_proto_events_events_proto_init() {
+	if File_internal_events_proto_events_events_proto != nil {
+		return
+	}
+	file_internal_events_proto_events_events_proto_msgTypes[0].OneofWrappers = []any{
+		(*Event_UserCreated)(nil),
+		(*Event_UserUpdated)(nil),
+		(*Event_UserDeleted)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_events_proto_events_events_proto_rawDesc), len(file_internal_events_proto_events_events_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_internal_events_proto_events_events_proto_goTypes,
+		DependencyIndexes: file_internal_events_proto_events_events_proto_depIdxs,
+		MessageInfos:      file_internal_events_proto_events_events_proto_msgTypes,
+	}.Build()
+	File_internal_events_proto_events_events_proto = out.File
+	file_internal_events_proto_events_events_proto_goTypes = nil
+	file_internal_events_proto_events_events_proto_depIdxs = nil
+}
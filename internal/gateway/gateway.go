@@ -0,0 +1,92 @@
+package gateway
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/vinofsteel/grpc-management/internal/handlers/proto_user"
+	"github.com/vinofsteel/grpc-management/internal/interceptors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// openapi.swagger.json is generated from the proto files together with the gateway code (see `make proto`)
+//
+//go:embed openapi.swagger.json
+var openAPIDocument []byte
+
+// Headers forwarded as-is to gRPC metadata, since the interceptors read them without the grpcgateway- prefix
+var forwardedHeaders = map[string]bool{
+	"Accept-Language": true,
+	"X-Request-Id":    true,
+	"X-Api-Key":       true,
+	"Traceparent":     true,
+	"Tracestate":      true,
+}
+
+type Config struct {
+	// Address the HTTP server listens on, e.g. ":8080"
+	Address string
+	// GRPCAddress is the gRPC server requests are forwarded to, so they go through the same interceptors
+	GRPCAddress string
+	// GRPCCredentials secure the connection to GRPCAddress, which is plaintext when nil
+	GRPCCredentials credentials.TransportCredentials
+}
+
+// New creates the HTTP/JSON server for UserService, AdminService being served over gRPC only to admin principals. gRPC
+// status codes are mapped to HTTP statuses and error details (such as validation violations) are kept in the body.
+func New(ctx context.Context, config Config) (*http.Server, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(incomingHeaderMatcher),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeaderMatcher),
+	)
+
+	grpcCredentials := config.GRPCCredentials
+	if grpcCredentials == nil {
+		grpcCredentials = insecure.NewCredentials()
+	}
+	dialOptions := []grpc.DialOption{grpc.WithTransportCredentials(grpcCredentials)}
+
+	if err := proto_user.RegisterUserServiceHandlerFromEndpoint(ctx, mux, config.GRPCAddress, dialOptions); err != nil {
+		return nil, fmt.Errorf("error registering UserService gateway: %w", err)
+	}
+
+	router := http.NewServeMux()
+	router.HandleFunc("GET /openapi.json", serveOpenAPIDocument)
+	router.Handle("/", mux)
+
+	return &http.Server{
+		Addr:              config.Address,
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}, nil
+}
+
+// Utilities
+func incomingHeaderMatcher(key string) (string, bool) {
+	if forwardedHeaders[textproto.CanonicalMIMEHeaderKey(key)] {
+		return key, true
+	}
+
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// outgoingHeaderMatcher returns the request ID as X-Request-Id, instead of the default Grpc-Metadata-X-Request-Id
+func outgoingHeaderMatcher(key string) (string, bool) {
+	if key == interceptors.RequestIDHeader {
+		return textproto.CanonicalMIMEHeaderKey(key), true
+	}
+
+	return runtime.MetadataHeaderPrefix + key, true
+}
+
+func serveOpenAPIDocument(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPIDocument)
+}
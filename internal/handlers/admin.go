@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinofsteel/grpc-management/internal/database"
+	"github.com/vinofsteel/grpc-management/internal/handlers/proto_admin"
+	"github.com/vinofsteel/grpc-management/internal/handlers/proto_user"
+	"github.com/vinofsteel/grpc-management/internal/handlers/proto_validate"
+	"github.com/vinofsteel/grpc-management/internal/outbox"
+	"github.com/vinofsteel/grpc-management/internal/validation"
+	"github.com/vinofsteel/grpc-management/internal/webhooks"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func (h *Handlers) CheckEmailPolicy(ctx context.Context, req *proto_admin.CheckEmailPolicyRequest) (*proto_admin.CheckEmailPolicyResponse, error) {
+	if req.TenantId != "" {
+		ctx = validation.ContextWithTenant(ctx, req.TenantId)
+	}
+
+	decision := h.EmailPolicy.Check(ctx, req.Email)
+
+	// Run the same rules CreateUser enforces, so the errors match what a sign up would get
+	var errors []string
+	if err := h.Validator.ValidateFields(ctx, []validation.Field{
+		{Name: "email", Value: req.Email, Rules: createUserEmailRules()},
+	}); err != nil {
+		errors = err.Errors
+	}
+
+	return &proto_admin.CheckEmailPolicyResponse{
+		Allowed: decision.Allowed && len(errors) == 0,
+		Domain:  decision.Domain,
+		Reason:  decision.Reason,
+		Rule:    decision.Rule,
+		Errors:  errors,
+	}, nil
+}
+
+func (h *Handlers) UpdateUsername(ctx context.Context, req *proto_admin.UpdateUsernameRequest) (*proto_user.UserResponse, error) {
+	userID, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
+	}
+
+	// Check if the username is taken by someone else
+	userWithExistingUsername, err := h.Queries.ListUserByUsername(ctx, database.ListUserByUsernameParams{
+		Username: req.Username,
+	})
+	if err != nil && err != sql.ErrNoRows {
+		slog.ErrorContext(ctx, "Database error while checking existing user", "error", err)
+		return nil, status.Errorf(codes.Internal, "internal server error")
+	}
+
+	if userWithExistingUsername != nil && userWithExistingUsername.ID != userID {
+		return nil, status.Errorf(codes.AlreadyExists, "user with username %s already exists", req.Username)
+	}
+
+	usernameSkeleton := h.UsernamePolicy.Skeleton(req.Username)
+	if err := h.checkConfusableUsername(ctx, usernameSkeleton, userID); err != nil {
+		return nil, err
+	}
+
+	dbUser, err := h.Queries.UpdateUserUsername(ctx, database.UpdateUserUsernameParams{
+		UserID:           userID,
+		Username:         req.Username,
+		UsernameSkeleton: usernameSkeleton,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		if errors.Is(err, database.ErrUniqueViolation) {
+			return nil, status.Errorf(codes.AlreadyExists, "user with username %s already exists", req.Username)
+		}
+		slog.ErrorContext(ctx, "Failed to update username in database", "error", err)
+		return nil, status.Errorf(codes.Internal, "failed to update username")
+	}
+
+	return &proto_user.UserResponse{
+		Id:        dbUser.ID.String(),
+		Email:     dbUser.Email,
+		Username:  dbUser.Username,
+		CreatedAt: dbUser.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: dbUser.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}, nil
+}
+
+func (h *Handlers) CreateWebhookSubscription(ctx context.Context, req *proto_admin.CreateWebhookSubscriptionRequest) (*proto_admin.WebhookSubscription, error) {
+	// Repeated fields aren't covered by the validation rules
+	var eventTypes database.EventTypes
+	for _, eventType := range req.EventTypes {
+		if !slices.Contains(outbox.EventTypes, eventType) {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown event type %q, expected one of [%s]", eventType, strings.Join(outbox.EventTypes, " "))
+		}
+		if !slices.Contains(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	if len(eventTypes) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "at least one event type is required")
+	}
+
+	// Checked again by the dispatcher on every connection, the host may be re-pointed later
+	if err := webhooks.CheckTarget(ctx, req.Url); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid webhook url: %v", err)
+	}
+
+	subscription, err := h.Queries.InsertWebhookSubscription(ctx, database.InsertWebhookSubscriptionParams{
+		ID:         uuid.New(),
+		URL:        req.Url,
+		EventTypes: eventTypes,
+		Secret:     req.Secret,
+		CreatedAt:  time.Now().UTC().Truncate(time.Microsecond),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create webhook subscription in database", "error", err)
+		return nil, status.Errorf(codes.Internal, "failed to create webhook subscription")
+	}
+
+	return webhookSubscriptionResponse(subscription), nil
+}
+
+func (h *Handlers) ListWebhookSubscriptions(ctx context.Context, req *proto_admin.ListWebhookSubscriptionsRequest) (*proto_admin.ListWebhookSubscriptionsResponse, error) {
+	subscriptions, err := h.Queries.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Database error while listing webhook subscriptions", "error", err)
+		return nil, status.Errorf(codes.Internal, "internal server error")
+	}
+
+	response := &proto_admin.ListWebhookSubscriptionsResponse{}
+	for _, subscription := range subscriptions {
+		response.Subscriptions = append(response.Subscriptions, webhookSubscriptionResponse(subscription))
+	}
+
+	return response, nil
+}
+
+func (h *Handlers) DeleteWebhookSubscription(ctx context.Context, req *proto_admin.DeleteWebhookSubscriptionRequest) (*proto_admin.DeleteWebhookSubscriptionResponse, error) {
+	subscriptionID, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid webhook subscription ID format")
+	}
+
+	err = h.Queries.DeleteWebhookSubscription(ctx, database.DeleteWebhookSubscriptionParams{ID: subscriptionID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Errorf(codes.NotFound, "webhook subscription not found")
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Database error while deleting webhook subscription", "error", err)
+		return nil, status.Errorf(codes.Internal, "internal server error")
+	}
+
+	return &proto_admin.DeleteWebhookSubscriptionResponse{}, nil
+}
+
+func (h *Handlers) ListWebhookDeliveries(ctx context.Context, req *proto_admin.ListWebhookDeliveriesRequest) (*proto_admin.ListWebhookDeliveriesResponse, error) {
+	subscriptionID, err := uuid.Parse(req.SubscriptionId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid webhook subscription ID format")
+	}
+
+	deliveries, err := h.Queries.ListWebhookDeliveries(ctx, database.ListWebhookDeliveriesParams{
+		SubscriptionID: subscriptionID,
+		Status:         req.Status,
+		Limit:          req.Limit,
+		Offset:         req.Offset,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Database error while listing webhook deliveries", "error", err)
+		return nil, status.Errorf(codes.Internal, "internal server error")
+	}
+
+	response := &proto_admin.ListWebhookDeliveriesResponse{}
+	for _, delivery := range deliveries {
+		response.Deliveries = append(response.Deliveries, webhookDeliveryResponse(delivery))
+	}
+
+	return response, nil
+}
+
+// RedeliverWebhook makes the delivery due right away, the dispatcher sending it on its next poll
+func (h *Handlers) RedeliverWebhook(ctx context.Context, req *proto_admin.RedeliverWebhookRequest) (*proto_admin.WebhookDelivery, error) {
+	deliveryID, err := uuid.Parse(req.DeliveryId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid webhook delivery ID format")
+	}
+
+	delivery, err := h.Queries.RedeliverWebhookDelivery(ctx, database.RedeliverWebhookDeliveryParams{
+		ID:  deliveryID,
+		Now: time.Now().UTC().Truncate(time.Microsecond),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Errorf(codes.NotFound, "webhook delivery not found")
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Database error while redelivering webhook", "error", err)
+		return nil, status.Errorf(codes.Internal, "internal server error")
+	}
+
+	slog.InfoContext(ctx, "Webhook delivery scheduled for redelivery", "delivery_id", delivery.ID, "subscription_id", delivery.SubscriptionID)
+	return webhookDeliveryResponse(delivery), nil
+}
+
+// Utilities
+// createUserEmailRules returns the validation rules declared for CreateUserRequest.email in user.proto
+func createUserEmailRules() string {
+	field := (&proto_user.CreateUserRequest{}).ProtoReflect().Descriptor().Fields().ByName("email")
+	rules, _ := proto.GetExtension(field.Options(), proto_validate.E_Rules).(string)
+	return rules
+}
+
+func webhookSubscriptionResponse(subscription *database.WebhookSubscription) *proto_admin.WebhookSubscription {
+	return &proto_admin.WebhookSubscription{
+		Id:         subscription.ID.String(),
+		Url:        subscription.URL,
+		EventTypes: subscription.EventTypes,
+		CreatedAt:  subscription.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+func webhookDeliveryResponse(delivery *database.WebhookDelivery) *proto_admin.WebhookDelivery {
+	response := &proto_admin.WebhookDelivery{
+		Id:             delivery.ID.String(),
+		SubscriptionId: delivery.SubscriptionID.String(),
+		EventId:        delivery.EventID.String(),
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		ResponseCode:   delivery.ResponseCode.Int32,
+		LatencyMs:      delivery.LatencyMs.Int64,
+		LastError:      delivery.LastError.String,
+		NextAttemptAt:  delivery.NextAttemptAt.Format("2006-01-02T15:04:05Z07:00"),
+		CreatedAt:      delivery.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if delivery.LastAttemptAt.Valid {
+		response.LastAttemptAt = delivery.LastAttemptAt.Time.Format("2006-01-02T15:04:05Z07:00")
+	}
+
+	return response
+}
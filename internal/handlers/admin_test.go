@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/vinofsteel/grpc-management/internal/database"
+	"github.com/vinofsteel/grpc-management/internal/handlers/proto_admin"
+	"github.com/vinofsteel/grpc-management/internal/handlers/proto_user"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWebhookSubscriptions(t *testing.T) {
+	h, _ := newTestHandlers(t)
+	ctx := context.Background()
+
+	t.Logf("Running failure case: Testing unknown event types are rejected")
+	_, err := h.CreateWebhookSubscription(ctx, &proto_admin.CreateWebhookSubscriptionRequest{
+		Url: "https://example.com/webhooks", EventTypes: []string{"user.renamed"}, Secret: "0123456789abcdef",
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	t.Logf("Running failure case: Testing urls pointing to internal addresses are rejected")
+	_, err = h.CreateWebhookSubscription(ctx, &proto_admin.CreateWebhookSubscriptionRequest{
+		Url: "http://169.254.169.254/latest/meta-data", EventTypes: []string{"user.created"}, Secret: "0123456789abcdef",
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	t.Logf("Running success case: Testing a subscription is created and listed without its secret")
+	created, err := h.CreateWebhookSubscription(ctx, &proto_admin.CreateWebhookSubscriptionRequest{
+		Url: "https://example.com/webhooks", EventTypes: []string{"user.created", "user.created"}, Secret: "0123456789abcdef",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user.created"}, created.EventTypes)
+
+	listed, err := h.ListWebhookSubscriptions(ctx, &proto_admin.ListWebhookSubscriptionsRequest{})
+	assert.NoError(t, err)
+	if assert.Len(t, listed.Subscriptions, 1) {
+		assert.Equal(t, created.Id, listed.Subscriptions[0].Id)
+	}
+
+	t.Logf("Running success case: Testing a delivery is redelivered")
+	deliveryID := uuid.New()
+	assert.NoError(t, h.Queries.InsertWebhookDelivery(ctx, database.InsertWebhookDeliveryParams{
+		ID:             deliveryID,
+		SubscriptionID: uuid.MustParse(created.Id),
+		EventID:        uuid.New(),
+		EventType:      "user.created",
+		Payload:        []byte(`{}`),
+	}))
+	redelivered, err := h.RedeliverWebhook(ctx, &proto_admin.RedeliverWebhookRequest{DeliveryId: deliveryID.String()})
+	assert.NoError(t, err)
+	assert.Equal(t, "pending", redelivered.Status)
+
+	deliveries, err := h.ListWebhookDeliveries(ctx, &proto_admin.ListWebhookDeliveriesRequest{SubscriptionId: created.Id, Status: "pending"})
+	assert.NoError(t, err)
+	assert.Len(t, deliveries.Deliveries, 1)
+
+	t.Logf("Running failure case: Testing a missing delivery isn't found")
+	_, err = h.RedeliverWebhook(ctx, &proto_admin.RedeliverWebhookRequest{DeliveryId: uuid.NewString()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	t.Logf("Running success case: Testing a deleted subscription takes its deliveries along")
+	_, err = h.DeleteWebhookSubscription(ctx, &proto_admin.DeleteWebhookSubscriptionRequest{Id: created.Id})
+	assert.NoError(t, err)
+	_, err = h.RedeliverWebhook(ctx, &proto_admin.RedeliverWebhookRequest{DeliveryId: deliveryID.String()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = h.DeleteWebhookSubscription(ctx, &proto_admin.DeleteWebhookSubscriptionRequest{Id: created.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestUpdateUsername(t *testing.T) {
+	h, existing := newTestHandlers(t)
+	ctx := context.Background()
+
+	other, err := h.CreateUser(ctx, &proto_user.CreateUserRequest{Email: "other@example.com", Username: "other", Password: "Password1!"})
+	assert.NoError(t, err)
+
+	t.Logf("Running success case: Testing a user can keep their own username")
+	user, err := h.UpdateUsername(ctx, &proto_admin.UpdateUsernameRequest{Id: existing.ID.String(), Username: "existing"})
+	assert.NoError(t, err)
+	assert.Equal(t, "existing", user.Username)
+
+	t.Logf("Running success case: Testing a free username is taken")
+	user, err = h.UpdateUsername(ctx, &proto_admin.UpdateUsernameRequest{Id: existing.ID.String(), Username: "renamed"})
+	assert.NoError(t, err)
+	assert.Equal(t, "renamed", user.Username)
+
+	t.Logf("Running failure case: Testing another user's username is rejected")
+	_, err = h.UpdateUsername(ctx, &proto_admin.UpdateUsernameRequest{Id: other.Id, Username: "renamed"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	t.Logf("Running failure case: Testing a missing user isn't found")
+	_, err = h.UpdateUsername(ctx, &proto_admin.UpdateUsernameRequest{Id: uuid.NewString(), Username: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
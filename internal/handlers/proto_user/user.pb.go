@@ -7,6 +7,8 @@
 package proto_user
 
 import (
+	_ "github.com/vinofsteel/grpc-management/internal/handlers/proto_validate"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -21,6 +23,58 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type UserChangeType int32
+
+const (
+	UserChangeType_USER_CHANGE_TYPE_UNSPECIFIED UserChangeType = 0
+	UserChangeType_USER_CHANGE_TYPE_CREATED     UserChangeType = 1
+	UserChangeType_USER_CHANGE_TYPE_UPDATED     UserChangeType = 2
+	UserChangeType_USER_CHANGE_TYPE_DELETED     UserChangeType = 3
+)
+
+// Enum value maps for UserChangeType.
+var (
+	UserChangeType_name = map[int32]string{
+		0: "USER_CHANGE_TYPE_UNSPECIFIED",
+		1: "USER_CHANGE_TYPE_CREATED",
+		2: "USER_CHANGE_TYPE_UPDATED",
+		3: "USER_CHANGE_TYPE_DELETED",
+	}
+	UserChangeType_value = map[string]int32{
+		"USER_CHANGE_TYPE_UNSPECIFIED": 0,
+		"USER_CHANGE_TYPE_CREATED":     1,
+		"USER_CHANGE_TYPE_UPDATED":     2,
+		"USER_CHANGE_TYPE_DELETED":     3,
+	}
+)
+
+func (x UserChangeType) Enum() *UserChangeType {
+	p := new(UserChangeType)
+	*p = x
+	return p
+}
+
+func (x UserChangeType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (UserChangeType) Descriptor() protoreflect.EnumDescriptor {
+	return file_internal_handlers_proto_user_user_proto_enumTypes[0].Descriptor()
+}
+
+func (UserChangeType) Type() protoreflect.EnumType {
+	return &file_internal_handlers_proto_user_user_proto_enumTypes[0]
+}
+
+func (x UserChangeType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use UserChangeType.Descriptor instead.
+func (UserChangeType) EnumDescriptor() ([]byte, []int) {
+	return file_internal_handlers_proto_user_user_proto_rawDescGZIP(), []int{0}
+}
+
 type CreateUserRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
@@ -385,16 +439,143 @@ func (x *ListUsersResponse) GetUsers() []*UserResponse {
 	return nil
 }
 
+type WatchUsersRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Sequence of the last change received. The retained changes after it are sent before the live ones, all of them
+	// when 0. A token older than the retained log fails with FAILED_PRECONDITION, the client then has to list the
+	// users again and watch from now on.
+	ResumeToken int64 `protobuf:"varint,1,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	// Skips the retained log, sending only the changes made from now on. To miss no change in between, list the users
+	// once the response headers are received, which are sent as soon as the stream is watching
+	StartAtNow    bool `protobuf:"varint,2,opt,name=start_at_now,json=startAtNow,proto3" json:"start_at_now,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchUsersRequest) Reset() {
+	*x = WatchUsersRequest{}
+	mi := &file_internal_handlers_proto_user_user_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchUsersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchUsersRequest) ProtoMessage() {}
+
+func (x *WatchUsersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_handlers_proto_user_user_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchUsersRequest.ProtoReflect.Descriptor instead.
+func (*WatchUsersRequest) Descriptor() ([]byte, []int) {
+	return file_internal_handlers_proto_user_user_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *WatchUsersRequest) GetResumeToken() int64 {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return 0
+}
+
+func (x *WatchUsersRequest) GetStartAtNow() bool {
+	if x != nil {
+		return x.StartAtNow
+	}
+	return false
+}
+
+type UserChange struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Increases with every change, it's the resume token of WatchUsers
+	Sequence int64          `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Type     UserChangeType `protobuf:"varint,2,opt,name=type,proto3,enum=proto_user.UserChangeType" json:"type,omitempty"`
+	// The user as the change left it, or as it was when hard deleted
+	User          *UserResponse `protobuf:"bytes,3,opt,name=user,proto3" json:"user,omitempty"`
+	ChangedAt     string        `protobuf:"bytes,4,opt,name=changed_at,json=changedAt,proto3" json:"changed_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserChange) Reset() {
+	*x = UserChange{}
+	mi := &file_internal_handlers_proto_user_user_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserChange) ProtoMessage() {}
+
+func (x *UserChange) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_handlers_proto_user_user_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserChange.ProtoReflect.Descriptor instead.
+func (*UserChange) Descriptor() ([]byte, []int) {
+	return file_internal_handlers_proto_user_user_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UserChange) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *UserChange) GetType() UserChangeType {
+	if x != nil {
+		return x.Type
+	}
+	return UserChangeType_USER_CHANGE_TYPE_UNSPECIFIED
+}
+
+func (x *UserChange) GetUser() *UserResponse {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *UserChange) GetChangedAt() string {
+	if x != nil {
+		return x.ChangedAt
+	}
+	return ""
+}
+
 var File_internal_handlers_proto_user_user_proto protoreflect.FileDescriptor
 
 const file_internal_handlers_proto_user_user_proto_rawDesc = "" +
 	"\n" +
 	"'internal/handlers/proto_user/user.proto\x12\n" +
-	"proto_user\"a\n" +
-	"\x11CreateUserRequest\x12\x14\n" +
-	"\x05email\x18\x01 \x01(\tR\x05email\x12\x1a\n" +
-	"\busername\x18\x02 \x01(\tR\busername\x12\x1a\n" +
-	"\bpassword\x18\x03 \x01(\tR\bpassword\"\x8e\x01\n" +
+	"proto_user\x1a\x1cgoogle/api/annotations.proto\x1a/internal/handlers/proto_validate/validate.proto\"\xdd\x01\n" +
+	"\x11CreateUserRequest\x125\n" +
+	"\x05email\x18\x01 \x01(\tB\x1f\xc2\xf3\x18\x1brequired,email,email_domainR\x05email\x12^\n" +
+	"\busername\x18\x02 \x01(\tBB\xc2\xf3\x18>required,min=3,max=25,alphanumunicode,not_reserved,not_profaneR\busername\x121\n" +
+	"\bpassword\x18\x03 \x01(\tB\x15\xc2\xf3\x18\x11required,passwordR\bpassword\"\x8e\x01\n" +
 	"\fUserResponse\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
 	"\x05email\x18\x02 \x01(\tR\x05email\x12\x1a\n" +
@@ -402,25 +583,43 @@ const file_internal_handlers_proto_user_user_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\x04 \x01(\tR\tcreatedAt\x12\x1d\n" +
 	"\n" +
-	"updated_at\x18\x05 \x01(\tR\tupdatedAt\"%\n" +
-	"\x13ListUserByIDRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\".\n" +
-	"\x16ListUserByEmailRequest\x12\x14\n" +
-	"\x05email\x18\x01 \x01(\tR\x05email\"7\n" +
-	"\x19ListUserByUsernameRequest\x12\x1a\n" +
-	"\busername\x18\x01 \x01(\tR\busername\"@\n" +
-	"\x10ListUsersRequest\x12\x16\n" +
-	"\x06offset\x18\x01 \x01(\x05R\x06offset\x12\x14\n" +
-	"\x05limit\x18\x02 \x01(\x05R\x05limit\"C\n" +
+	"updated_at\x18\x05 \x01(\tR\tupdatedAt\"8\n" +
+	"\x13ListUserByIDRequest\x12!\n" +
+	"\x02id\x18\x01 \x01(\tB\x11\xc2\xf3\x18\rrequired,uuidR\x02id\"B\n" +
+	"\x16ListUserByEmailRequest\x12(\n" +
+	"\x05email\x18\x01 \x01(\tB\x12\xc2\xf3\x18\x0erequired,emailR\x05email\"b\n" +
+	"\x19ListUserByUsernameRequest\x12E\n" +
+	"\busername\x18\x01 \x01(\tB)\xc2\xf3\x18%required,min=3,max=25,alphanumunicodeR\busername\"^\n" +
+	"\x10ListUsersRequest\x12!\n" +
+	"\x06offset\x18\x01 \x01(\x05B\t\xc2\xf3\x18\x05min=0R\x06offset\x12'\n" +
+	"\x05limit\x18\x02 \x01(\x05B\x11\xc2\xf3\x18\rmin=0,max=100R\x05limit\"C\n" +
 	"\x11ListUsersResponse\x12.\n" +
-	"\x05users\x18\x01 \x03(\v2\x18.proto_user.UserResponseR\x05users2\x9b\x03\n" +
-	"\vUserService\x12G\n" +
+	"\x05users\x18\x01 \x03(\v2\x18.proto_user.UserResponseR\x05users\"c\n" +
+	"\x11WatchUsersRequest\x12,\n" +
+	"\fresume_token\x18\x01 \x01(\x03B\t\xc2\xf3\x18\x05min=0R\vresumeToken\x12 \n" +
+	"\fstart_at_now\x18\x02 \x01(\bR\n" +
+	"startAtNow\"\xa5\x01\n" +
+	"\n" +
+	"UserChange\x12\x1a\n" +
+	"\bsequence\x18\x01 \x01(\x03R\bsequence\x12.\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x1a.proto_user.UserChangeTypeR\x04type\x12,\n" +
+	"\x04user\x18\x03 \x01(\v2\x18.proto_user.UserResponseR\x04user\x12\x1d\n" +
+	"\n" +
+	"changed_at\x18\x04 \x01(\tR\tchangedAt*\x8c\x01\n" +
+	"\x0eUserChangeType\x12 \n" +
+	"\x1cUSER_CHANGE_TYPE_UNSPECIFIED\x10\x00\x12\x1c\n" +
+	"\x18USER_CHANGE_TYPE_CREATED\x10\x01\x12\x1c\n" +
+	"\x18USER_CHANGE_TYPE_UPDATED\x10\x02\x12\x1c\n" +
+	"\x18USER_CHANGE_TYPE_DELETED\x10\x032\x80\x05\n" +
+	"\vUserService\x12[\n" +
+	"\n" +
+	"CreateUser\x12\x1d.proto_user.CreateUserRequest\x1a\x18.proto_user.UserResponse\"\x14\x82\xd3\xe4\x93\x02\x0e:\x01*\"\t/v1/users\x12a\n" +
+	"\fListUserByID\x12\x1f.proto_user.ListUserByIDRequest\x1a\x18.proto_user.UserResponse\"\x16\x82\xd3\xe4\x93\x02\x10\x12\x0e/v1/users/{id}\x12s\n" +
+	"\x0fListUserByEmail\x12\".proto_user.ListUserByEmailRequest\x1a\x18.proto_user.UserResponse\"\"\x82\xd3\xe4\x93\x02\x1c\x12\x1a/v1/users/by-email/{email}\x12\x7f\n" +
+	"\x12ListUserByUsername\x12%.proto_user.ListUserByUsernameRequest\x1a\x18.proto_user.UserResponse\"(\x82\xd3\xe4\x93\x02\"\x12 /v1/users/by-username/{username}\x12[\n" +
+	"\tListUsers\x12\x1c.proto_user.ListUsersRequest\x1a\x1d.proto_user.ListUsersResponse\"\x11\x82\xd3\xe4\x93\x02\v\x12\t/v1/users\x12^\n" +
 	"\n" +
-	"CreateUser\x12\x1d.proto_user.CreateUserRequest\x1a\x18.proto_user.UserResponse\"\x00\x12K\n" +
-	"\fListUserByID\x12\x1f.proto_user.ListUserByIDRequest\x1a\x18.proto_user.UserResponse\"\x00\x12Q\n" +
-	"\x0fListUserByEmail\x12\".proto_user.ListUserByEmailRequest\x1a\x18.proto_user.UserResponse\"\x00\x12W\n" +
-	"\x12ListUserByUsername\x12%.proto_user.ListUserByUsernameRequest\x1a\x18.proto_user.UserResponse\"\x00\x12J\n" +
-	"\tListUsers\x12\x1c.proto_user.ListUsersRequest\x1a\x1d.proto_user.ListUsersResponse\"\x00B Z\x1e./internal/handlers/proto_userb\x06proto3"
+	"WatchUsers\x12\x1d.proto_user.WatchUsersRequest\x1a\x16.proto_user.UserChange\"\x17\x82\xd3\xe4\x93\x02\x11\x12\x0f/v1/users:watch0\x01BDZBgithub.com/vinofsteel/grpc-management/internal/handlers/proto_userb\x06proto3"
 
 var (
 	file_internal_handlers_proto_user_user_proto_rawDescOnce sync.Once
@@ -434,33 +633,41 @@ func file_internal_handlers_proto_user_user_proto_rawDescGZIP() []byte {
 	return file_internal_handlers_proto_user_user_proto_rawDescData
 }
 
-var file_internal_handlers_proto_user_user_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_internal_handlers_proto_user_user_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_internal_handlers_proto_user_user_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
 var file_internal_handlers_proto_user_user_proto_goTypes = []any{
-	(*CreateUserRequest)(nil),         // 0: proto_user.CreateUserRequest
-	(*UserResponse)(nil),              // 1: proto_user.UserResponse
-	(*ListUserByIDRequest)(nil),       // 2: proto_user.ListUserByIDRequest
-	(*ListUserByEmailRequest)(nil),    // 3: proto_user.ListUserByEmailRequest
-	(*ListUserByUsernameRequest)(nil), // 4: proto_user.ListUserByUsernameRequest
-	(*ListUsersRequest)(nil),          // 5: proto_user.ListUsersRequest
-	(*ListUsersResponse)(nil),         // 6: proto_user.ListUsersResponse
+	(UserChangeType)(0),               // 0: proto_user.UserChangeType
+	(*CreateUserRequest)(nil),         // 1: proto_user.CreateUserRequest
+	(*UserResponse)(nil),              // 2: proto_user.UserResponse
+	(*ListUserByIDRequest)(nil),       // 3: proto_user.ListUserByIDRequest
+	(*ListUserByEmailRequest)(nil),    // 4: proto_user.ListUserByEmailRequest
+	(*ListUserByUsernameRequest)(nil), // 5: proto_user.ListUserByUsernameRequest
+	(*ListUsersRequest)(nil),          // 6: proto_user.ListUsersRequest
+	(*ListUsersResponse)(nil),         // 7: proto_user.ListUsersResponse
+	(*WatchUsersRequest)(nil),         // 8: proto_user.WatchUsersRequest
+	(*UserChange)(nil),                // 9: proto_user.UserChange
 }
 var file_internal_handlers_proto_user_user_proto_depIdxs = []int32{
-	1, // 0: proto_user.ListUsersResponse.users:type_name -> proto_user.UserResponse
-	0, // 1: proto_user.UserService.CreateUser:input_type -> proto_user.CreateUserRequest
-	2, // 2: proto_user.UserService.ListUserByID:input_type -> proto_user.ListUserByIDRequest
-	3, // 3: proto_user.UserService.ListUserByEmail:input_type -> proto_user.ListUserByEmailRequest
-	4, // 4: proto_user.UserService.ListUserByUsername:input_type -> proto_user.ListUserByUsernameRequest
-	5, // 5: proto_user.UserService.ListUsers:input_type -> proto_user.ListUsersRequest
-	1, // 6: proto_user.UserService.CreateUser:output_type -> proto_user.UserResponse
-	1, // 7: proto_user.UserService.ListUserByID:output_type -> proto_user.UserResponse
-	1, // 8: proto_user.UserService.ListUserByEmail:output_type -> proto_user.UserResponse
-	1, // 9: proto_user.UserService.ListUserByUsername:output_type -> proto_user.UserResponse
-	6, // 10: proto_user.UserService.ListUsers:output_type -> proto_user.ListUsersResponse
-	6, // [6:11] is the sub-list for method output_type
-	1, // [1:6] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	2, // 0: proto_user.ListUsersResponse.users:type_name -> proto_user.UserResponse
+	0, // 1: proto_user.UserChange.type:type_name -> proto_user.UserChangeType
+	2, // 2: proto_user.UserChange.user:type_name -> proto_user.UserResponse
+	1, // 3: proto_user.UserService.CreateUser:input_type -> proto_user.CreateUserRequest
+	3, // 4: proto_user.UserService.ListUserByID:input_type -> proto_user.ListUserByIDRequest
+	4, // 5: proto_user.UserService.ListUserByEmail:input_type -> proto_user.ListUserByEmailRequest
+	5, // 6: proto_user.UserService.ListUserByUsername:input_type -> proto_user.ListUserByUsernameRequest
+	6, // 7: proto_user.UserService.ListUsers:input_type -> proto_user.ListUsersRequest
+	8, // 8: proto_user.UserService.WatchUsers:input_type -> proto_user.WatchUsersRequest
+	2, // 9: proto_user.UserService.CreateUser:output_type -> proto_user.UserResponse
+	2, // 10: proto_user.UserService.ListUserByID:output_type -> proto_user.UserResponse
+	2, // 11: proto_user.UserService.ListUserByEmail:output_type -> proto_user.UserResponse
+	2, // 12: proto_user.UserService.ListUserByUsername:output_type -> proto_user.UserResponse
+	7, // 13: proto_user.UserService.ListUsers:output_type -> proto_user.ListUsersResponse
+	9, // 14: proto_user.UserService.WatchUsers:output_type -> proto_user.UserChange
+	9, // [9:15] is the sub-list for method output_type
+	3, // [3:9] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_internal_handlers_proto_user_user_proto_init() }
@@ -473,13 +680,14 @@ func file_internal_handlers_proto_user_user_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_handlers_proto_user_user_proto_rawDesc), len(file_internal_handlers_proto_user_user_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   7,
+			NumEnums:      1,
+			NumMessages:   9,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_internal_handlers_proto_user_user_proto_goTypes,
 		DependencyIndexes: file_internal_handlers_proto_user_user_proto_depIdxs,
+		EnumInfos:         file_internal_handlers_proto_user_user_proto_enumTypes,
 		MessageInfos:      file_internal_handlers_proto_user_user_proto_msgTypes,
 	}.Build()
 	File_internal_handlers_proto_user_user_proto = out.File
@@ -24,6 +24,7 @@ const (
 	UserService_ListUserByEmail_FullMethodName    = "/proto_user.UserService/ListUserByEmail"
 	UserService_ListUserByUsername_FullMethodName = "/proto_user.UserService/ListUserByUsername"
 	UserService_ListUsers_FullMethodName          = "/proto_user.UserService/ListUsers"
+	UserService_WatchUsers_FullMethodName         = "/proto_user.UserService/WatchUsers"
 )
 
 // UserServiceClient is the client API for UserService service.
@@ -35,6 +36,11 @@ type UserServiceClient interface {
 	ListUserByEmail(ctx context.Context, in *ListUserByEmailRequest, opts ...grpc.CallOption) (*UserResponse, error)
 	ListUserByUsername(ctx context.Context, in *ListUserByUsernameRequest, opts ...grpc.CallOption) (*UserResponse, error)
 	ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*ListUsersResponse, error)
+	// WatchUsers streams every user created, updated or deleted. Sequences are gap-free and increasing, and a change is
+	// only streamed once no change with a lower sequence can still appear. On Postgres they follow the order of the
+	// writing transactions' IDs rather than of their commits, so concurrent writes to the same user may be streamed
+	// in the opposite order to the one they were committed in.
+	WatchUsers(ctx context.Context, in *WatchUsersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[UserChange], error)
 }
 
 type userServiceClient struct {
@@ -95,6 +101,25 @@ func (c *userServiceClient) ListUsers(ctx context.Context, in *ListUsersRequest,
 	return out, nil
 }
 
+func (c *userServiceClient) WatchUsers(ctx context.Context, in *WatchUsersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[UserChange], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &UserService_ServiceDesc.Streams[0], UserService_WatchUsers_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchUsersRequest, UserChange]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UserService_WatchUsersClient = grpc.ServerStreamingClient[UserChange]
+
 // UserServiceServer is the server API for UserService service.
 // All implementations must embed UnimplementedUserServiceServer
 // for forward compatibility.
@@ -104,6 +129,11 @@ type UserServiceServer interface {
 	ListUserByEmail(context.Context, *ListUserByEmailRequest) (*UserResponse, error)
 	ListUserByUsername(context.Context, *ListUserByUsernameRequest) (*UserResponse, error)
 	ListUsers(context.Context, *ListUsersRequest) (*ListUsersResponse, error)
+	// WatchUsers streams every user created, updated or deleted. Sequences are gap-free and increasing, and a change is
+	// only streamed once no change with a lower sequence can still appear. On Postgres they follow the order of the
+	// writing transactions' IDs rather than of their commits, so concurrent writes to the same user may be streamed
+	// in the opposite order to the one they were committed in.
+	WatchUsers(*WatchUsersRequest, grpc.ServerStreamingServer[UserChange]) error
 	mustEmbedUnimplementedUserServiceServer()
 }
 
@@ -129,6 +159,9 @@ func (UnimplementedUserServiceServer) ListUserByUsername(context.Context, *ListU
 func (UnimplementedUserServiceServer) ListUsers(context.Context, *ListUsersRequest) (*ListUsersResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListUsers not implemented")
 }
+func (UnimplementedUserServiceServer) WatchUsers(*WatchUsersRequest, grpc.ServerStreamingServer[UserChange]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchUsers not implemented")
+}
 func (UnimplementedUserServiceServer) mustEmbedUnimplementedUserServiceServer() {}
 func (UnimplementedUserServiceServer) testEmbeddedByValue()                     {}
 
@@ -240,6 +273,17 @@ func _UserService_ListUsers_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UserService_WatchUsers_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchUsersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UserServiceServer).WatchUsers(m, &grpc.GenericServerStream[WatchUsersRequest, UserChange]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UserService_WatchUsersServer = grpc.ServerStreamingServer[UserChange]
+
 // UserService_ServiceDesc is the grpc.ServiceDesc for UserService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -268,6 +312,12 @@ var UserService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _UserService_ListUsers_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchUsers",
+			Handler:       _UserService_WatchUsers_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "internal/handlers/proto_user/user.proto",
 }
@@ -0,0 +1,85 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v3.21.12
+// source: internal/handlers/proto_validate/validate.proto
+
+package proto_validate
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+var file_internal_handlers_proto_validate_validate_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*string)(nil),
+		Field:         51000,
+		Name:          "proto_validate.rules",
+		Tag:           "bytes,51000,opt,name=rules",
+		Filename:      "internal/handlers/proto_validate/validate.proto",
+	},
+}
+
+// Extension fields to descriptorpb.FieldOptions.
+var (
+	// Validation rules for the field, written with the same tag syntax used by go-playground/validator,
+	// e.g. "required,email". They are enforced for every RPC by the validation interceptor.
+	//
+	// optional string rules = 51000;
+	E_Rules = &file_internal_handlers_proto_validate_validate_proto_extTypes[0]
+)
+
+var File_internal_handlers_proto_validate_validate_proto protoreflect.FileDescriptor
+
+const file_internal_handlers_proto_validate_validate_proto_rawDesc = "" +
+	"\n" +
+	"/internal/handlers/proto_validate/validate.proto\x12\x0eproto_validate\x1a google/protobuf/descriptor.proto:5\n" +
+	"\x05rules\x12\x1d.google.protobuf.FieldOptions\x18\xb8\x8e\x03 \x01(\tR\x05rulesBHZFgithub.com/vinofsteel/grpc-management/internal/handlers/proto_validateb\x06proto3"
+
+var file_internal_handlers_proto_validate_validate_proto_goTypes = []any{
+	(*descriptorpb.FieldOptions)(nil), // 0: google.protobuf.FieldOptions
+}
+var file_internal_handlers_proto_validate_validate_proto_depIdxs = []int32{
+	0, // 0: proto_validate.rules:extendee -> google.protobuf.FieldOptions
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	0, // [0:1] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_internal_handlers_proto_validate_validate_proto_init() }
+func file_internal_handlers_proto_validate_validate_proto_init() {
+	if File_internal_handlers_proto_validate_validate_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_handlers_proto_validate_validate_proto_rawDesc), len(file_internal_handlers_proto_validate_validate_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   0,
+			NumExtensions: 1,
+			NumServices:   0,
+		},
+		GoTypes:           file_internal_handlers_proto_validate_validate_proto_goTypes,
+		DependencyIndexes: file_internal_handlers_proto_validate_validate_proto_depIdxs,
+		ExtensionInfos:    file_internal_handlers_proto_validate_validate_proto_extTypes,
+	}.Build()
+	File_internal_handlers_proto_validate_validate_proto = out.File
+	file_internal_handlers_proto_validate_validate_proto_goTypes = nil
+	file_internal_handlers_proto_validate_validate_proto_depIdxs = nil
+}
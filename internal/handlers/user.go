@@ -3,32 +3,19 @@ package handlers
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"log/slog"
 
 	"github.com/google/uuid"
 	"github.com/vinofsteel/grpc-management/internal/database"
 	"github.com/vinofsteel/grpc-management/internal/handlers/proto_user"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/vinofsteel/grpc-management/internal/password"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
 func (h *Handlers) CreateUser(ctx context.Context, newUser *proto_user.CreateUserRequest) (*proto_user.UserResponse, error) {
-	slog.InfoContext(ctx, "Received request to create a new user", "email", newUser.Email, "username", newUser.Username)
-
-	// Inline validation struct
-	if err := h.validateRequest(ctx, struct {
-		Email    string `validate:"required,email"`
-		Username string `validate:"required,min=3,max=50,alphanum"`
-		Password string `validate:"required,password"`
-	}{
-		Email:    newUser.Email,
-		Username: newUser.Username,
-		Password: newUser.Password,
-	}, "CreateUser"); err != nil {
-		return nil, err
-	}
-
 	// Check if user already exists
 	userWithExistingEmail, err := h.Queries.ListUserByEmail(ctx, database.ListUserByEmailParams{
 		Email: newUser.Email,
@@ -39,7 +26,6 @@ func (h *Handlers) CreateUser(ctx context.Context, newUser *proto_user.CreateUse
 	}
 
 	if userWithExistingEmail != nil {
-		slog.WarnContext(ctx, "User already exists", "email", newUser.Email)
 		return nil, status.Errorf(codes.AlreadyExists, "user with email %s already exists", newUser.Email)
 	}
 
@@ -52,30 +38,42 @@ func (h *Handlers) CreateUser(ctx context.Context, newUser *proto_user.CreateUse
 	}
 
 	if userWithExistingUsername != nil {
-		slog.WarnContext(ctx, "User already exists", "username", newUser.Username)
 		return nil, status.Errorf(codes.AlreadyExists, "user with username %s already exists", newUser.Username)
 	}
 
+	// Look-alikes of existing usernames (e.g. "paypa1" for "paypal") are rejected as well
+	usernameSkeleton := h.UsernamePolicy.Skeleton(newUser.Username)
+	if err := h.checkConfusableUsername(ctx, usernameSkeleton, uuid.Nil); err != nil {
+		return nil, err
+	}
+
 	// Encrypting user's password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newUser.Password), 12)
+	hashedPassword, err := h.Hasher.Hash(ctx, newUser.Password)
+	if errors.Is(err, password.ErrHasherSaturated) {
+		return nil, status.Errorf(codes.Unavailable, "server overloaded, retry later")
+	}
 	if err != nil {
 		slog.ErrorContext(ctx, "Error encrypting user's password", "error", err)
 		return nil, status.Errorf(codes.Internal, "internal server error")
 	}
-	newUser.Password = string(hashedPassword)
+	newUser.Password = hashedPassword
 
 	// Create user in database
 	dbUser, err := h.Queries.InsertUser(ctx, database.InsertUserParams{
-		Email:    newUser.Email,
-		Username: newUser.Username,
-		Password: newUser.Password,
+		Email:            newUser.Email,
+		Username:         newUser.Username,
+		UsernameSkeleton: usernameSkeleton,
+		Password:         newUser.Password,
 	})
+	// A concurrent request may have taken the email or username since the checks above
+	if errors.Is(err, database.ErrUniqueViolation) {
+		return nil, status.Errorf(codes.AlreadyExists, "user with this email or username already exists")
+	}
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to create user in database", "error", err)
 		return nil, status.Errorf(codes.Internal, "failed to create user")
 	}
 
-	slog.InfoContext(ctx, "User created successfully", "id", dbUser.ID, "email", dbUser.Email, "username", dbUser.Username)
 	return &proto_user.UserResponse{
 		Id:        dbUser.ID.String(),
 		Email:     dbUser.Email,
@@ -86,12 +84,9 @@ func (h *Handlers) CreateUser(ctx context.Context, newUser *proto_user.CreateUse
 }
 
 func (h *Handlers) ListUserByID(ctx context.Context, req *proto_user.ListUserByIDRequest) (*proto_user.UserResponse, error) {
-	slog.InfoContext(ctx, "Received request to get user", "id", req.Id)
-
 	// Validate UUID format
 	userID, err := uuid.Parse(req.Id)
 	if err != nil {
-		slog.WarnContext(ctx, "Invalid user ID format", "id", req.Id, "error", err)
 		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID format")
 	}
 
@@ -102,14 +97,12 @@ func (h *Handlers) ListUserByID(ctx context.Context, req *proto_user.ListUserByI
 	})
 	if err != nil {
 		if err == sql.ErrNoRows {
-			slog.WarnContext(ctx, "User not found", "id", req.Id)
 			return nil, status.Errorf(codes.NotFound, "user not found")
 		}
 		slog.ErrorContext(ctx, "Database error while fetching user", "error", err)
 		return nil, status.Errorf(codes.Internal, "internal server error")
 	}
 
-	slog.InfoContext(ctx, "User retrieved successfully", "id", dbUser.ID, "email", dbUser.Email, "username", dbUser.Username)
 	return &proto_user.UserResponse{
 		Id:        dbUser.ID.String(),
 		Email:     dbUser.Email,
@@ -120,31 +113,18 @@ func (h *Handlers) ListUserByID(ctx context.Context, req *proto_user.ListUserByI
 }
 
 func (h *Handlers) ListUserByEmail(ctx context.Context, req *proto_user.ListUserByEmailRequest) (*proto_user.UserResponse, error) {
-	slog.InfoContext(ctx, "Received request to get user", "email", req.Email)
-
-	// Inline validation for email
-	if err := h.validateRequest(ctx, struct {
-		Email string `validate:"required,email"`
-	}{
-		Email: req.Email,
-	}, "ListUserByEmail"); err != nil {
-		return nil, err
-	}
-
 	// Get user from database
 	dbUser, err := h.Queries.ListUserByEmail(ctx, database.ListUserByEmailParams{
 		Email: req.Email,
 	})
 	if err != nil {
 		if err == sql.ErrNoRows {
-			slog.WarnContext(ctx, "User not found", "email", req.Email)
 			return nil, status.Errorf(codes.NotFound, "user not found")
 		}
 		slog.ErrorContext(ctx, "Database error while fetching user", "error", err)
 		return nil, status.Errorf(codes.Internal, "internal server error")
 	}
 
-	slog.InfoContext(ctx, "User retrieved successfully", "id", dbUser.ID, "email", dbUser.Email, "username", dbUser.Username)
 	return &proto_user.UserResponse{
 		Id:        dbUser.ID.String(),
 		Email:     dbUser.Email,
@@ -155,31 +135,18 @@ func (h *Handlers) ListUserByEmail(ctx context.Context, req *proto_user.ListUser
 }
 
 func (h *Handlers) ListUserByUsername(ctx context.Context, req *proto_user.ListUserByUsernameRequest) (*proto_user.UserResponse, error) {
-	slog.InfoContext(ctx, "Received request to get user", "username", req.Username)
-
-	// Inline validation for username
-	if err := h.validateRequest(ctx, struct {
-		Username string `validate:"required,min=3,max=50,alphanum"`
-	}{
-		Username: req.Username,
-	}, "ListUserByUsername"); err != nil {
-		return nil, err
-	}
-
 	// Get user from database
 	dbUser, err := h.Queries.ListUserByUsername(ctx, database.ListUserByUsernameParams{
 		Username: req.Username,
 	})
 	if err != nil {
 		if err == sql.ErrNoRows {
-			slog.WarnContext(ctx, "User not found", "username", req.Username)
 			return nil, status.Errorf(codes.NotFound, "user not found")
 		}
 		slog.ErrorContext(ctx, "Database error while fetching user", "error", err)
 		return nil, status.Errorf(codes.Internal, "internal server error")
 	}
 
-	slog.InfoContext(ctx, "User retrieved successfully", "id", dbUser.ID, "email", dbUser.Email, "username", dbUser.Username)
 	return &proto_user.UserResponse{
 		Id:        dbUser.ID.String(),
 		Email:     dbUser.Email,
@@ -190,18 +157,6 @@ func (h *Handlers) ListUserByUsername(ctx context.Context, req *proto_user.ListU
 }
 
 func (h *Handlers) ListUsers(ctx context.Context, req *proto_user.ListUsersRequest) (*proto_user.ListUsersResponse, error) {
-	slog.InfoContext(ctx, "Received request to list users", "limit", req.Limit, "offset", req.Offset)
-
-	if err := h.validateRequest(ctx, struct {
-		Limit  int32 `validate:"min=0,max=100"`
-		Offset int32 `validate:"min=0"`
-	}{
-		Limit:  req.Limit,
-		Offset: req.Offset,
-	}, "ListUsers"); err != nil {
-		return nil, err
-	}
-
 	// Set default pagination values if not provided
 	limit := req.Limit
 	offset := req.Offset
@@ -238,8 +193,117 @@ func (h *Handlers) ListUsers(ctx context.Context, req *proto_user.ListUsersReque
 		}
 	}
 
-	slog.InfoContext(ctx, "Users retrieved successfully", "count", len(users), "limit", limit, "offset", offset)
 	return &proto_user.ListUsersResponse{
 		Users: users,
 	}, nil
 }
+
+// WatchUsers sends the retained changes after the resume token, then the changes made while the stream is open
+func (h *Handlers) WatchUsers(req *proto_user.WatchUsersRequest, stream proto_user.UserService_WatchUsersServer) error {
+	ctx := stream.Context()
+
+	// Subscribing first, so changes made while catching up are received live instead of being missed
+	subscription := h.ChangeFeed.Subscribe()
+	defer subscription.Close()
+
+	bounds, err := h.Queries.ListUserChangesBounds(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Database error while reading user changes bounds", "error", err)
+		return status.Errorf(codes.Internal, "internal server error")
+	}
+
+	last := req.ResumeToken
+	switch {
+	case req.StartAtNow:
+		last = bounds.Last
+	case last > bounds.Last:
+		return status.Errorf(codes.InvalidArgument, "resume token %d is ahead of the last change", last)
+	case last < bounds.First-1:
+		return status.Errorf(codes.FailedPrecondition, "changes after resume token %d are no longer retained, list the users again and watch from now", last)
+	}
+
+	// Tells the client no change is missed from here on, e.g. to list the users when starting at now
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		changes, err := h.Queries.ListUserChanges(ctx, database.ListUserChangesParams{AfterSequence: last, Limit: watchBatchSize})
+		if err != nil {
+			slog.ErrorContext(ctx, "Database error while listing user changes", "error", err, "after_sequence", last)
+			return status.Errorf(codes.Internal, "internal server error")
+		}
+
+		for _, change := range changes {
+			if err := stream.Send(userChangeResponse(change)); err != nil {
+				return err
+			}
+			last = change.Sequence
+		}
+
+		if len(changes) < watchBatchSize {
+			break
+		}
+	}
+
+	for {
+		select {
+		case change := <-subscription.Changes():
+			// Already sent while catching up
+			if change.Sequence <= last {
+				continue
+			}
+
+			if err := stream.Send(userChangeResponse(change)); err != nil {
+				return err
+			}
+			last = change.Sequence
+		case <-subscription.Dropped():
+			return status.Errorf(codes.ResourceExhausted, "stream fell behind the changes, resume from sequence %d", last)
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// checkConfusableUsername fails with AlreadyExists when a user other than ownerID has a username with the same skeleton
+func (h *Handlers) checkConfusableUsername(ctx context.Context, usernameSkeleton string, ownerID uuid.UUID) error {
+	confusableUser, err := h.Queries.ListUserByUsernameSkeleton(ctx, database.ListUserByUsernameSkeletonParams{
+		UsernameSkeleton: usernameSkeleton,
+		ListDeleted:      true, // Deleted users keep their skeleton in the unique index
+	})
+	if err != nil && err != sql.ErrNoRows {
+		slog.ErrorContext(ctx, "Database error while checking confusable usernames", "error", err)
+		return status.Errorf(codes.Internal, "internal server error")
+	}
+
+	if confusableUser != nil && confusableUser.ID != ownerID {
+		return status.Errorf(codes.AlreadyExists, "username is too similar to an existing username")
+	}
+
+	return nil
+}
+
+// watchBatchSize is how many changes WatchUsers reads at a time while catching up
+const watchBatchSize = 500
+
+var userChangeTypes = map[string]proto_user.UserChangeType{
+	database.UserChangeCreated: proto_user.UserChangeType_USER_CHANGE_TYPE_CREATED,
+	database.UserChangeUpdated: proto_user.UserChangeType_USER_CHANGE_TYPE_UPDATED,
+	database.UserChangeDeleted: proto_user.UserChangeType_USER_CHANGE_TYPE_DELETED,
+}
+
+func userChangeResponse(change *database.UserChange) *proto_user.UserChange {
+	return &proto_user.UserChange{
+		Sequence: change.Sequence,
+		Type:     userChangeTypes[change.Operation],
+		User: &proto_user.UserResponse{
+			Id:        change.UserID.String(),
+			Email:     change.Email,
+			Username:  change.Username,
+			CreatedAt: change.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt: change.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		},
+		ChangedAt: change.ChangedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
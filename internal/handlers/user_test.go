@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/vinofsteel/grpc-management/internal/changefeed"
+	"github.com/vinofsteel/grpc-management/internal/database"
+	"github.com/vinofsteel/grpc-management/internal/database/memory"
+	"github.com/vinofsteel/grpc-management/internal/handlers/proto_admin"
+	"github.com/vinofsteel/grpc-management/internal/handlers/proto_user"
+	"github.com/vinofsteel/grpc-management/internal/password"
+	"github.com/vinofsteel/grpc-management/internal/validation"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateUser(t *testing.T) {
+	createTests := []struct {
+		name     string
+		request  *proto_user.CreateUserRequest
+		wantCode codes.Code
+	}{
+		{
+			name:     "success case: Testing a new user is created",
+			request:  &proto_user.CreateUserRequest{Email: "new@example.com", Username: "newuser", Password: "Password1!"},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "failure case: Testing a taken email is rejected",
+			request:  &proto_user.CreateUserRequest{Email: "existing@example.com", Username: "otheruser", Password: "Password1!"},
+			wantCode: codes.AlreadyExists,
+		},
+		{
+			name:     "failure case: Testing a taken username is rejected",
+			request:  &proto_user.CreateUserRequest{Email: "other@example.com", Username: "existing", Password: "Password1!"},
+			wantCode: codes.AlreadyExists,
+		},
+		{
+			name:     "failure case: Testing a look-alike of a taken username is rejected",
+			request:  &proto_user.CreateUserRequest{Email: "other@example.com", Username: "\u0435xisting", Password: "Password1!"},
+			wantCode: codes.AlreadyExists,
+		},
+	}
+
+	for _, tt := range createTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Logf("Running %s", tt.name)
+
+			h, _ := newTestHandlers(t)
+			ctx := context.Background()
+
+			user, err := h.CreateUser(ctx, tt.request)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode != codes.OK {
+				return
+			}
+
+			stored, err := h.Queries.ListUserByEmail(ctx, database.ListUserByEmailParams{Email: tt.request.Email})
+			assert.NoError(t, err)
+			assert.Equal(t, user.Id, stored.ID.String())
+			assert.NotEqual(t, "Password1!", stored.Password, "the password is stored hashed")
+		})
+	}
+}
+
+func TestListUserByID(t *testing.T) {
+	h, existing := newTestHandlers(t)
+	ctx := context.Background()
+
+	t.Logf("Running success case: Testing an existing user is returned")
+	user, err := h.ListUserByID(ctx, &proto_user.ListUserByIDRequest{Id: existing.ID.String()})
+	assert.NoError(t, err)
+	assert.Equal(t, existing.Username, user.Username)
+
+	t.Logf("Running failure case: Testing an invalid ID is rejected")
+	_, err = h.ListUserByID(ctx, &proto_user.ListUserByIDRequest{Id: "not-a-uuid"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	t.Logf("Running failure case: Testing a deleted user isn't found")
+	assert.NoError(t, h.Queries.DeleteUser(ctx, database.DeleteUserParams{ID: existing.ID}))
+	_, err = h.ListUserByID(ctx, &proto_user.ListUserByIDRequest{Id: existing.ID.String()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// fakeWatchStream hands the changes sent over to the test, closing watching once the headers are sent
+type fakeWatchStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	sent     chan *proto_user.UserChange
+	watching chan struct{}
+}
+
+func (s *fakeWatchStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeWatchStream) SendHeader(metadata.MD) error {
+	close(s.watching)
+	return nil
+}
+
+func (s *fakeWatchStream) Send(change *proto_user.UserChange) error {
+	s.sent <- change
+	return nil
+}
+
+func TestWatchUsers(t *testing.T) {
+	h, existing := newTestHandlers(t)
+	ctx := context.Background()
+
+	created, err := h.CreateUser(ctx, &proto_user.CreateUserRequest{Email: "new@example.com", Username: "newuser", Password: "Password1!"})
+	assert.NoError(t, err)
+
+	t.Logf("Running success case: Testing the changes after the resume token are sent, then the live ones")
+	sent, errs := watchUsers(t, h, &proto_user.WatchUsersRequest{ResumeToken: 1})
+	change := receiveChange(t, sent)
+	assert.Equal(t, int64(2), change.Sequence)
+	assert.Equal(t, proto_user.UserChangeType_USER_CHANGE_TYPE_CREATED, change.Type)
+	assert.Equal(t, created.Id, change.User.Id)
+
+	_, err = h.UpdateUsername(ctx, &proto_admin.UpdateUsernameRequest{Id: existing.ID.String(), Username: "renamed"})
+	assert.NoError(t, err)
+	change = receiveChange(t, sent)
+	assert.Equal(t, int64(3), change.Sequence)
+	assert.Equal(t, proto_user.UserChangeType_USER_CHANGE_TYPE_UPDATED, change.Type)
+	assert.Equal(t, "renamed", change.User.Username)
+
+	t.Logf("Running success case: Testing start_at_now skips the retained changes")
+	sent, _ = watchUsers(t, h, &proto_user.WatchUsersRequest{StartAtNow: true})
+	assert.NoError(t, h.Queries.DeleteUser(ctx, database.DeleteUserParams{ID: existing.ID}))
+	change = receiveChange(t, sent)
+	assert.Equal(t, int64(4), change.Sequence)
+	assert.Equal(t, proto_user.UserChangeType_USER_CHANGE_TYPE_DELETED, change.Type)
+
+	t.Logf("Running failure case: Testing a resume token ahead of the log is rejected")
+	_, errs = watchUsers(t, h, &proto_user.WatchUsersRequest{ResumeToken: 10})
+	assert.Equal(t, codes.InvalidArgument, status.Code(<-errs))
+
+	t.Logf("Running failure case: Testing a resume token older than the retained log is rejected")
+	_, err = h.Queries.DeleteUserChanges(ctx, database.DeleteUserChangesParams{ChangedBefore: time.Now().Add(time.Hour)})
+	assert.NoError(t, err)
+	_, errs = watchUsers(t, h, &proto_user.WatchUsersRequest{ResumeToken: 1})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(<-errs))
+}
+
+// Utilities
+// watchUsers calls WatchUsers until the test ends, returning once it's watching (or failed) with the changes it sends
+// and the error it returns
+func watchUsers(t *testing.T, h *Handlers, req *proto_user.WatchUsersRequest) (<-chan *proto_user.UserChange, <-chan error) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	stream := &fakeWatchStream{ctx: ctx, sent: make(chan *proto_user.UserChange, 10), watching: make(chan struct{})}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- h.WatchUsers(req, stream)
+	}()
+
+	// Failed calls return before watching
+	select {
+	case <-stream.watching:
+	case err := <-errs:
+		errs <- err
+	}
+
+	return stream.sent, errs
+}
+
+func receiveChange(t *testing.T, sent <-chan *proto_user.UserChange) *proto_user.UserChange {
+	t.Helper()
+
+	select {
+	case change := <-sent:
+		return change
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change was sent")
+		return nil
+	}
+}
+
+// newTestHandlers returns handlers backed by the in-memory queries and their change feed, with a user named "existing" already created
+func newTestHandlers(t *testing.T) (*Handlers, *database.User) {
+	t.Helper()
+	ctx := context.Background()
+
+	usernamePolicy, err := validation.NewUsernamePolicy(ctx, validation.UsernamePolicyConfig{})
+	if err != nil {
+		t.Fatalf("error creating username policy: %v", err)
+	}
+
+	queries := memory.NewMemoryQueries()
+	changeFeed := changefeed.NewFeed(queries, nil, changefeed.Config{PollInterval: 10 * time.Millisecond, Retention: time.Hour})
+	feedCtx, cancel := context.WithCancel(ctx)
+	t.Cleanup(cancel)
+	go changeFeed.Run(feedCtx)
+
+	h := New(Config{
+		Queries:        queries,
+		UsernamePolicy: usernamePolicy,
+		Hasher:         password.NewBcryptHasher(bcrypt.MinCost),
+		ChangeFeed:     changeFeed,
+	})
+
+	created, err := h.CreateUser(ctx, &proto_user.CreateUserRequest{Email: "existing@example.com", Username: "existing", Password: "Password1!"})
+	if err != nil {
+		t.Fatalf("error creating existing user: %v", err)
+	}
+
+	existing, err := h.Queries.ListUserById(ctx, database.ListUserByIdParams{ID: uuid.MustParse(created.Id)})
+	if err != nil {
+		t.Fatalf("error listing existing user: %v", err)
+	}
+
+	return h, existing
+}
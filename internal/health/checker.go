@@ -0,0 +1,105 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/vinofsteel/grpc-management/pkg"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type Config struct {
+	// Interval between database checks
+	Interval time.Duration
+	// Timeout for a single database check
+	Timeout time.Duration
+	// DatabaseServices are reported as NOT_SERVING while the database is unreachable. The overall
+	// server status ("") always follows the database, since nothing useful can be served without it.
+	DatabaseServices []string
+	// Services don't depend on the database and stay SERVING until shutdown
+	Services []string
+}
+
+// Checker drives the grpc.health.v1.Health service from the state of the database
+type Checker struct {
+	config   Config
+	provider pkg.DBProvider
+	server   *grpchealth.Server
+	serving  bool
+}
+
+func NewChecker(provider pkg.DBProvider, config Config) *Checker {
+	server := grpchealth.NewServer()
+
+	// Nothing is serving until the first database check succeeds
+	server.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	for _, service := range config.DatabaseServices {
+		server.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	for _, service := range config.Services {
+		server.SetServingStatus(service, healthpb.HealthCheckResponse_SERVING)
+	}
+
+	return &Checker{
+		config:   config,
+		provider: provider,
+		server:   server,
+	}
+}
+
+// Server returns the health service to register in the gRPC server
+func (c *Checker) Server() healthpb.HealthServer {
+	return c.server
+}
+
+// Run checks the database every interval until ctx is done
+func (c *Checker) Run(ctx context.Context) {
+	c.check(ctx)
+
+	ticker := time.NewTicker(c.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.check(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Shutdown reports every service as NOT_SERVING and ignores later checks, so load balancers
+// stop sending new requests while in-flight ones are drained by the HTTP servers' Shutdown
+func (c *Checker) Shutdown(ctx context.Context) {
+	slog.InfoContext(ctx, "Setting health status to NOT_SERVING for shutdown")
+	c.server.Shutdown()
+}
+
+func (c *Checker) check(ctx context.Context) {
+	checkCtx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	_, err := c.provider.GetConnection(checkCtx)
+	serving := err == nil
+
+	if serving == c.serving {
+		return
+	}
+	c.serving = serving
+
+	status := healthpb.HealthCheckResponse_SERVING
+	if !serving {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+		slog.ErrorContext(ctx, "Database is unreachable, reporting NOT_SERVING", "error", err)
+	} else {
+		slog.InfoContext(ctx, "Database is reachable, reporting SERVING")
+	}
+
+	c.server.SetServingStatus("", status)
+	for _, service := range c.config.DatabaseServices {
+		c.server.SetServingStatus(service, status)
+	}
+}
@@ -0,0 +1,97 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type fakeDBProvider struct {
+	err error
+}
+
+func (f *fakeDBProvider) GetConnection(ctx context.Context) (*sqlx.DB, error) {
+	return nil, f.err
+}
+
+func (f *fakeDBProvider) Close() error {
+	return nil
+}
+
+func TestChecker(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeDBProvider{err: errors.New("connection refused")}
+	checker := NewChecker(provider, Config{
+		Interval:         time.Second,
+		Timeout:          time.Second,
+		DatabaseServices: []string{"proto_user.UserService"},
+		Services:         []string{"proto_admin.AdminService"},
+	})
+
+	statusOf := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		response, err := checker.Server().Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		assert.NoError(t, err)
+		return response.Status
+	}
+
+	checkerTests := []struct {
+		name        string
+		databaseErr error
+		shutdown    bool
+		want        map[string]healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{
+			name:        "failure case: Testing services depending on an unreachable database are NOT_SERVING",
+			databaseErr: errors.New("connection refused"),
+			want: map[string]healthpb.HealthCheckResponse_ServingStatus{
+				"":                         healthpb.HealthCheckResponse_NOT_SERVING,
+				"proto_user.UserService":   healthpb.HealthCheckResponse_NOT_SERVING,
+				"proto_admin.AdminService": healthpb.HealthCheckResponse_SERVING,
+			},
+		},
+		{
+			name: "success case: Testing every service is SERVING once the database is reachable",
+			want: map[string]healthpb.HealthCheckResponse_ServingStatus{
+				"":                         healthpb.HealthCheckResponse_SERVING,
+				"proto_user.UserService":   healthpb.HealthCheckResponse_SERVING,
+				"proto_admin.AdminService": healthpb.HealthCheckResponse_SERVING,
+			},
+		},
+		{
+			name:        "failure case: Testing services flip back to NOT_SERVING when the database goes away",
+			databaseErr: errors.New("connection reset"),
+			want: map[string]healthpb.HealthCheckResponse_ServingStatus{
+				"":                         healthpb.HealthCheckResponse_NOT_SERVING,
+				"proto_user.UserService":   healthpb.HealthCheckResponse_NOT_SERVING,
+				"proto_admin.AdminService": healthpb.HealthCheckResponse_SERVING,
+			},
+		},
+		{
+			name:     "failure case: Testing every service is NOT_SERVING after shutdown, even with a reachable database",
+			shutdown: true,
+			want: map[string]healthpb.HealthCheckResponse_ServingStatus{
+				"":                         healthpb.HealthCheckResponse_NOT_SERVING,
+				"proto_user.UserService":   healthpb.HealthCheckResponse_NOT_SERVING,
+				"proto_admin.AdminService": healthpb.HealthCheckResponse_NOT_SERVING,
+			},
+		},
+	}
+
+	for _, testCase := range checkerTests {
+		t.Logf("Running Checker %s\n", testCase.name)
+		provider.err = testCase.databaseErr
+		if testCase.shutdown {
+			checker.Shutdown(ctx)
+		}
+		checker.check(ctx)
+
+		for service, want := range testCase.want {
+			assert.Equal(t, want, statusOf(service), "Unexpected status for service %q in testCase: %v", service, testCase.name)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package interceptors
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/vinofsteel/grpc-management/internal/transport"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryAccessLog writes one log line per call with its method, status code, duration and peer
+func UnaryAccessLog() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logAccess(ctx, info.FullMethod, err, time.Since(start))
+
+		return resp, err
+	}
+}
+
+// StreamAccessLog is the streaming counterpart of UnaryAccessLog, logging once the stream ends
+func StreamAccessLog() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logAccess(ss.Context(), info.FullMethod, err, time.Since(start))
+
+		return err
+	}
+}
+
+// Utilities
+func logAccess(ctx context.Context, method string, err error, duration time.Duration) {
+	code := status.Code(err)
+
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("duration", duration),
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		attrs = append(attrs, slog.String("peer", p.Addr.String()))
+	}
+	if principal, ok := transport.PrincipalFromContext(ctx); ok {
+		attrs = append(attrs, slog.String("principal", principal.Name))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+
+	slog.LogAttrs(ctx, accessLogLevel(code), "gRPC call", attrs...)
+}
+
+// accessLogLevel reports server-side failures as errors and client mistakes (invalid arguments, missing users...) as warnings
+func accessLogLevel(code codes.Code) slog.Level {
+	switch code {
+	case codes.OK:
+		return slog.LevelInfo
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable, codes.Unimplemented, codes.DeadlineExceeded:
+		return slog.LevelError
+	default:
+		return slog.LevelWarn
+	}
+}
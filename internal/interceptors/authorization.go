@@ -0,0 +1,60 @@
+package interceptors
+
+import (
+	"context"
+	"slices"
+
+	"github.com/vinofsteel/grpc-management/internal/transport"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type AuthorizationConfig struct {
+	// Services are restricted to Principals, such as the admin service
+	Services []string
+	// Principals are the names of the mTLS principals (see transport.Principal) allowed to call Services
+	Principals []string
+}
+
+// UnaryAuthorization restricts the configured services to an allowlist of mTLS principals. Calls without a verified
+// client certificate fail with Unauthenticated and calls from any other principal with PermissionDenied, so the
+// services are unreachable when mTLS is disabled.
+func UnaryAuthorization(config AuthorizationConfig) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := authorize(ctx, config, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthorization is the streaming counterpart of UnaryAuthorization
+func StreamAuthorization(config AuthorizationConfig) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(ss.Context(), config, info.FullMethod); err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+// Utilities
+func authorize(ctx context.Context, config AuthorizationConfig, fullMethod string) error {
+	if !inServices(fullMethod, config.Services) {
+		return nil
+	}
+
+	principal, ok := transport.PrincipalFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "a verified client certificate is required")
+	}
+
+	if !slices.Contains(config.Principals, principal.Name) {
+		return status.Errorf(codes.PermissionDenied, "principal %q is not allowed to call %s", principal.Name, fullMethod)
+	}
+
+	return nil
+}
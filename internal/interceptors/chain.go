@@ -0,0 +1,99 @@
+package interceptors
+
+import (
+	"context"
+
+	"github.com/vinofsteel/grpc-management/internal/metrics"
+	"github.com/vinofsteel/grpc-management/internal/validation"
+	"google.golang.org/grpc"
+)
+
+type Config struct {
+	// Tracing starts a span for every call, continuing the W3C trace context sent by the client
+	Tracing bool
+	// RequestID reads the x-request-id metadata (or generates an ID), echoing it back and adding it to the call's logs
+	RequestID bool
+	// Session reads the x-session-id and x-consistency metadata, which route the call's reads to the primary or replicas
+	Session bool
+	// Metrics records per method and code histograms of every call, skipped when nil
+	Metrics *metrics.Metrics
+	// AccessLog logs one line per call with its method, status code, duration and peer
+	AccessLog bool
+	// Authorization restricts services to an allowlist of mTLS principals, skipped when nil
+	Authorization *AuthorizationConfig
+	// RateLimit rejects calls over the limit of their method, skipped when nil
+	RateLimit *RateLimitConfig
+	// ConcurrencyLimit sheds calls once the server is saturated, skipped when nil
+	ConcurrencyLimit *ConcurrencyLimitConfig
+	// Recovery turns panics in handlers into Internal errors instead of crashing the process
+	Recovery bool
+	// Validator validates requests against the rules declared in the proto schema, skipped when nil
+	Validator validation.ValidationProvider
+	// Tenants decides whose tenant policy overrides the validation applies
+	Tenants TenantConfig
+}
+
+// Chain returns the server options installing the enabled interceptors. They run in the order tracing, request ID,
+// session, metrics, access log, authorization, rate limit, concurrency limit, recovery and validation, so spans, metrics
+// and logs cover every call (including rejected ones and recovered panics), unauthorized calls never consume a rate
+// limit and rate limited calls never take a concurrency slot.
+func Chain(config Config) []grpc.ServerOption {
+	var unary []grpc.UnaryServerInterceptor
+	var stream []grpc.StreamServerInterceptor
+
+	if config.Tracing {
+		unary = append(unary, UnaryTracing())
+		stream = append(stream, StreamTracing())
+	}
+	if config.RequestID {
+		unary = append(unary, UnaryRequestID())
+		stream = append(stream, StreamRequestID())
+	}
+	if config.Session {
+		unary = append(unary, UnarySession())
+		stream = append(stream, StreamSession())
+	}
+	if config.Metrics != nil {
+		unary = append(unary, UnaryMetrics(config.Metrics))
+		stream = append(stream, StreamMetrics(config.Metrics))
+	}
+	if config.AccessLog {
+		unary = append(unary, UnaryAccessLog())
+		stream = append(stream, StreamAccessLog())
+	}
+	if config.Authorization != nil {
+		unary = append(unary, UnaryAuthorization(*config.Authorization))
+		stream = append(stream, StreamAuthorization(*config.Authorization))
+	}
+	if config.RateLimit != nil {
+		unary = append(unary, UnaryRateLimit(*config.RateLimit))
+		stream = append(stream, StreamRateLimit(*config.RateLimit))
+	}
+	if config.ConcurrencyLimit != nil {
+		unary = append(unary, UnaryConcurrencyLimit(*config.ConcurrencyLimit))
+		stream = append(stream, StreamConcurrencyLimit(*config.ConcurrencyLimit))
+	}
+	if config.Recovery {
+		unary = append(unary, UnaryRecovery())
+		stream = append(stream, StreamRecovery())
+	}
+	if config.Validator != nil {
+		unary = append(unary, UnaryValidation(config.Validator, config.Tenants))
+		stream = append(stream, StreamValidation(config.Validator, config.Tenants))
+	}
+
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+}
+
+// contextServerStream overrides the context of a stream, for interceptors that add values to it
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
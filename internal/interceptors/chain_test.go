@@ -0,0 +1,137 @@
+package interceptors
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/vinofsteel/grpc-management/internal/transport"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryRequestID(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/proto_user.UserService/ListUsers"}
+
+	requestIDTests := []struct {
+		name      string
+		requestID string
+		wantKept  bool
+	}{
+		{
+			name:      "success case: Testing a request ID sent by the client is kept",
+			requestID: "3f2a9c1e-web-42",
+			wantKept:  true,
+		},
+		{
+			name: "success case: Testing a request ID is generated when the client sends none",
+		},
+		{
+			name:      "failure case: Testing a request ID with spaces is replaced",
+			requestID: "not a valid id",
+		},
+		{
+			name:      "failure case: Testing an overly long request ID is replaced",
+			requestID: strings.Repeat("a", maxRequestIDLength+1),
+		},
+	}
+
+	for _, tt := range requestIDTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Logf("Running %s", tt.name)
+
+			ctx := context.Background()
+			if tt.requestID != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(RequestIDHeader, tt.requestID))
+			}
+
+			var requestID string
+			_, err := UnaryRequestID()(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+				requestID = RequestIDFromContext(ctx)
+				return nil, nil
+			})
+			assert.NoError(t, err)
+
+			if tt.wantKept {
+				assert.Equal(t, tt.requestID, requestID)
+			} else {
+				_, parseErr := uuid.Parse(requestID)
+				assert.NoError(t, parseErr)
+			}
+		})
+	}
+}
+
+func TestUnaryRecovery(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/proto_user.UserService/CreateUser"}
+
+	t.Logf("Running failure case: Testing a panicking handler returns Internal instead of crashing")
+	_, err := UnaryRecovery()(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		panic("nil map assignment")
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	t.Logf("Running success case: Testing errors from a handler that doesn't panic are kept")
+	_, err = UnaryRecovery()(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return nil, status.Errorf(codes.NotFound, "user not found")
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestUnaryAuthorization(t *testing.T) {
+	config := AuthorizationConfig{
+		Services:   []string{"proto_admin.AdminService"},
+		Principals: []string{"spiffe://example.org/admin"},
+	}
+
+	authorizationTests := []struct {
+		name       string
+		fullMethod string
+		principal  string
+		want       codes.Code
+	}{
+		{
+			name:       "success case: Testing an allowed principal can call a restricted service",
+			fullMethod: "/proto_admin.AdminService/CheckEmailPolicy",
+			principal:  "spiffe://example.org/admin",
+			want:       codes.OK,
+		},
+		{
+			name:       "success case: Testing services that aren't restricted need no principal",
+			fullMethod: "/proto_user.UserService/ListUsers",
+			want:       codes.OK,
+		},
+		{
+			name:       "failure case: Testing a call without a client certificate is unauthenticated",
+			fullMethod: "/proto_admin.AdminService/CheckEmailPolicy",
+			want:       codes.Unauthenticated,
+		},
+		{
+			name:       "failure case: Testing a principal outside the allowlist is denied",
+			fullMethod: "/proto_admin.AdminService/CreateWebhookSubscription",
+			principal:  "spiffe://example.org/gateway",
+			want:       codes.PermissionDenied,
+		},
+	}
+
+	for _, tt := range authorizationTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Logf("Running %s", tt.name)
+
+			ctx := context.Background()
+			if tt.principal != "" {
+				ctx = transport.ContextWithPrincipal(ctx, transport.Principal{Name: tt.principal})
+			}
+
+			info := &grpc.UnaryServerInfo{FullMethod: tt.fullMethod}
+			_, err := UnaryAuthorization(config)(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+				return nil, nil
+			})
+			assert.Equal(t, tt.want, status.Code(err))
+		})
+	}
+}
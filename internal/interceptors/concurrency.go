@@ -0,0 +1,83 @@
+package interceptors
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/vinofsteel/grpc-management/internal/concurrency"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type ConcurrencyLimitConfig struct {
+	Limiter concurrency.Limiter
+	// ExemptServices bypass the limiter, so health checks and admin calls still get through while the server is saturated
+	ExemptServices []string
+	// ExemptMethods (full method names) bypass the limiter too, e.g. long lived streams that would hold a slot for good
+	ExemptMethods []string
+}
+
+// UnaryConcurrencyLimit rejects calls with Unavailable once the limiter is saturated, so clients can retry elsewhere
+// instead of waiting in line. The latency of every call admitted is fed back to the limiter.
+func UnaryConcurrencyLimit(config ConcurrencyLimitConfig) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if isConcurrencyExempt(info.FullMethod, config) {
+			return handler(ctx, req)
+		}
+
+		release, ok := config.Limiter.Acquire()
+		if !ok {
+			return nil, status.Errorf(codes.Unavailable, "server overloaded, retry later")
+		}
+
+		resp, err := handler(ctx, req)
+		release(isDroppedCall(ctx, err))
+
+		return resp, err
+	}
+}
+
+// StreamConcurrencyLimit is the streaming counterpart of UnaryConcurrencyLimit. Streams hold their slot while
+// they're open, but their duration isn't latency, so it's never sampled.
+func StreamConcurrencyLimit(config ConcurrencyLimitConfig) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if isConcurrencyExempt(info.FullMethod, config) {
+			return handler(srv, ss)
+		}
+
+		release, ok := config.Limiter.Acquire()
+		if !ok {
+			return status.Errorf(codes.Unavailable, "server overloaded, retry later")
+		}
+		defer release(true)
+
+		return handler(srv, ss)
+	}
+}
+
+// Utilities
+func isConcurrencyExempt(fullMethod string, config ConcurrencyLimitConfig) bool {
+	return slices.Contains(config.ExemptMethods, fullMethod) || inServices(fullMethod, config.ExemptServices)
+}
+
+func inServices(fullMethod string, services []string) bool {
+	for _, service := range services {
+		if strings.HasPrefix(fullMethod, "/"+service+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isDroppedCall tells calls cut short by the client apart, their latency says nothing about the server
+func isDroppedCall(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return true
+	}
+
+	return status.Code(err) == codes.Canceled
+}
@@ -0,0 +1,32 @@
+package interceptors
+
+import (
+	"context"
+	"time"
+
+	"github.com/vinofsteel/grpc-management/internal/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryMetrics records the duration of every call in the gRPC server histograms, labeled by method and status code
+func UnaryMetrics(m *metrics.Metrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.ObserveRPC(info.FullMethod, status.Code(err), time.Since(start))
+
+		return resp, err
+	}
+}
+
+// StreamMetrics is the streaming counterpart of UnaryMetrics, recording the whole lifetime of the stream
+func StreamMetrics(m *metrics.Metrics) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		m.ObserveRPC(info.FullMethod, status.Code(err), time.Since(start))
+
+		return err
+	}
+}
@@ -0,0 +1,139 @@
+package interceptors
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/vinofsteel/grpc-management/internal/ratelimit"
+	"github.com/vinofsteel/grpc-management/internal/transport"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// APIKeyHeader is the metadata key clients identify themselves with for rate limiting
+const APIKeyHeader = "x-api-key"
+
+type RateLimitConfig struct {
+	Limiter ratelimit.Limiter
+	Policy  ratelimit.Policy
+	// ExemptServices are never limited, such as the health service probed by load balancers
+	ExemptServices []string
+	// APIKeyDigests are the hex encoded SHA-256 digests of the API keys clients may identify themselves with. Any
+	// other x-api-key is ignored, so clients can't get fresh buckets by making keys up.
+	APIKeyDigests []string
+	// TrustedPrincipals are gateways calling on behalf of their own clients, which are limited by the address the
+	// gateway forwards in x-forwarded-for instead of sharing the gateway's buckets
+	TrustedPrincipals []string
+}
+
+// UnaryRateLimit rejects calls over the limit of their method with ResourceExhausted and RetryInfo details. Each client
+// has its own buckets, identified by its mTLS principal, a known x-api-key or else its IP address.
+func UnaryRateLimit(config RateLimitConfig) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := checkRateLimit(ctx, config, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamRateLimit is the streaming counterpart of UnaryRateLimit, taking a single token when the stream is opened
+func StreamRateLimit(config RateLimitConfig) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := checkRateLimit(ss.Context(), config, info.FullMethod); err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+// Utilities
+func checkRateLimit(ctx context.Context, config RateLimitConfig, fullMethod string) error {
+	if inServices(fullMethod, config.ExemptServices) {
+		return nil
+	}
+
+	limit, ok := config.Policy.For(fullMethod)
+	if !ok {
+		return nil
+	}
+
+	decision, err := config.Limiter.Allow(ctx, fullMethod+"|"+rateLimitKey(ctx, config), limit)
+	if err != nil {
+		// Failing open, an unavailable limiter shouldn't take the whole API down with it
+		slog.ErrorContext(ctx, "Error checking rate limit, allowing call", "method", fullMethod, "error", err)
+		return nil
+	}
+
+	if decision.Allowed {
+		return nil
+	}
+
+	st := status.New(codes.ResourceExhausted, "rate limit exceeded, retry later")
+	withDetails, detailsErr := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(decision.RetryAfter)})
+	if detailsErr != nil {
+		return st.Err()
+	}
+
+	return withDetails.Err()
+}
+
+// rateLimitKey identifies the client of the call, preferring the mTLS principal (unless it's a trusted gateway), then a
+// known API key and then the IP address
+func rateLimitKey(ctx context.Context, config RateLimitConfig) string {
+	principal, hasPrincipal := transport.PrincipalFromContext(ctx)
+	trusted := hasPrincipal && slices.Contains(config.TrustedPrincipals, principal.Name)
+	if hasPrincipal && !trusted {
+		return "principal:" + principal.Name
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if apiKey := md.Get(APIKeyHeader); len(apiKey) > 0 && apiKey[0] != "" {
+		// Hashed, so keys don't end up in memory dumps or in the Postgres buckets table
+		sum := sha256.Sum256([]byte(apiKey[0]))
+		digest := hex.EncodeToString(sum[:])
+		if slices.ContainsFunc(config.APIKeyDigests, func(known string) bool { return strings.EqualFold(known, digest) }) {
+			return "api_key:" + digest[:32]
+		}
+	}
+
+	return "ip:" + clientIP(ctx, md, trusted)
+}
+
+// clientIP returns the peer's IP, or the address of the client of a gateway. Gateways are the trusted principals and,
+// without mTLS, calls from the loopback interface (the REST gateway of this same process), which append the address
+// of their own client to x-forwarded-for.
+func clientIP(ctx context.Context, md metadata.MD, trusted bool) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+
+	if ip := net.ParseIP(host); trusted || (ip != nil && ip.IsLoopback()) {
+		if forwardedFor := md.Get("x-forwarded-for"); len(forwardedFor) > 0 {
+			addresses := strings.Split(forwardedFor[len(forwardedFor)-1], ",")
+			if forwarded := strings.TrimSpace(addresses[len(addresses)-1]); forwarded != "" {
+				return forwarded
+			}
+		}
+	}
+
+	return host
+}
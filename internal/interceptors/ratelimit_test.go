@@ -0,0 +1,90 @@
+package interceptors
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vinofsteel/grpc-management/internal/ratelimit"
+	"github.com/vinofsteel/grpc-management/internal/transport"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryRateLimit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interceptor := UnaryRateLimit(RateLimitConfig{
+		Limiter: ratelimit.NewMemoryLimiter(ctx, time.Hour),
+		Policy: ratelimit.NewPolicy(ratelimit.Limits{
+			Methods: map[string]ratelimit.Limit{
+				"/proto_user.UserService/CreateUser": {Rate: 0.1, Burst: 1},
+				"/grpc.health.v1.Health/Check":       {Rate: 0.1, Burst: 1},
+			},
+		}),
+		ExemptServices: []string{"grpc.health.v1.Health"},
+		// SHA-256 of "key-1"
+		APIKeyDigests:     []string{"be2974546978e3739e6d6da85c4be9f334ce32df2b9fd4b6ff1b55c0d57e9d44"},
+		TrustedPrincipals: []string{"spiffe://example.org/api-gateway"},
+	})
+	handler := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+
+	callAs := func(principal, address string, md metadata.MD, method string) error {
+		callCtx := peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(address), Port: 50000}})
+		callCtx = metadata.NewIncomingContext(callCtx, md)
+		if principal != "" {
+			callCtx = transport.ContextWithPrincipal(callCtx, transport.Principal{Name: principal})
+		}
+
+		_, err := interceptor(callCtx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+	callFrom := func(address string, md metadata.MD, method string) error {
+		return callAs("", address, md, method)
+	}
+
+	t.Logf("Running success case: Testing the first call of a client is allowed")
+	assert.NoError(t, callFrom("203.0.113.7", nil, "/proto_user.UserService/CreateUser"))
+
+	t.Logf("Running failure case: Testing a call over the limit gets ResourceExhausted with RetryInfo")
+	err := callFrom("203.0.113.7", nil, "/proto_user.UserService/CreateUser")
+	st := status.Convert(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	if assert.Len(t, st.Details(), 1) {
+		retryInfo, ok := st.Details()[0].(*errdetails.RetryInfo)
+		assert.True(t, ok)
+		assert.InDelta(t, 10*time.Second, retryInfo.RetryDelay.AsDuration(), float64(time.Second))
+	}
+
+	t.Logf("Running success case: Testing API keys get buckets of their own, even from the same IP")
+	assert.NoError(t, callFrom("203.0.113.7", metadata.Pairs(APIKeyHeader, "key-1"), "/proto_user.UserService/CreateUser"))
+
+	t.Logf("Running failure case: Testing unknown API keys are limited by IP")
+	err = callFrom("203.0.113.7", metadata.Pairs(APIKeyHeader, "made-up-key"), "/proto_user.UserService/CreateUser")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	t.Logf("Running success case: Testing gateway calls are limited by the forwarded client IP")
+	assert.NoError(t, callFrom("127.0.0.1", metadata.Pairs("x-forwarded-for", "198.51.100.1"), "/proto_user.UserService/CreateUser"))
+	assert.NoError(t, callFrom("127.0.0.1", metadata.Pairs("x-forwarded-for", "198.51.100.2"), "/proto_user.UserService/CreateUser"))
+
+	t.Logf("Running success case: Testing calls of trusted principals are limited by the forwarded client IP")
+	gateway := "spiffe://example.org/api-gateway"
+	assert.NoError(t, callAs(gateway, "10.0.0.9", metadata.Pairs("x-forwarded-for", "198.51.100.3"), "/proto_user.UserService/CreateUser"))
+	assert.NoError(t, callAs(gateway, "10.0.0.9", metadata.Pairs("x-forwarded-for", "198.51.100.4"), "/proto_user.UserService/CreateUser"))
+
+	t.Logf("Running failure case: Testing other principals can't pick their bucket with x-forwarded-for")
+	assert.NoError(t, callAs("spiffe://example.org/app", "10.0.0.9", metadata.Pairs("x-forwarded-for", "198.51.100.5"), "/proto_user.UserService/CreateUser"))
+	err = callAs("spiffe://example.org/app", "10.0.0.9", metadata.Pairs("x-forwarded-for", "198.51.100.6"), "/proto_user.UserService/CreateUser")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	t.Logf("Running success case: Testing exempt services are never limited")
+	assert.NoError(t, callFrom("203.0.113.7", nil, "/grpc.health.v1.Health/Check"))
+	assert.NoError(t, callFrom("203.0.113.7", nil, "/grpc.health.v1.Health/Check"))
+}
@@ -0,0 +1,44 @@
+package interceptors
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryRecovery turns a panic in the handler into an Internal error, logging the stack trace, so a
+// single bad request can't take the whole process down
+func UnaryRecovery() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = recoveredError(ctx, info.FullMethod, recovered)
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamRecovery is the streaming counterpart of UnaryRecovery
+func StreamRecovery() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = recoveredError(ss.Context(), info.FullMethod, recovered)
+			}
+		}()
+
+		return handler(srv, ss)
+	}
+}
+
+// Utilities
+func recoveredError(ctx context.Context, method string, recovered any) error {
+	slog.ErrorContext(ctx, "Recovered from panic in handler", "method", method, "panic", recovered, "stack", string(debug.Stack()))
+	return status.Errorf(codes.Internal, "internal server error")
+}
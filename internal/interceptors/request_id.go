@@ -0,0 +1,78 @@
+package interceptors
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/vinofsteel/grpc-management/pkg"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDHeader is the metadata key the request ID is read from and echoed back in
+const RequestIDHeader = "x-request-id"
+
+// Longer IDs are replaced, so clients can't inflate every log line of a call
+const maxRequestIDLength = 128
+
+type requestIDContextKey struct{}
+
+// RequestIDFromContext returns the ID of the call being handled, empty outside of the RequestID interceptors
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+// UnaryRequestID takes the request ID from the x-request-id metadata, generating one when it's missing or invalid.
+// The ID is sent back in the response headers and added to every log line written with the call's context.
+func UnaryRequestID() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, requestID := requestIDContext(ctx)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, requestID))
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamRequestID is the streaming counterpart of UnaryRequestID
+func StreamRequestID() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, requestID := requestIDContext(ss.Context())
+		_ = ss.SetHeader(metadata.Pairs(RequestIDHeader, requestID))
+
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// Utilities
+func requestIDContext(ctx context.Context) (context.Context, string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	var requestID string
+	if values := md.Get(RequestIDHeader); len(values) > 0 && isValidRequestID(values[0]) {
+		requestID = values[0]
+	} else {
+		requestID = uuid.NewString()
+	}
+
+	ctx = context.WithValue(ctx, requestIDContextKey{}, requestID)
+	ctx = pkg.ContextWithLogAttrs(ctx, slog.String("request_id", requestID))
+
+	return ctx, requestID
+}
+
+// isValidRequestID accepts up to maxRequestIDLength printable ASCII characters, without spaces
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] <= ' ' || requestID[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
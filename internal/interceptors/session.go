@@ -0,0 +1,46 @@
+package interceptors
+
+import (
+	"context"
+
+	"github.com/vinofsteel/grpc-management/internal/database"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// SessionHeader is the metadata key identifying the client session, whose reads stick to the primary after it writes
+const SessionHeader = "x-session-id"
+
+// ConsistencyHeader set to "strong" sends every read of the call to the primary
+const ConsistencyHeader = "x-consistency"
+
+// UnarySession adds the client session (and the strong consistency override) from the call's metadata to its
+// context, where the repository routes reads between the primary and the replicas
+func UnarySession() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(sessionContext(ctx), req)
+	}
+}
+
+// StreamSession is the streaming counterpart of UnarySession
+func StreamSession() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: sessionContext(ss.Context())})
+	}
+}
+
+// Utilities
+func sessionContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	// Session IDs are kept in memory until their window passes, so they're held to the rules of request IDs
+	if values := md.Get(SessionHeader); len(values) > 0 && isValidRequestID(values[0]) {
+		ctx = database.WithSession(ctx, values[0])
+	}
+
+	if values := md.Get(ConsistencyHeader); len(values) > 0 && values[0] == "strong" {
+		ctx = database.WithReadPrimary(ctx)
+	}
+
+	return ctx
+}
@@ -0,0 +1,81 @@
+package interceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/proto_user.UserService/CreateUser"}
+
+	tracingTests := []struct {
+		name        string
+		traceparent string
+		handlerErr  error
+		wantTraceID string
+		wantStatus  otelcodes.Code
+	}{
+		{
+			name:        "success case: Testing the trace sent in traceparent is continued",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantStatus:  otelcodes.Unset,
+		},
+		{
+			name:       "success case: Testing client errors don't mark the span as failed",
+			handlerErr: status.Errorf(codes.AlreadyExists, "user already exists"),
+			wantStatus: otelcodes.Unset,
+		},
+		{
+			name:       "failure case: Testing internal errors mark the span as failed",
+			handlerErr: status.Errorf(codes.Internal, "internal server error"),
+			wantStatus: otelcodes.Error,
+		},
+	}
+
+	for _, tt := range tracingTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Logf("Running %s", tt.name)
+			exporter.Reset()
+
+			ctx := context.Background()
+			if tt.traceparent != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("traceparent", tt.traceparent))
+			}
+
+			var handlerSpan trace.SpanContext
+			_, err := UnaryTracing()(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+				handlerSpan = trace.SpanContextFromContext(ctx)
+				return nil, tt.handlerErr
+			})
+			assert.Equal(t, tt.handlerErr, err)
+
+			spans := exporter.GetSpans()
+			assert.Len(t, spans, 1)
+			assert.Equal(t, "proto_user.UserService/CreateUser", spans[0].Name)
+			assert.Equal(t, tt.wantStatus, spans[0].Status.Code)
+			assert.Equal(t, spans[0].SpanContext.SpanID(), handlerSpan.SpanID())
+
+			if tt.wantTraceID != "" {
+				assert.Equal(t, tt.wantTraceID, spans[0].SpanContext.TraceID().String())
+				assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent.SpanID().String())
+			}
+		})
+	}
+}
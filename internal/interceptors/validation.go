@@ -0,0 +1,202 @@
+package interceptors
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/vinofsteel/grpc-management/internal/handlers/proto_validate"
+	"github.com/vinofsteel/grpc-management/internal/transport"
+	"github.com/vinofsteel/grpc-management/internal/validation"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// fieldRule is a field of a message annotated with (proto_validate.rules)
+type fieldRule struct {
+	descriptor protoreflect.FieldDescriptor
+	rules      string
+}
+
+// Rules only depend on the message descriptor, so they're read once per message type
+var messageRulesCache sync.Map // map[protoreflect.FullName][]fieldRule
+
+// TenantConfig decides whose tenant policy overrides apply to a call, from the caller's mTLS principal only
+type TenantConfig struct {
+	// TrustedPrincipals (e.g. an API gateway authenticating its own clients) pick the tenant with the x-tenant-id
+	// metadata, which is ignored for everyone else
+	TrustedPrincipals []string
+	// Principals maps principals to the tenant they belong to
+	Principals map[string]string
+}
+
+// UnaryValidation validates every request against the rules declared in the proto schema, storing the locale
+// requested through the accept-language header and the caller's tenant in the context
+func UnaryValidation(validator validation.ValidationProvider, tenants TenantConfig) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx = validationContext(ctx, tenants)
+
+		if err := validateMessage(ctx, validator, req, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamValidation is the streaming counterpart of UnaryValidation, validating every message received from the client
+func StreamValidation(validator validation.ValidationProvider, tenants TenantConfig) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &validatingServerStream{
+			ServerStream: ss,
+			ctx:          validationContext(ss.Context(), tenants),
+			validator:    validator,
+			method:       info.FullMethod,
+		})
+	}
+}
+
+type validatingServerStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	validator validation.ValidationProvider
+	method    string
+}
+
+func (s *validatingServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *validatingServerStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	return validateMessage(s.ctx, s.validator, m, s.method)
+}
+
+// Utilities
+func validateMessage(ctx context.Context, validator validation.ValidationProvider, req any, method string) error {
+	message, ok := req.(proto.Message)
+	if !ok {
+		return nil
+	}
+
+	fields := collectFields(message.ProtoReflect(), "")
+	if len(fields) == 0 {
+		return nil
+	}
+
+	if err := validator.ValidateFields(ctx, fields); err != nil {
+		slog.WarnContext(ctx, "Validation failed", "method", method, "errors", err.Errors)
+		return validationStatus(err).Err()
+	}
+
+	return nil
+}
+
+// validationStatus builds an InvalidArgument status carrying the violations as BadRequest details,
+// so clients (and the REST gateway) can tell which field failed without parsing the message
+func validationStatus(err *validation.ValidationError) *status.Status {
+	st := status.New(codes.InvalidArgument, fmt.Sprintf("validation failed: %s", strings.Join(err.Errors, "; ")))
+
+	badRequest := &errdetails.BadRequest{}
+	for _, violation := range err.Violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       violation.Field,
+			Description: violation.Message,
+			Reason:      violation.Tag,
+		})
+	}
+
+	withDetails, detailsErr := st.WithDetails(badRequest)
+	if detailsErr != nil {
+		return st
+	}
+
+	return withDetails
+}
+
+// collectFields pairs every annotated field with its current value, descending into nested messages
+func collectFields(message protoreflect.Message, prefix string) []validation.Field {
+	var fields []validation.Field
+
+	for _, rule := range messageRules(message.Descriptor()) {
+		name := prefix + string(rule.descriptor.Name())
+
+		if rule.descriptor.Kind() == protoreflect.MessageKind && !rule.descriptor.IsList() && !rule.descriptor.IsMap() {
+			if message.Has(rule.descriptor) {
+				fields = append(fields, collectFields(message.Get(rule.descriptor).Message(), name+".")...)
+			}
+			continue
+		}
+
+		if rule.rules == "" || rule.descriptor.IsList() || rule.descriptor.IsMap() {
+			continue
+		}
+
+		fields = append(fields, validation.Field{
+			Name:  name,
+			Value: message.Get(rule.descriptor).Interface(),
+			Rules: rule.rules,
+		})
+	}
+
+	return fields
+}
+
+func messageRules(descriptor protoreflect.MessageDescriptor) []fieldRule {
+	if cached, ok := messageRulesCache.Load(descriptor.FullName()); ok {
+		return cached.([]fieldRule)
+	}
+
+	var rules []fieldRule
+	fieldDescriptors := descriptor.Fields()
+	for i := 0; i < fieldDescriptors.Len(); i++ {
+		fieldDescriptor := fieldDescriptors.Get(i)
+
+		var fieldRules string
+		if options, ok := fieldDescriptor.Options().(*descriptorpb.FieldOptions); ok && options != nil {
+			fieldRules, _ = proto.GetExtension(options, proto_validate.E_Rules).(string)
+		}
+
+		// Nested messages are kept even without rules of their own, since their fields may have some
+		if fieldRules != "" || fieldDescriptor.Kind() == protoreflect.MessageKind {
+			rules = append(rules, fieldRule{descriptor: fieldDescriptor, rules: fieldRules})
+		}
+	}
+
+	messageRulesCache.Store(descriptor.FullName(), rules)
+	return rules
+}
+
+// validationContext stores the locale requested through the accept-language metadata header and the tenant of the
+// caller's principal in ctx. Only trusted principals may act on behalf of another tenant with x-tenant-id.
+func validationContext(ctx context.Context, tenants TenantConfig) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	ctx = validation.ContextWithLocale(ctx, validation.ParseAcceptLanguage(strings.Join(md.Get("accept-language"), ",")))
+
+	principal, ok := transport.PrincipalFromContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	if tenant := md.Get("x-tenant-id"); len(tenant) > 0 && slices.Contains(tenants.TrustedPrincipals, principal.Name) {
+		return validation.ContextWithTenant(ctx, tenant[0])
+	}
+	if tenant, ok := tenants.Principals[principal.Name]; ok {
+		return validation.ContextWithTenant(ctx, tenant)
+	}
+
+	return ctx
+}
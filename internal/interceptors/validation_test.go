@@ -0,0 +1,132 @@
+package interceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vinofsteel/grpc-management/internal/handlers/proto_admin"
+	"github.com/vinofsteel/grpc-management/internal/transport"
+	"github.com/vinofsteel/grpc-management/internal/validation"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestUnaryValidation(t *testing.T) {
+	interceptor := UnaryValidation(validation.NewValidateValidationrovider(context.Background()), TenantConfig{})
+	info := &grpc.UnaryServerInfo{FullMethod: "/proto_admin.AdminService/ListWebhookDeliveries"}
+
+	validationTests := []struct {
+		name           string
+		req            any
+		wantCode       codes.Code
+		wantViolations []*errdetails.BadRequest_FieldViolation
+	}{
+		{
+			name:     "success case: Testing a valid request reaches the handler",
+			req:      &proto_admin.ListWebhookDeliveriesRequest{SubscriptionId: "3f2a9c1e-5b7d-4e8f-9a0b-1c2d3e4f5a6b", Limit: 10},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "success case: Testing a message without rules reaches the handler",
+			req:      &proto_admin.ListWebhookSubscriptionsRequest{},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "failure case: Testing rule violations are InvalidArgument with localized field violations",
+			req:      &proto_admin.ListWebhookDeliveriesRequest{Status: "sent", Limit: 500},
+			wantCode: codes.InvalidArgument,
+			wantViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: "subscription_id", Reason: "required", Description: "o campo 'subscription_id' é obrigatório"},
+				{Field: "status", Reason: "oneof", Description: "o campo 'status' deve ser um dos valores [pending failed delivered dead]"},
+				{Field: "limit", Reason: "max", Description: "o campo 'limit' deve ser no máximo 100"},
+			},
+		},
+	}
+
+	for _, tt := range validationTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Logf("Running %s", tt.name)
+
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("accept-language", "pt-BR"))
+			called := false
+			_, err := interceptor(ctx, tt.req, info, func(ctx context.Context, req any) (any, error) {
+				called = true
+				return nil, nil
+			})
+
+			st := status.Convert(err)
+			assert.Equal(t, tt.wantCode, st.Code())
+			assert.Equal(t, tt.wantCode == codes.OK, called)
+			if tt.wantViolations == nil {
+				return
+			}
+
+			if assert.Len(t, st.Details(), 1) {
+				badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+				if assert.True(t, ok) {
+					assert.Len(t, badRequest.FieldViolations, len(tt.wantViolations))
+					for i, want := range tt.wantViolations {
+						assert.True(t, proto.Equal(want, badRequest.FieldViolations[i]), "violation %d: %v", i, badRequest.FieldViolations[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestValidationContextTenant(t *testing.T) {
+	tenants := TenantConfig{
+		TrustedPrincipals: []string{"spiffe://example.org/api-gateway"},
+		Principals:        map[string]string{"spiffe://example.org/acme-app": "acme"},
+	}
+
+	tenantTests := []struct {
+		name      string
+		principal string
+		tenant    string
+		want      string
+	}{
+		{
+			name:      "success case: Testing the tenant of the caller's principal is used",
+			principal: "spiffe://example.org/acme-app",
+			want:      "acme",
+		},
+		{
+			name:      "success case: Testing a trusted principal picks the tenant with x-tenant-id",
+			principal: "spiffe://example.org/api-gateway",
+			tenant:    "globex",
+			want:      "globex",
+		},
+		{
+			name:      "failure case: Testing x-tenant-id is ignored for principals that aren't trusted",
+			principal: "spiffe://example.org/acme-app",
+			tenant:    "globex",
+			want:      "acme",
+		},
+		{
+			name:   "failure case: Testing x-tenant-id is ignored for anonymous callers",
+			tenant: "globex",
+		},
+	}
+
+	for _, tt := range tenantTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Logf("Running %s", tt.name)
+
+			ctx := context.Background()
+			if tt.tenant != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-tenant-id", tt.tenant))
+			}
+			if tt.principal != "" {
+				ctx = transport.ContextWithPrincipal(ctx, transport.Principal{Name: tt.principal})
+			}
+
+			assert.Equal(t, tt.want, validation.TenantFromContext(validationContext(ctx, tenants)))
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/vinofsteel/grpc-management/internal/password"
+)
+
+type instrumentedHasher struct {
+	hasher  password.Hasher
+	metrics *Metrics
+}
+
+// InstrumentHasher records how long each password hash takes
+func InstrumentHasher(hasher password.Hasher, m *Metrics) password.Hasher {
+	return &instrumentedHasher{hasher: hasher, metrics: m}
+}
+
+func (h *instrumentedHasher) Hash(ctx context.Context, password string) (string, error) {
+	start := time.Now()
+	defer func() { h.metrics.ObservePasswordHash(time.Since(start)) }()
+
+	return h.hasher.Hash(ctx, password)
+}
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc/codes"
+)
+
+// Query outcomes, so slow lookups of missing users can be told apart from failing ones
+const (
+	QueryOutcomeSuccess  = "success"
+	QueryOutcomeNotFound = "not_found"
+	QueryOutcomeError    = "error"
+)
+
+type Config struct {
+	// Address the metrics HTTP server listens on, e.g. ":9090"
+	Address string
+}
+
+// Metrics holds the application's Prometheus collectors, exposed by NewServer
+type Metrics struct {
+	registry             *prometheus.Registry
+	rpcDuration          *prometheus.HistogramVec
+	queryDuration        *prometheus.HistogramVec
+	passwordHashDuration prometheus.Histogram
+}
+
+// New creates a registry with the Go runtime and process collectors plus the application metrics
+func New() *Metrics {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(collectors.WithGoCollectorRuntimeMetrics(collectors.MetricsAll)),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	m := &Metrics{
+		registry: registry,
+		rpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_server_handling_seconds",
+			Help:    "Duration of gRPC calls handled by the server, until the response (or the end of the stream) is sent.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"grpc_service", "grpc_method", "grpc_code"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "repository_query_duration_seconds",
+			Help:    "Duration of repository operations, such as ListUserByEmail.",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "outcome"}),
+		passwordHashDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name: "password_hash_duration_seconds",
+			Help: "Duration of password hashing, which grows with the bcrypt cost.",
+			// bcrypt is slow on purpose, the default cost takes a few hundred milliseconds
+			Buckets: []float64{.05, .1, .2, .3, .5, .75, 1, 1.5, 2, 3, 5},
+		}),
+	}
+	registry.MustRegister(m.rpcDuration, m.queryDuration, m.passwordHashDuration)
+
+	return m
+}
+
+// RegisterDBStats exposes the connection pool statistics (sql.DBStats) of db, labeled with dbName
+func (m *Metrics) RegisterDBStats(db *sql.DB, dbName string) error {
+	if err := m.registry.Register(collectors.NewDBStatsCollector(db, dbName)); err != nil {
+		return fmt.Errorf("error registering database pool metrics: %w", err)
+	}
+
+	return nil
+}
+
+// ObserveRPC records a call to fullMethod ("/package.Service/Method") that finished with code
+func (m *Metrics) ObserveRPC(fullMethod string, code codes.Code, duration time.Duration) {
+	service, method := splitFullMethod(fullMethod)
+	m.rpcDuration.WithLabelValues(service, method, code.String()).Observe(duration.Seconds())
+}
+
+// ObserveQuery records a repository operation, outcome being one of the QueryOutcome constants
+func (m *Metrics) ObserveQuery(operation, outcome string, duration time.Duration) {
+	m.queryDuration.WithLabelValues(operation, outcome).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObservePasswordHash(duration time.Duration) {
+	m.passwordHashDuration.Observe(duration.Seconds())
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// NewServer creates the HTTP server exposing GET /metrics. It's kept apart from the API listener,
+// so it can stay internal to the cluster.
+func NewServer(config Config, m *Metrics) *http.Server {
+	router := http.NewServeMux()
+	router.Handle("GET /metrics", m.Handler())
+
+	return &http.Server{
+		Addr:              config.Address,
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// Utilities
+func splitFullMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if service, method, found := strings.Cut(fullMethod, "/"); found {
+		return service, method
+	}
+
+	return "unknown", fullMethod
+}
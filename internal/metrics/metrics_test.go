@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/vinofsteel/grpc-management/internal/database"
+	"google.golang.org/grpc/codes"
+)
+
+type fakeQueries struct {
+	database.Queries
+	err error
+}
+
+func (f *fakeQueries) ListUserByEmail(ctx context.Context, params database.ListUserByEmailParams) (*database.User, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &database.User{Email: params.Email}, nil
+}
+
+func TestInstrumentQueries(t *testing.T) {
+	queryTests := []struct {
+		name        string
+		err         error
+		wantOutcome string
+	}{
+		{
+			name:        "success case: Testing a found user is recorded as success",
+			wantOutcome: QueryOutcomeSuccess,
+		},
+		{
+			name:        "success case: Testing a missing user is recorded as not_found",
+			err:         sql.ErrNoRows,
+			wantOutcome: QueryOutcomeNotFound,
+		},
+		{
+			name:        "failure case: Testing a database error is recorded as error",
+			err:         errors.New("connection reset by peer"),
+			wantOutcome: QueryOutcomeError,
+		},
+	}
+
+	for _, tt := range queryTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Logf("Running %s", tt.name)
+
+			m := New()
+			queries := InstrumentQueries(&fakeQueries{err: tt.err}, m)
+
+			_, err := queries.ListUserByEmail(context.Background(), database.ListUserByEmailParams{Email: "jane@example.com"})
+			assert.Equal(t, tt.err, err)
+
+			assert.Equal(t, 1, testutil.CollectAndCount(m.queryDuration))
+			// Deleting only succeeds for a series that was recorded
+			assert.True(t, m.queryDuration.DeleteLabelValues("ListUserByEmail", tt.wantOutcome))
+		})
+	}
+}
+
+func TestObserveRPC(t *testing.T) {
+	m := New()
+
+	t.Logf("Running success case: Testing calls are labeled by service, method and code")
+	m.ObserveRPC("/proto_user.UserService/CreateUser", codes.AlreadyExists, 20*time.Millisecond)
+	m.ObserveRPC("/proto_user.UserService/CreateUser", codes.OK, 300*time.Millisecond)
+
+	assert.Equal(t, 2, testutil.CollectAndCount(m.rpcDuration))
+	assert.True(t, m.rpcDuration.DeleteLabelValues("proto_user.UserService", "CreateUser", "AlreadyExists"))
+	assert.True(t, m.rpcDuration.DeleteLabelValues("proto_user.UserService", "CreateUser", "OK"))
+}
@@ -0,0 +1,266 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/vinofsteel/grpc-management/internal/database"
+)
+
+type instrumentedQueries struct {
+	queries database.Queries
+	metrics *Metrics
+}
+
+// InstrumentQueries records the duration of every repository operation, labeled with the method name
+func InstrumentQueries(queries database.Queries, m *Metrics) database.Queries {
+	return &instrumentedQueries{queries: queries, metrics: m}
+}
+
+func (q *instrumentedQueries) ListUserByEmail(ctx context.Context, params database.ListUserByEmailParams) (*database.User, error) {
+	start := time.Now()
+	user, err := q.queries.ListUserByEmail(ctx, params)
+	q.observe("ListUserByEmail", start, err)
+
+	return user, err
+}
+
+func (q *instrumentedQueries) ListUserByUsername(ctx context.Context, params database.ListUserByUsernameParams) (*database.User, error) {
+	start := time.Now()
+	user, err := q.queries.ListUserByUsername(ctx, params)
+	q.observe("ListUserByUsername", start, err)
+
+	return user, err
+}
+
+func (q *instrumentedQueries) ListUserByUsernameSkeleton(ctx context.Context, params database.ListUserByUsernameSkeletonParams) (*database.User, error) {
+	start := time.Now()
+	user, err := q.queries.ListUserByUsernameSkeleton(ctx, params)
+	q.observe("ListUserByUsernameSkeleton", start, err)
+
+	return user, err
+}
+
+func (q *instrumentedQueries) ListUserById(ctx context.Context, params database.ListUserByIdParams) (*database.User, error) {
+	start := time.Now()
+	user, err := q.queries.ListUserById(ctx, params)
+	q.observe("ListUserById", start, err)
+
+	return user, err
+}
+
+func (q *instrumentedQueries) ListUsers(ctx context.Context, params database.ListUsersParams) ([]*database.User, error) {
+	start := time.Now()
+	users, err := q.queries.ListUsers(ctx, params)
+	q.observe("ListUsers", start, err)
+
+	return users, err
+}
+
+func (q *instrumentedQueries) InsertUser(ctx context.Context, params database.InsertUserParams) (*database.User, error) {
+	start := time.Now()
+	user, err := q.queries.InsertUser(ctx, params)
+	q.observe("InsertUser", start, err)
+
+	return user, err
+}
+
+func (q *instrumentedQueries) UpdateUserPassword(ctx context.Context, params database.UpdateUserPasswordParams) (*database.User, error) {
+	start := time.Now()
+	user, err := q.queries.UpdateUserPassword(ctx, params)
+	q.observe("UpdateUserPassword", start, err)
+
+	return user, err
+}
+
+func (q *instrumentedQueries) UpdateUserUsername(ctx context.Context, params database.UpdateUserUsernameParams) (*database.User, error) {
+	start := time.Now()
+	user, err := q.queries.UpdateUserUsername(ctx, params)
+	q.observe("UpdateUserUsername", start, err)
+
+	return user, err
+}
+
+func (q *instrumentedQueries) DeleteUser(ctx context.Context, params database.DeleteUserParams) error {
+	start := time.Now()
+	err := q.queries.DeleteUser(ctx, params)
+	q.observe("DeleteUser", start, err)
+
+	return err
+}
+
+func (q *instrumentedQueries) InsertOutboxEvent(ctx context.Context, params database.InsertOutboxEventParams) (*database.OutboxEvent, error) {
+	start := time.Now()
+	event, err := q.queries.InsertOutboxEvent(ctx, params)
+	q.observe("InsertOutboxEvent", start, err)
+
+	return event, err
+}
+
+func (q *instrumentedQueries) ClaimOutboxEvents(ctx context.Context, params database.ClaimOutboxEventsParams) ([]*database.OutboxEvent, error) {
+	start := time.Now()
+	events, err := q.queries.ClaimOutboxEvents(ctx, params)
+	q.observe("ClaimOutboxEvents", start, err)
+
+	return events, err
+}
+
+func (q *instrumentedQueries) MarkOutboxEventPublished(ctx context.Context, params database.MarkOutboxEventPublishedParams) error {
+	start := time.Now()
+	err := q.queries.MarkOutboxEventPublished(ctx, params)
+	q.observe("MarkOutboxEventPublished", start, err)
+
+	return err
+}
+
+func (q *instrumentedQueries) MarkOutboxEventFailed(ctx context.Context, params database.MarkOutboxEventFailedParams) error {
+	start := time.Now()
+	err := q.queries.MarkOutboxEventFailed(ctx, params)
+	q.observe("MarkOutboxEventFailed", start, err)
+
+	return err
+}
+
+func (q *instrumentedQueries) DeleteOutboxEvents(ctx context.Context, params database.DeleteOutboxEventsParams) (int64, error) {
+	start := time.Now()
+	count, err := q.queries.DeleteOutboxEvents(ctx, params)
+	q.observe("DeleteOutboxEvents", start, err)
+
+	return count, err
+}
+
+func (q *instrumentedQueries) SequenceUserChanges(ctx context.Context) (int64, error) {
+	start := time.Now()
+	count, err := q.queries.SequenceUserChanges(ctx)
+	q.observe("SequenceUserChanges", start, err)
+
+	return count, err
+}
+
+func (q *instrumentedQueries) ListUserChanges(ctx context.Context, params database.ListUserChangesParams) ([]*database.UserChange, error) {
+	start := time.Now()
+	changes, err := q.queries.ListUserChanges(ctx, params)
+	q.observe("ListUserChanges", start, err)
+
+	return changes, err
+}
+
+func (q *instrumentedQueries) ListUserChangesBounds(ctx context.Context) (*database.UserChangesBounds, error) {
+	start := time.Now()
+	bounds, err := q.queries.ListUserChangesBounds(ctx)
+	q.observe("ListUserChangesBounds", start, err)
+
+	return bounds, err
+}
+
+func (q *instrumentedQueries) DeleteUserChanges(ctx context.Context, params database.DeleteUserChangesParams) (int64, error) {
+	start := time.Now()
+	count, err := q.queries.DeleteUserChanges(ctx, params)
+	q.observe("DeleteUserChanges", start, err)
+
+	return count, err
+}
+
+func (q *instrumentedQueries) InsertWebhookSubscription(ctx context.Context, params database.InsertWebhookSubscriptionParams) (*database.WebhookSubscription, error) {
+	start := time.Now()
+	subscription, err := q.queries.InsertWebhookSubscription(ctx, params)
+	q.observe("InsertWebhookSubscription", start, err)
+
+	return subscription, err
+}
+
+func (q *instrumentedQueries) ListWebhookSubscriptions(ctx context.Context) ([]*database.WebhookSubscription, error) {
+	start := time.Now()
+	subscriptions, err := q.queries.ListWebhookSubscriptions(ctx)
+	q.observe("ListWebhookSubscriptions", start, err)
+
+	return subscriptions, err
+}
+
+func (q *instrumentedQueries) ListWebhookSubscriptionById(ctx context.Context, params database.ListWebhookSubscriptionByIdParams) (*database.WebhookSubscription, error) {
+	start := time.Now()
+	subscription, err := q.queries.ListWebhookSubscriptionById(ctx, params)
+	q.observe("ListWebhookSubscriptionById", start, err)
+
+	return subscription, err
+}
+
+func (q *instrumentedQueries) DeleteWebhookSubscription(ctx context.Context, params database.DeleteWebhookSubscriptionParams) error {
+	start := time.Now()
+	err := q.queries.DeleteWebhookSubscription(ctx, params)
+	q.observe("DeleteWebhookSubscription", start, err)
+
+	return err
+}
+
+func (q *instrumentedQueries) InsertWebhookDelivery(ctx context.Context, params database.InsertWebhookDeliveryParams) error {
+	start := time.Now()
+	err := q.queries.InsertWebhookDelivery(ctx, params)
+	q.observe("InsertWebhookDelivery", start, err)
+
+	return err
+}
+
+func (q *instrumentedQueries) ClaimWebhookDeliveries(ctx context.Context, params database.ClaimWebhookDeliveriesParams) ([]*database.WebhookDelivery, error) {
+	start := time.Now()
+	deliveries, err := q.queries.ClaimWebhookDeliveries(ctx, params)
+	q.observe("ClaimWebhookDeliveries", start, err)
+
+	return deliveries, err
+}
+
+func (q *instrumentedQueries) RecordWebhookDeliveryAttempt(ctx context.Context, params database.RecordWebhookDeliveryAttemptParams) error {
+	start := time.Now()
+	err := q.queries.RecordWebhookDeliveryAttempt(ctx, params)
+	q.observe("RecordWebhookDeliveryAttempt", start, err)
+
+	return err
+}
+
+func (q *instrumentedQueries) ListWebhookDeliveries(ctx context.Context, params database.ListWebhookDeliveriesParams) ([]*database.WebhookDelivery, error) {
+	start := time.Now()
+	deliveries, err := q.queries.ListWebhookDeliveries(ctx, params)
+	q.observe("ListWebhookDeliveries", start, err)
+
+	return deliveries, err
+}
+
+func (q *instrumentedQueries) ListWebhookDeliveryById(ctx context.Context, params database.ListWebhookDeliveryByIdParams) (*database.WebhookDelivery, error) {
+	start := time.Now()
+	delivery, err := q.queries.ListWebhookDeliveryById(ctx, params)
+	q.observe("ListWebhookDeliveryById", start, err)
+
+	return delivery, err
+}
+
+func (q *instrumentedQueries) RedeliverWebhookDelivery(ctx context.Context, params database.RedeliverWebhookDeliveryParams) (*database.WebhookDelivery, error) {
+	start := time.Now()
+	delivery, err := q.queries.RedeliverWebhookDelivery(ctx, params)
+	q.observe("RedeliverWebhookDelivery", start, err)
+
+	return delivery, err
+}
+
+// WithTx records the whole transaction as one operation, along with the queries run inside it
+func (q *instrumentedQueries) WithTx(ctx context.Context, fn func(tx database.Queries) error, options ...database.TxOption) error {
+	start := time.Now()
+	err := q.queries.WithTx(ctx, func(tx database.Queries) error {
+		return fn(&instrumentedQueries{queries: tx, metrics: q.metrics})
+	}, options...)
+	q.observe("WithTx", start, err)
+
+	return err
+}
+
+func (q *instrumentedQueries) observe(operation string, start time.Time, err error) {
+	outcome := QueryOutcomeSuccess
+	if errors.Is(err, sql.ErrNoRows) {
+		outcome = QueryOutcomeNotFound
+	} else if err != nil {
+		outcome = QueryOutcomeError
+	}
+
+	q.metrics.ObserveQuery(operation, outcome, time.Since(start))
+}
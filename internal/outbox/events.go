@@ -0,0 +1,71 @@
+// Package outbox records the domain events of user lifecycle changes in the transaction of the change (transactional
+// outbox), and relays them to a Publisher once committed. Events are delivered at least once, and the events of a
+// user in the order they happened.
+package outbox
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinofsteel/grpc-management/internal/database"
+	"github.com/vinofsteel/grpc-management/internal/events/proto_events"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Event types, each with the version of its payload. A version is bumped when its payload changes incompatibly.
+const (
+	EventUserCreated = "user.created"
+	EventUserUpdated = "user.updated"
+	EventUserDeleted = "user.deleted"
+
+	userCreatedVersion = 1
+	userUpdatedVersion = 1
+	userDeletedVersion = 1
+)
+
+// EventTypes lists every event type, e.g. for subscribers to choose from
+var EventTypes = []string{EventUserCreated, EventUserUpdated, EventUserDeleted}
+
+func userCreated(user *database.User, occurredAt time.Time) *proto_events.Event {
+	event := newEvent(EventUserCreated, userCreatedVersion, user.ID, occurredAt)
+	event.Payload = &proto_events.Event_UserCreated{UserCreated: &proto_events.UserCreated{
+		Id:        user.ID.String(),
+		Email:     user.Email,
+		Username:  user.Username,
+		CreatedAt: timestamppb.New(user.CreatedAt),
+	}}
+
+	return event
+}
+
+func userUpdated(user *database.User, fields []string, occurredAt time.Time) *proto_events.Event {
+	event := newEvent(EventUserUpdated, userUpdatedVersion, user.ID, occurredAt)
+	event.Payload = &proto_events.Event_UserUpdated{UserUpdated: &proto_events.UserUpdated{
+		Id:        user.ID.String(),
+		Fields:    fields,
+		UpdatedAt: timestamppb.New(user.UpdatedAt),
+	}}
+
+	return event
+}
+
+func userDeleted(userID uuid.UUID, hard bool, occurredAt time.Time) *proto_events.Event {
+	event := newEvent(EventUserDeleted, userDeletedVersion, userID, occurredAt)
+	event.Payload = &proto_events.Event_UserDeleted{UserDeleted: &proto_events.UserDeleted{
+		Id:   userID.String(),
+		Hard: hard,
+	}}
+
+	return event
+}
+
+// Utilities
+func newEvent(eventType string, version int32, userID uuid.UUID, occurredAt time.Time) *proto_events.Event {
+	return &proto_events.Event{
+		Id:         uuid.NewString(),
+		Type:       eventType,
+		Version:    version,
+		UserId:     userID.String(),
+		OccurredAt: timestamppb.New(occurredAt),
+	}
+}
@@ -0,0 +1,117 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vinofsteel/grpc-management/internal/events/proto_events"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Publisher delivers events outside the application. An error makes the relay retry the event later, so Publish may
+// be called more than once with the same event.
+type Publisher interface {
+	Publish(ctx context.Context, event *proto_events.Event) error
+}
+
+type multiPublisher []Publisher
+
+// NewMultiPublisher publishes every event to each of publishers in turn, stopping at the first failure. The event
+// is then published again to all of them, so they should tolerate duplicates.
+func NewMultiPublisher(publishers ...Publisher) Publisher {
+	return multiPublisher(publishers)
+}
+
+func (p multiPublisher) Publish(ctx context.Context, event *proto_events.Event) error {
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type stdoutPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewStdoutPublisher writes every event to w as a line of JSON, mostly for development
+func NewStdoutPublisher(w io.Writer) Publisher {
+	return &stdoutPublisher{w: w}
+}
+
+func (p *stdoutPublisher) Publish(ctx context.Context, event *proto_events.Event) error {
+	line, err := protojson.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, err = p.w.Write(append(line, '\n'))
+	return err
+}
+
+type WebhookConfig struct {
+	URL string
+	// Timeout bounds each delivery, 10 seconds when 0
+	Timeout time.Duration
+}
+
+type webhookPublisher struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookPublisher POSTs every event to the configured URL as JSON, along with its ID, type and version in the
+// X-Event-Id, X-Event-Type and X-Event-Version headers. Any status other than 2xx is a failed delivery.
+func NewWebhookPublisher(config WebhookConfig) Publisher {
+	if config.Timeout == 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	return &webhookPublisher{
+		url:    config.URL,
+		client: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+func (p *webhookPublisher) Publish(ctx context.Context, event *proto_events.Event) error {
+	body, err := protojson.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Id", event.Id)
+	req.Header.Set("X-Event-Type", event.Type)
+	req.Header.Set("X-Event-Version", strconv.Itoa(int(event.Version)))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Drained so the connection is reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinofsteel/grpc-management/internal/database"
+	"github.com/vinofsteel/grpc-management/internal/events/proto_events"
+	"google.golang.org/protobuf/proto"
+)
+
+type recordingQueries struct {
+	database.Queries
+	now func() time.Time
+}
+
+// NewRecordingQueries records an event in the outbox for every user created, updated or deleted through queries,
+// in the same transaction as the change, so an event is recorded if and only if the change is committed. Only changes
+// made through the returned queries are recorded, the server wraps its queries only when there's a publisher.
+func NewRecordingQueries(queries database.Queries) database.Queries {
+	return &recordingQueries{Queries: queries, now: time.Now}
+}
+
+func (q *recordingQueries) InsertUser(ctx context.Context, params database.InsertUserParams) (*database.User, error) {
+	var user *database.User
+	err := q.Queries.WithTx(ctx, func(tx database.Queries) error {
+		var err error
+		user, err = tx.InsertUser(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		return q.record(ctx, tx, userCreated(user, q.timestamp()))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (q *recordingQueries) UpdateUserPassword(ctx context.Context, params database.UpdateUserPasswordParams) (*database.User, error) {
+	var user *database.User
+	err := q.Queries.WithTx(ctx, func(tx database.Queries) error {
+		var err error
+		user, err = tx.UpdateUserPassword(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		return q.record(ctx, tx, userUpdated(user, []string{"password"}, q.timestamp()))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (q *recordingQueries) UpdateUserUsername(ctx context.Context, params database.UpdateUserUsernameParams) (*database.User, error) {
+	var user *database.User
+	err := q.Queries.WithTx(ctx, func(tx database.Queries) error {
+		var err error
+		user, err = tx.UpdateUserUsername(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		return q.record(ctx, tx, userUpdated(user, []string{"username"}, q.timestamp()))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// DeleteUser records an event only when a user was deleted, deleting a missing (or, softly, an already deleted) user
+// being a no-op
+func (q *recordingQueries) DeleteUser(ctx context.Context, params database.DeleteUserParams) error {
+	return q.Queries.WithTx(ctx, func(tx database.Queries) error {
+		_, err := tx.ListUserById(ctx, database.ListUserByIdParams{ID: params.ID, ListDeleted: params.Hard})
+		if errors.Is(err, sql.ErrNoRows) {
+			return tx.DeleteUser(ctx, params)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.DeleteUser(ctx, params); err != nil {
+			return err
+		}
+
+		return q.record(ctx, tx, userDeleted(params.ID, params.Hard, q.timestamp()))
+	})
+}
+
+// WithTx records the events of the writes made in the transaction along with them
+func (q *recordingQueries) WithTx(ctx context.Context, fn func(tx database.Queries) error, options ...database.TxOption) error {
+	return q.Queries.WithTx(ctx, func(tx database.Queries) error {
+		return fn(&recordingQueries{Queries: tx, now: q.now})
+	}, options...)
+}
+
+// Utilities
+// record inserts event in the outbox through tx
+func (q *recordingQueries) record(ctx context.Context, tx database.Queries, event *proto_events.Event) error {
+	payload, err := proto.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error encoding %s event: %w", event.Type, err)
+	}
+
+	_, err = tx.InsertOutboxEvent(ctx, database.InsertOutboxEventParams{
+		EventID:      uuid.MustParse(event.Id),
+		AggregateID:  uuid.MustParse(event.UserId),
+		EventType:    event.Type,
+		EventVersion: event.Version,
+		Payload:      payload,
+		CreatedAt:    event.OccurredAt.AsTime(),
+	})
+	return err
+}
+
+// timestamp matches the microsecond precision of the database timestamps, so the recorded event and its row agree
+func (q *recordingQueries) timestamp() time.Time {
+	return q.now().UTC().Truncate(time.Microsecond)
+}
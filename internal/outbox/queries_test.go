@@ -0,0 +1,112 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/vinofsteel/grpc-management/internal/database"
+	"github.com/vinofsteel/grpc-management/internal/database/memory"
+	"github.com/vinofsteel/grpc-management/internal/events/proto_events"
+)
+
+// fakePublisher keeps the events published, failing while err is set
+type fakePublisher struct {
+	mu     sync.Mutex
+	events []*proto_events.Event
+	err    error
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, event *proto_events.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+
+	p.events = append(p.events, event)
+	return nil
+}
+
+// failingOutbox fails every outbox insert, inside transactions too
+type failingOutbox struct {
+	database.Queries
+}
+
+func (q *failingOutbox) InsertOutboxEvent(ctx context.Context, params database.InsertOutboxEventParams) (*database.OutboxEvent, error) {
+	return nil, errors.New("outbox unavailable")
+}
+
+func (q *failingOutbox) WithTx(ctx context.Context, fn func(tx database.Queries) error, options ...database.TxOption) error {
+	return q.Queries.WithTx(ctx, func(tx database.Queries) error {
+		return fn(&failingOutbox{Queries: tx})
+	}, options...)
+}
+
+func TestRecordingQueries(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.NewMemoryQueries()
+	q := NewRecordingQueries(mem)
+
+	t.Logf("Running success case: Testing every user change records its event")
+	user, err := q.InsertUser(ctx, database.InsertUserParams{Email: "alice@example.com", Username: "alice", UsernameSkeleton: "alice", Password: "hash"})
+	assert.NoError(t, err)
+	_, err = q.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{UserID: user.ID, Password: "new-hash"})
+	assert.NoError(t, err)
+	_, err = q.UpdateUserUsername(ctx, database.UpdateUserUsernameParams{UserID: user.ID, Username: "alicia", UsernameSkeleton: "alicia"})
+	assert.NoError(t, err)
+	assert.NoError(t, q.DeleteUser(ctx, database.DeleteUserParams{ID: user.ID}))
+
+	events := publishAll(t, ctx, q)
+	if assert.Len(t, events, 4) {
+		assert.Equal(t, EventUserCreated, events[0].Type)
+		assert.Equal(t, int32(1), events[0].Version)
+		assert.Equal(t, user.ID.String(), events[0].UserId)
+		assert.Equal(t, "alice@example.com", events[0].GetUserCreated().Email)
+		assert.Equal(t, []string{"password"}, events[1].GetUserUpdated().Fields)
+		assert.Equal(t, []string{"username"}, events[2].GetUserUpdated().Fields)
+		assert.Equal(t, EventUserDeleted, events[3].Type)
+		assert.False(t, events[3].GetUserDeleted().Hard)
+	}
+
+	t.Logf("Running success case: Testing deletes that change nothing record nothing")
+	assert.NoError(t, q.DeleteUser(ctx, database.DeleteUserParams{ID: user.ID}))
+	assert.NoError(t, q.DeleteUser(ctx, database.DeleteUserParams{ID: uuid.New(), Hard: true}))
+	assert.Empty(t, publishAll(t, ctx, q))
+
+	t.Logf("Running success case: Testing the events of a rolled back transaction are discarded with it")
+	err = q.WithTx(ctx, func(tx database.Queries) error {
+		if _, err := tx.InsertUser(ctx, database.InsertUserParams{Email: "bob@example.com", Username: "bob", UsernameSkeleton: "bob", Password: "hash"}); err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	assert.Error(t, err)
+	assert.Empty(t, publishAll(t, ctx, q))
+
+	t.Logf("Running failure case: Testing the change is rolled back when its event can't be recorded")
+	failing := NewRecordingQueries(&failingOutbox{Queries: mem})
+	_, err = failing.InsertUser(ctx, database.InsertUserParams{Email: "carol@example.com", Username: "carol", UsernameSkeleton: "carol", Password: "hash"})
+	assert.Error(t, err)
+	_, err = q.ListUserByUsername(ctx, database.ListUserByUsernameParams{Username: "carol"})
+	assert.Error(t, err)
+}
+
+// Utilities
+// publishAll publishes every pending event of q, returning them in the order they were published
+func publishAll(t *testing.T, ctx context.Context, q database.Queries) []*proto_events.Event {
+	t.Helper()
+
+	publisher := &fakePublisher{}
+	relay := NewRelay(q, publisher, RelayConfig{})
+	for {
+		claimed, err := relay.relayBatch(ctx)
+		if !assert.NoError(t, err) || claimed == 0 {
+			return publisher.events
+		}
+	}
+}
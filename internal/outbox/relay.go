@@ -0,0 +1,186 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/vinofsteel/grpc-management/internal/database"
+	"github.com/vinofsteel/grpc-management/internal/events/proto_events"
+	"google.golang.org/protobuf/proto"
+)
+
+type RelayConfig struct {
+	// PollInterval is how often the outbox is checked for new events once it's been emptied
+	PollInterval time.Duration
+	// BatchSize is how many users have an event published at the same time
+	BatchSize int32
+	// Lease is how long a claimed event is reserved to this relay, it must outlast a delivery. Events of a relay that
+	// stopped before marking them are claimed again once it expires.
+	Lease time.Duration
+	// MinBackoff and MaxBackoff bound the wait before a failed event is retried, doubling with every attempt
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Retention is how long published events are kept before being deleted, checked every CleanupInterval
+	Retention       time.Duration
+	CleanupInterval time.Duration
+}
+
+type Relay struct {
+	queries   database.Queries
+	publisher Publisher
+	config    RelayConfig
+	now       func() time.Time
+}
+
+// NewRelay publishes the events recorded in the outbox of queries through publisher. Several relays (e.g. one per
+// server) can share an outbox, each event being claimed by a single one at a time. Failed events are retried
+// indefinitely, holding back the later events of their user so they're never published out of order.
+func NewRelay(queries database.Queries, publisher Publisher, config RelayConfig) *Relay {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.Lease <= 0 {
+		config.Lease = time.Minute
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = time.Second
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		config.MaxBackoff = max(5*time.Minute, config.MinBackoff)
+	}
+	if config.CleanupInterval <= 0 {
+		config.CleanupInterval = time.Hour
+	}
+
+	return &Relay{
+		queries:   queries,
+		publisher: publisher,
+		config:    config,
+		now:       time.Now,
+	}
+}
+
+// Run publishes events until ctx is done
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	var lastCleanup time.Time
+	for {
+		// Full batches mean more events are waiting
+		for {
+			claimed, err := r.relayBatch(ctx)
+			if err != nil || claimed < int(r.config.BatchSize) {
+				break
+			}
+		}
+
+		if r.now().Sub(lastCleanup) >= r.config.CleanupInterval {
+			r.cleanup(ctx)
+			lastCleanup = r.now()
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// relayBatch publishes the events of a claimed batch, returning how many were claimed. Each event belongs to a
+// different user, so they're published concurrently.
+func (r *Relay) relayBatch(ctx context.Context) (int, error) {
+	now := r.timestamp()
+	events, err := r.queries.ClaimOutboxEvents(ctx, database.ClaimOutboxEventsParams{
+		Now:        now,
+		LeaseUntil: now.Add(r.config.Lease),
+		Limit:      r.config.BatchSize,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error claiming outbox events", "error", err)
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, event := range events {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.publish(ctx, event)
+		}()
+	}
+	wg.Wait()
+
+	return len(events), nil
+}
+
+func (r *Relay) publish(ctx context.Context, row *database.OutboxEvent) {
+	var event proto_events.Event
+	err := proto.Unmarshal(row.Payload, &event)
+	if err == nil {
+		err = r.publisher.Publish(ctx, &event)
+	}
+
+	// Marked even if ctx is done, the event having been published or not
+	ctx = context.WithoutCancel(ctx)
+
+	if err != nil {
+		backoff := r.backoff(row.Attempts)
+		slog.WarnContext(ctx, "Error publishing outbox event", "error", err, "event_id", row.EventID, "event_type", row.EventType, "attempts", row.Attempts+1, "retry_in", backoff)
+
+		if err := r.queries.MarkOutboxEventFailed(ctx, database.MarkOutboxEventFailedParams{
+			ID:            row.ID,
+			Error:         err.Error(),
+			NextAttemptAt: r.timestamp().Add(backoff),
+		}); err != nil {
+			slog.ErrorContext(ctx, "Error marking outbox event as failed", "error", err, "event_id", row.EventID)
+		}
+		return
+	}
+
+	// Should this fail, the event is published again once its lease expires
+	if err := r.queries.MarkOutboxEventPublished(ctx, database.MarkOutboxEventPublishedParams{
+		ID:          row.ID,
+		PublishedAt: r.timestamp(),
+	}); err != nil {
+		slog.ErrorContext(ctx, "Error marking outbox event as published", "error", err, "event_id", row.EventID)
+	}
+}
+
+// cleanup deletes the events published longer than the retention ago, returning how many were
+func (r *Relay) cleanup(ctx context.Context) int64 {
+	deleted, err := r.queries.DeleteOutboxEvents(ctx, database.DeleteOutboxEventsParams{
+		PublishedBefore: r.timestamp().Add(-r.config.Retention),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting published outbox events", "error", err)
+		return 0
+	}
+
+	if deleted > 0 {
+		slog.InfoContext(ctx, "Deleted published outbox events", "count", deleted)
+	}
+	return deleted
+}
+
+// Utilities
+// backoff is the wait before retrying an event that failed after attempts previous attempts
+func (r *Relay) backoff(attempts int32) time.Duration {
+	backoff := r.config.MinBackoff
+	for range attempts {
+		backoff *= 2
+		if backoff >= r.config.MaxBackoff {
+			return r.config.MaxBackoff
+		}
+	}
+
+	return backoff
+}
+
+// timestamp is in UTC with microsecond precision, as written by the repositories
+func (r *Relay) timestamp() time.Time {
+	return r.now().UTC().Truncate(time.Microsecond)
+}
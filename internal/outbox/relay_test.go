@@ -0,0 +1,100 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vinofsteel/grpc-management/internal/database"
+	"github.com/vinofsteel/grpc-management/internal/database/memory"
+	"github.com/vinofsteel/grpc-management/internal/events/proto_events"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestRelay(t *testing.T) {
+	ctx := context.Background()
+	q := NewRecordingQueries(memory.NewMemoryQueries())
+	publisher := &fakePublisher{}
+	relay := NewRelay(q, publisher, RelayConfig{MinBackoff: time.Second, MaxBackoff: 4 * time.Second, Retention: time.Hour})
+	alice, err := q.InsertUser(ctx, database.InsertUserParams{Email: "alice@example.com", Username: "alice", UsernameSkeleton: "alice", Password: "hash"})
+	assert.NoError(t, err)
+	bob, err := q.InsertUser(ctx, database.InsertUserParams{Email: "bob@example.com", Username: "bob", UsernameSkeleton: "bob", Password: "hash"})
+	assert.NoError(t, err)
+	_, err = q.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{UserID: alice.ID, Password: "new-hash"})
+	assert.NoError(t, err)
+
+	now := time.Now()
+	relay.now = func() time.Time { return now }
+
+	t.Logf("Running failure case: Testing failed events are retried with backoff, holding back the later events of their user")
+	publisher.err = errors.New("unreachable")
+	claimed, err := relay.relayBatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, claimed)
+
+	publisher.err = nil
+	claimed, err = relay.relayBatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, claimed)
+
+	now = now.Add(time.Second)
+	claimed, err = relay.relayBatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, claimed)
+	assert.Equal(t, 4*time.Second, relay.backoff(5))
+
+	t.Logf("Running success case: Testing the events of a user are published in order")
+	_, err = relay.relayBatch(ctx)
+	assert.NoError(t, err)
+	var aliceEvents []string
+	for _, event := range publisher.events {
+		if event.UserId == alice.ID.String() {
+			aliceEvents = append(aliceEvents, event.Type)
+		}
+	}
+	assert.Equal(t, []string{EventUserCreated, EventUserUpdated}, aliceEvents)
+	assert.Len(t, publisher.events, 3)
+	assert.Contains(t, []string{publisher.events[0].UserId, publisher.events[1].UserId}, bob.ID.String())
+
+	t.Logf("Running success case: Testing published events are deleted once past the retention")
+	assert.Equal(t, int64(0), relay.cleanup(ctx))
+
+	now = now.Add(2 * time.Hour)
+	assert.Equal(t, int64(3), relay.cleanup(ctx))
+}
+
+func TestWebhookPublisher(t *testing.T) {
+	status := http.StatusNoContent
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	publisher := NewWebhookPublisher(WebhookConfig{URL: server.URL})
+	event := &proto_events.Event{Id: "event-id", Type: EventUserDeleted, Version: 1, UserId: "user-id"}
+
+	t.Logf("Running success case: Testing events are POSTed as JSON with their ID, type and version in headers")
+	assert.NoError(t, publisher.Publish(context.Background(), event))
+	assert.Equal(t, http.MethodPost, received.Method)
+	assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+	assert.Equal(t, "event-id", received.Header.Get("X-Event-Id"))
+	assert.Equal(t, EventUserDeleted, received.Header.Get("X-Event-Type"))
+	assert.Equal(t, "1", received.Header.Get("X-Event-Version"))
+
+	var decoded proto_events.Event
+	assert.NoError(t, protojson.Unmarshal(body, &decoded))
+	assert.Equal(t, "user-id", decoded.UserId)
+
+	t.Logf("Running failure case: Testing statuses other than 2xx fail the delivery")
+	status = http.StatusInternalServerError
+	assert.Error(t, publisher.Publish(context.Background(), event))
+}
@@ -0,0 +1,36 @@
+package password
+
+import (
+	"context"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultBcryptCost is the work factor used for new password hashes
+const DefaultBcryptCost = 12
+
+// Hasher hashes user passwords before they're stored
+type Hasher interface {
+	Hash(ctx context.Context, password string) (string, error)
+}
+
+type bcryptHasher struct {
+	cost int
+}
+
+func NewBcryptHasher(cost int) Hasher {
+	if cost == 0 {
+		cost = DefaultBcryptCost
+	}
+
+	return &bcryptHasher{cost: cost}
+}
+
+func (h *bcryptHasher) Hash(ctx context.Context, password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hashedPassword), nil
+}
@@ -0,0 +1,82 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// ErrHasherSaturated is returned when every hashing worker is busy and the queue is full
+var ErrHasherSaturated = errors.New("password hasher saturated")
+
+type PoolConfig struct {
+	// Workers is the number of passwords hashed at once, usually the number of CPUs
+	Workers int
+	// MaxQueue is the number of calls allowed to wait for a worker, others fail right away with ErrHasherSaturated
+	MaxQueue int
+}
+
+// Pool is a Hasher running on a bounded number of workers
+type Pool interface {
+	Hasher
+	// Resize changes the number of workers and the queue length. Hashes already running finish on the previous
+	// workers, so for a moment both sizes may add up.
+	Resize(config PoolConfig)
+}
+
+type workerPool struct {
+	workers  chan struct{}
+	maxQueue int64
+}
+
+type pooledHasher struct {
+	hasher  Hasher
+	pool    atomic.Pointer[workerPool]
+	waiting atomic.Int64
+}
+
+// NewPooledHasher bounds the number of concurrent hashes, so a burst of sign ups can't take every CPU
+// away from the other calls. Waiting calls give up when their context is done.
+func NewPooledHasher(hasher Hasher, config PoolConfig) Pool {
+	h := &pooledHasher{
+		hasher: hasher,
+	}
+	h.Resize(config)
+
+	return h
+}
+
+func (h *pooledHasher) Resize(config PoolConfig) {
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+
+	h.pool.Store(&workerPool{
+		workers:  make(chan struct{}, config.Workers),
+		maxQueue: int64(config.MaxQueue),
+	})
+}
+
+func (h *pooledHasher) Hash(ctx context.Context, password string) (string, error) {
+	pool := h.pool.Load()
+
+	select {
+	case pool.workers <- struct{}{}:
+	default:
+		if h.waiting.Add(1) > pool.maxQueue {
+			h.waiting.Add(-1)
+			return "", ErrHasherSaturated
+		}
+
+		select {
+		case pool.workers <- struct{}{}:
+			h.waiting.Add(-1)
+		case <-ctx.Done():
+			h.waiting.Add(-1)
+			return "", ctx.Err()
+		}
+	}
+	defer func() { <-pool.workers }()
+
+	return h.hasher.Hash(ctx, password)
+}
@@ -0,0 +1,55 @@
+package password
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type blockingHasher struct {
+	started chan struct{}
+	unblock chan struct{}
+}
+
+func (h blockingHasher) Hash(ctx context.Context, password string) (string, error) {
+	h.started <- struct{}{}
+	<-h.unblock
+	return "hashed:" + password, nil
+}
+
+func TestPooledHasher(t *testing.T) {
+	inner := blockingHasher{started: make(chan struct{}, 2), unblock: make(chan struct{})}
+	hasher := NewPooledHasher(inner, PoolConfig{Workers: 1, MaxQueue: 1}).(*pooledHasher)
+
+	results := make(chan error, 2)
+	go func() {
+		_, err := hasher.Hash(context.Background(), "first")
+		results <- err
+	}()
+	<-inner.started
+
+	t.Logf("Running failure case: Testing a waiting call gives up when its context is done")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := hasher.Hash(ctx, "cancelled")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int64(0), hasher.waiting.Load())
+
+	go func() {
+		_, err := hasher.Hash(context.Background(), "queued")
+		results <- err
+	}()
+	assert.Eventually(t, func() bool { return hasher.waiting.Load() == 1 }, time.Second, time.Millisecond)
+
+	t.Logf("Running failure case: Testing calls are rejected once the queue is full")
+	_, err = hasher.Hash(context.Background(), "rejected")
+	assert.ErrorIs(t, err, ErrHasherSaturated)
+
+	t.Logf("Running success case: Testing queued calls run once a worker is free")
+	close(inner.unblock)
+	assert.NoError(t, <-results)
+	assert.NoError(t, <-results)
+	assert.Equal(t, int64(0), hasher.waiting.Load())
+}
@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	// fullAt is when the bucket is back to its burst, after which it can be dropped without changing any decision
+	fullAt time.Time
+}
+
+type memoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// NewMemoryLimiter keeps the buckets in this process, so each replica enforces its limits separately.
+// Full buckets are dropped every cleanupInterval, until ctx is done.
+func NewMemoryLimiter(ctx context.Context, cleanupInterval time.Duration) Limiter {
+	limiter := &memoryLimiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+	go limiter.runCleanup(ctx, cleanupInterval)
+
+	return limiter
+}
+
+func (l *memoryLimiter) Allow(ctx context.Context, key string, limit Limit) (Decision, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	burst := float64(limit.Burst)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, updatedAt: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.updatedAt).Seconds()*limit.Rate)
+	b.updatedAt = now
+
+	decision := Decision{Allowed: b.tokens >= 1}
+	if decision.Allowed {
+		b.tokens--
+	} else {
+		decision.RetryAfter = retryAfter(b.tokens, limit)
+	}
+	b.fullAt = now.Add(time.Duration((burst - b.tokens) / limit.Rate * float64(time.Second)))
+
+	return decision, nil
+}
+
+func (l *memoryLimiter) runCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.cleanup()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (l *memoryLimiter) cleanup() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for key, b := range l.buckets {
+		if !now.Before(b.fullAt) {
+			delete(l.buckets, key)
+		}
+	}
+}
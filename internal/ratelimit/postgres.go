@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vinofsteel/grpc-management/pkg"
+)
+
+// Buckets left untouched for this long are deleted. Limits refilling slower than that start full again afterwards.
+const postgresBucketIdleTTL = time.Hour
+
+// refilledTokens is the content of the existing bucket after refilling it for the time elapsed since its last update
+const refilledTokens = `LEAST(CAST(:burst AS double precision),
+	b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * CAST(:rate AS double precision))`
+
+// The whole decision is made in a single statement, so replicas racing on the same bucket are serialized by the row lock
+var allowQuery = fmt.Sprintf(`INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+	VALUES (:key, CAST(:burst AS double precision) - 1, true, now())
+	ON CONFLICT (key) DO UPDATE SET
+		tokens = CASE WHEN %[1]s >= 1 THEN %[1]s - 1 ELSE %[1]s END,
+		allowed = %[1]s >= 1,
+		updated_at = now()
+	RETURNING tokens, allowed`, refilledTokens)
+
+type postgresLimiter struct {
+	db *sqlx.DB
+}
+
+type allowParams struct {
+	Key   string  `db:"key"`
+	Rate  float64 `db:"rate"`
+	Burst int     `db:"burst"`
+}
+
+type allowResult struct {
+	Tokens  float64 `db:"tokens"`
+	Allowed bool    `db:"allowed"`
+}
+
+// NewPostgresLimiter keeps the buckets in the rate_limit_buckets table, so the limits are shared by every replica.
+// Idle buckets are deleted every cleanupInterval, until ctx is done.
+func NewPostgresLimiter(ctx context.Context, provider pkg.DBProvider, cleanupInterval time.Duration) (Limiter, error) {
+	db, err := provider.GetConnection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limiter := &postgresLimiter{db: db}
+	go limiter.runCleanup(ctx, cleanupInterval)
+
+	return limiter, nil
+}
+
+func (l *postgresLimiter) Allow(ctx context.Context, key string, limit Limit) (Decision, error) {
+	rows, err := l.db.NamedQueryContext(ctx, allowQuery, allowParams{Key: key, Rate: limit.Rate, Burst: limit.Burst})
+	if err != nil {
+		return Decision{}, fmt.Errorf("error taking rate limit token: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return Decision{}, fmt.Errorf("error taking rate limit token: %w", err)
+		}
+		return Decision{}, fmt.Errorf("error taking rate limit token: no bucket returned")
+	}
+
+	var result allowResult
+	if err := rows.StructScan(&result); err != nil {
+		return Decision{}, fmt.Errorf("error scanning rate limit bucket: %w", err)
+	}
+
+	if result.Allowed {
+		return Decision{Allowed: true}, nil
+	}
+
+	return Decision{Allowed: false, RetryAfter: retryAfter(result.Tokens, limit)}, nil
+}
+
+func (l *postgresLimiter) runCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			result, err := l.db.ExecContext(ctx, `DELETE FROM rate_limit_buckets WHERE updated_at < now() - make_interval(secs => $1)`,
+				postgresBucketIdleTTL.Seconds())
+			if err != nil {
+				slog.ErrorContext(ctx, "Error deleting idle rate limit buckets", "error", err)
+				continue
+			}
+
+			if deleted, _ := result.RowsAffected(); deleted > 0 {
+				slog.DebugContext(ctx, "Deleted idle rate limit buckets", "count", deleted)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Limit is a token bucket: Burst calls can be made at once, refilled at Rate calls per second
+type Limit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// Limits holds the limit of each gRPC method, keyed by full method name ("/proto_user.UserService/CreateUser")
+type Limits struct {
+	// Default applies to methods without a limit of their own, which are unlimited when it's nil
+	Default *Limit           `json:"default"`
+	Methods map[string]Limit `json:"methods"`
+}
+
+// Decision is the outcome of taking a token from a bucket
+type Decision struct {
+	Allowed bool
+	// RetryAfter is how long until a token is available again, zero when allowed
+	RetryAfter time.Duration
+}
+
+// Limiter keeps the token buckets. Each key (e.g. a method and a client) has a bucket of its own.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Decision, error)
+}
+
+// For returns the limit of fullMethod, or false when it's unlimited
+func (l Limits) For(fullMethod string) (Limit, bool) {
+	if limit, ok := l.Methods[fullMethod]; ok {
+		return limit, true
+	}
+	if l.Default != nil {
+		return *l.Default, true
+	}
+
+	return Limit{}, false
+}
+
+// Policy holds the limits in effect, which can be replaced while the server runs (e.g. on a configuration reload)
+type Policy interface {
+	// For returns the limit of fullMethod, or false when it's unlimited
+	For(fullMethod string) (Limit, bool)
+	Update(limits Limits)
+}
+
+type policy struct {
+	limits atomic.Pointer[Limits]
+}
+
+func NewPolicy(limits Limits) Policy {
+	p := &policy{}
+	p.limits.Store(&limits)
+
+	return p
+}
+
+func (p *policy) For(fullMethod string) (Limit, bool) {
+	return p.limits.Load().For(fullMethod)
+}
+
+func (p *policy) Update(limits Limits) {
+	p.limits.Store(&limits)
+}
+
+// LoadLimits reads the limits from a JSON file, e.g.
+//
+//	{"default": {"rate": 20, "burst": 40}, "methods": {"/proto_user.UserService/CreateUser": {"rate": 0.2, "burst": 5}}}
+func LoadLimits(path string) (Limits, error) {
+	// #nosec G304
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Limits{}, fmt.Errorf("error reading rate limit file: %w", err)
+	}
+
+	var limits Limits
+	if err := json.Unmarshal(content, &limits); err != nil {
+		return Limits{}, fmt.Errorf("error parsing rate limit file: %w", err)
+	}
+
+	if limits.Default != nil {
+		if err := limits.Default.validate(); err != nil {
+			return Limits{}, fmt.Errorf("invalid default rate limit: %w", err)
+		}
+	}
+	for method, limit := range limits.Methods {
+		if err := limit.validate(); err != nil {
+			return Limits{}, fmt.Errorf("invalid rate limit for %s: %w", method, err)
+		}
+	}
+
+	return limits, nil
+}
+
+func (l Limit) validate() error {
+	if l.Rate <= 0 || math.IsInf(l.Rate, 0) || math.IsNaN(l.Rate) {
+		return fmt.Errorf("rate must be a positive number of calls per second, got %v", l.Rate)
+	}
+	if l.Burst < 1 {
+		return fmt.Errorf("burst must be at least 1, got %d", l.Burst)
+	}
+
+	return nil
+}
+
+// Utilities
+
+// retryAfter is how long a bucket holding tokens takes to refill up to one token
+func retryAfter(tokens float64, limit Limit) time.Duration {
+	return time.Duration((1 - tokens) / limit.Rate * float64(time.Second))
+}
@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	limiter := NewMemoryLimiter(ctx, time.Hour).(*memoryLimiter)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	limit := Limit{Rate: 2, Burst: 3}
+
+	t.Logf("Running success case: Testing a burst of calls is allowed")
+	for i := 0; i < 3; i++ {
+		decision, err := limiter.Allow(ctx, "CreateUser|ip:10.0.0.1", limit)
+		assert.NoError(t, err)
+		assert.True(t, decision.Allowed)
+	}
+
+	t.Logf("Running failure case: Testing calls over the burst are rejected with the time until the next token")
+	decision, err := limiter.Allow(ctx, "CreateUser|ip:10.0.0.1", limit)
+	assert.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, 500*time.Millisecond, decision.RetryAfter)
+
+	t.Logf("Running success case: Testing other clients have buckets of their own")
+	decision, err = limiter.Allow(ctx, "CreateUser|ip:10.0.0.2", limit)
+	assert.NoError(t, err)
+	assert.True(t, decision.Allowed)
+
+	t.Logf("Running success case: Testing tokens are refilled over time")
+	now = now.Add(500 * time.Millisecond)
+	decision, err = limiter.Allow(ctx, "CreateUser|ip:10.0.0.1", limit)
+	assert.NoError(t, err)
+	assert.True(t, decision.Allowed)
+
+	t.Logf("Running success case: Testing only full buckets are cleaned up")
+	now = now.Add(time.Second)
+	limiter.cleanup()
+	assert.Len(t, limiter.buckets, 1)
+	assert.Contains(t, limiter.buckets, "CreateUser|ip:10.0.0.1")
+}
+
+func TestLoadLimits(t *testing.T) {
+	limitsTests := []struct {
+		name    string
+		content string
+		wantErr bool
+		method  string
+		want    *Limit
+	}{
+		{
+			name:    "success case: Testing a method limit takes precedence over the default",
+			content: `{"default": {"rate": 20, "burst": 40}, "methods": {"/proto_user.UserService/CreateUser": {"rate": 0.2, "burst": 5}}}`,
+			method:  "/proto_user.UserService/CreateUser",
+			want:    &Limit{Rate: 0.2, Burst: 5},
+		},
+		{
+			name:    "success case: Testing methods without a limit use the default",
+			content: `{"default": {"rate": 20, "burst": 40}}`,
+			method:  "/proto_user.UserService/ListUsers",
+			want:    &Limit{Rate: 20, Burst: 40},
+		},
+		{
+			name:    "success case: Testing methods are unlimited without a default",
+			content: `{"methods": {"/proto_user.UserService/CreateUser": {"rate": 1, "burst": 1}}}`,
+			method:  "/proto_user.UserService/ListUsers",
+		},
+		{
+			name:    "failure case: Testing a zero burst is rejected",
+			content: `{"methods": {"/proto_user.UserService/CreateUser": {"rate": 1, "burst": 0}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "failure case: Testing a negative rate is rejected",
+			content: `{"default": {"rate": -1, "burst": 10}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range limitsTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Logf("Running %s", tt.name)
+
+			path := filepath.Join(t.TempDir(), "limits.json")
+			assert.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+
+			limits, err := LoadLimits(path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			limit, ok := limits.For(tt.method)
+			assert.Equal(t, tt.want != nil, ok)
+			if tt.want != nil {
+				assert.Equal(t, *tt.want, limit)
+			}
+		})
+	}
+}
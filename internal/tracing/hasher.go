@@ -0,0 +1,33 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/vinofsteel/grpc-management/internal/password"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+)
+
+var tracer = otel.Tracer("github.com/vinofsteel/grpc-management/internal/tracing")
+
+type tracedHasher struct {
+	hasher password.Hasher
+}
+
+// InstrumentHasher wraps every password hash in a span, so its cost shows up next to the queries of the call
+func InstrumentHasher(hasher password.Hasher) password.Hasher {
+	return &tracedHasher{hasher: hasher}
+}
+
+func (h *tracedHasher) Hash(ctx context.Context, password string) (string, error) {
+	ctx, span := tracer.Start(ctx, "password.Hash")
+	defer span.End()
+
+	hashedPassword, err := h.hasher.Hash(ctx, password)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return hashedPassword, err
+}
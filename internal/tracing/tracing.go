@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Exporters accepted in Config.Exporter
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterNone   = "none"
+)
+
+type Config struct {
+	// Exporter is one of the Exporter constants, tracing is disabled with ExporterNone (or when empty).
+	// The OTLP exporter is configured through the standard OTEL_EXPORTER_OTLP_* variables.
+	Exporter string
+	// ServiceName identifies the spans of this application, OTEL_SERVICE_NAME takes precedence when set
+	ServiceName string
+	// SampleRatio is the fraction of new traces that are recorded, between 0 and 1. Calls continuing a
+	// trace follow the sampling decision of their parent.
+	SampleRatio float64
+}
+
+// Setup installs the global tracer provider and the W3C trace context propagator. The returned function
+// flushes pending spans and must be called on shutdown.
+func Setup(ctx context.Context, config Config) (func(context.Context) error, error) {
+	// The propagator is installed even without an exporter, so trace context still flows to downstream services
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch config.Exporter {
+	case ExporterOTLP:
+		exporter, err = otlptracegrpc.New(ctx)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterNone, "":
+		slog.InfoContext(ctx, "Tracing disabled")
+		return func(context.Context) error { return nil }, nil
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter %q, expected %s, %s or %s", config.Exporter, ExporterOTLP, ExporterStdout, ExporterNone)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s trace exporter: %w", config.Exporter, err)
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewSchemaless(semconv.ServiceName(config.ServiceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating tracing resource: %w", err)
+	}
+	// Environment attributes (OTEL_SERVICE_NAME, OTEL_RESOURCE_ATTRIBUTES) override the configured ones
+	res, err = resource.Merge(res, resource.Environment())
+	if err != nil {
+		return nil, fmt.Errorf("error creating tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	slog.InfoContext(ctx, "Tracing enabled", "exporter", config.Exporter, "sample_ratio", config.SampleRatio)
+	return provider.Shutdown, nil
+}
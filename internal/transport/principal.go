@@ -0,0 +1,52 @@
+package transport
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+)
+
+type principalContextKey struct{}
+
+// Principal identifies the peer of a request authenticated with a verified client certificate (mTLS)
+type Principal struct {
+	// Name is the certificate's first URI SAN (e.g. a SPIFFE ID), falling back to its first DNS SAN and then to its CN
+	Name        string
+	Certificate *x509.Certificate
+}
+
+// PrincipalFromContext returns the principal of the request, if the client presented a verified certificate
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(Principal)
+	return principal, ok
+}
+
+func ContextWithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// withPrincipal stores the principal in the request context, which grpcServer hands to the interceptors and handlers
+func withPrincipal(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+			certificate := r.TLS.VerifiedChains[0][0]
+			r = r.WithContext(ContextWithPrincipal(r.Context(), Principal{
+				Name:        principalName(certificate),
+				Certificate: certificate,
+			}))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func principalName(certificate *x509.Certificate) string {
+	if len(certificate.URIs) > 0 {
+		return certificate.URIs[0].String()
+	}
+	if len(certificate.DNSNames) > 0 {
+		return certificate.DNSNames[0]
+	}
+
+	return certificate.Subject.CommonName
+}
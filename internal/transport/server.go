@@ -0,0 +1,71 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	connectcors "connectrpc.com/cors"
+	"connectrpc.com/vanguard/vanguardgrpc"
+	"github.com/rs/cors"
+	"google.golang.org/grpc"
+)
+
+type Config struct {
+	// Address the server listens on, e.g. ":3000"
+	Address string
+	// CORSAllowedOrigins lists the browser origins allowed to call the API, CORS is disabled when empty
+	CORSAllowedOrigins []string
+	// CORSMaxAge is how long browsers may cache preflight responses
+	CORSMaxAge time.Duration
+	// TLS enables TLS (and mTLS, if configured) on the listener, connections are plaintext when nil
+	TLS TLS
+}
+
+// NewServer serves gRPC, gRPC-Web and the Connect protocol from a single listener. Every protocol is
+// translated to gRPC and handled by grpcServer, so its interceptors apply the same way to all of them.
+// Plaintext connections speak HTTP/1.1 and HTTP/2 with prior knowledge (h2c), which native gRPC clients need.
+// With TLS, HTTP/2 is negotiated through ALPN and the server must be started with ServeTLS(lis, "", "").
+//
+// All services must be registered in grpcServer before calling NewServer. The returned server must be
+// stopped with Shutdown, followed by grpcServer.Stop: GracefulStop isn't supported for handlers served over HTTP.
+func NewServer(grpcServer *grpc.Server, config Config) (*http.Server, error) {
+	transcoder, err := vanguardgrpc.NewTranscoder(grpcServer)
+	if err != nil {
+		return nil, fmt.Errorf("error creating protocol transcoder: %w", err)
+	}
+
+	var handler http.Handler = withPrincipal(transcoder)
+	if len(config.CORSAllowedOrigins) > 0 {
+		handler = newCORS(config).Handler(handler)
+	}
+
+	server := &http.Server{
+		Addr:              config.Address,
+		Handler:           handler,
+		Protocols:         new(http.Protocols),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	server.Protocols.SetHTTP1(true)
+	server.Protocols.SetHTTP2(true)
+
+	if config.TLS != nil {
+		server.TLSConfig = config.TLS.ServerConfig()
+	} else {
+		server.Protocols.SetUnencryptedHTTP2(true)
+	}
+
+	return server, nil
+}
+
+// Utilities
+func newCORS(config Config) *cors.Cors {
+	return cors.New(cors.Options{
+		AllowedOrigins: config.CORSAllowedOrigins,
+		AllowedMethods: connectcors.AllowedMethods(),
+		// Headers read by the interceptors must be allowed as well
+		AllowedHeaders: append(connectcors.AllowedHeaders(), "Accept-Language", "X-Request-Id", "X-Api-Key", "Traceparent", "Tracestate"),
+		ExposedHeaders: append(connectcors.ExposedHeaders(), "X-Request-Id"),
+		MaxAge:         int(config.CORSMaxAge.Seconds()),
+	})
+}
@@ -0,0 +1,258 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+type TLSConfig struct {
+	// CertFile and KeyFile hold the PEM encoded server certificate (with its intermediates) and private key
+	CertFile string
+	KeyFile  string
+	// MinVersion is the lowest accepted TLS version, "1.2" (default) or "1.3"
+	MinVersion string
+	// CipherSuites restricts the TLS 1.2 cipher suites by name, e.g. "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256".
+	// Go's secure defaults are used when empty. TLS 1.3 suites aren't configurable.
+	CipherSuites []string
+	// ClientCAFile is a PEM bundle of the CAs client certificates are verified against, mTLS is disabled when empty
+	ClientCAFile string
+	// RequireClientCert rejects connections without a client certificate. Otherwise one is only verified if presented,
+	// so browsers can still connect while services authenticate with their certificates.
+	RequireClientCert bool
+}
+
+// TLS provides the server's TLS configuration, picking up renewed certificates without a restart
+type TLS interface {
+	// ServerConfig returns the configuration for the listener. Each handshake uses the latest loaded certificates.
+	ServerConfig() *tls.Config
+	// Watch reloads the certificate, key and client CA files whenever they change, until ctx is done
+	Watch(ctx context.Context, interval time.Duration)
+}
+
+type certificateReloader struct {
+	config       TLSConfig
+	minVersion   uint16
+	cipherSuites []uint16
+	current      atomic.Pointer[tls.Config]
+	modTimes     map[string]time.Time
+}
+
+func NewTLS(ctx context.Context, config TLSConfig) (TLS, error) {
+	if config.CertFile == "" || config.KeyFile == "" {
+		return nil, fmt.Errorf("both a TLS certificate and key file are required")
+	}
+
+	minVersion, err := parseTLSVersion(config.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	cipherSuites, err := parseCipherSuites(config.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+
+	reloader := &certificateReloader{
+		config:       config,
+		minVersion:   minVersion,
+		cipherSuites: cipherSuites,
+	}
+
+	current, err := reloader.load()
+	if err != nil {
+		return nil, err
+	}
+	reloader.current.Store(current)
+	reloader.modTimes = reloader.currentModTimes()
+
+	slog.InfoContext(ctx, "TLS certificates loaded", "mtls", config.ClientCAFile != "", "min_version", tls.VersionName(minVersion))
+	return reloader, nil
+}
+
+func (r *certificateReloader) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:   r.minVersion,
+		CipherSuites: r.cipherSuites,
+		NextProtos:   []string{"h2", "http/1.1"},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &r.current.Load().Certificates[0], nil
+		},
+		// The client CAs are part of the config rather than the certificate, so the whole config is swapped per handshake
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.current.Load(), nil
+		},
+	}
+}
+
+func (r *certificateReloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.reloadIfChanged(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *certificateReloader) reloadIfChanged(ctx context.Context) {
+	modTimes := r.currentModTimes()
+
+	changed := false
+	for path, modTime := range modTimes {
+		if !modTime.Equal(r.modTimes[path]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	// Remember the new times even on failure, so a half-written pair is reported once instead of on every tick
+	r.modTimes = modTimes
+
+	current, err := r.load()
+	if err != nil {
+		slog.ErrorContext(ctx, "Error reloading TLS certificates, keeping previous ones", "error", err)
+		return
+	}
+	r.current.Store(current)
+
+	slog.InfoContext(ctx, "TLS certificates reloaded")
+}
+
+func (r *certificateReloader) load() (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading TLS certificate: %w", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   r.minVersion,
+		CipherSuites: r.cipherSuites,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+
+	if r.config.ClientCAFile != "" {
+		// #nosec G304
+		bundle, err := os.ReadFile(r.config.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading TLS client CA file: %w", err)
+		}
+
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("no certificates found in TLS client CA file %s", r.config.ClientCAFile)
+		}
+
+		config.ClientCAs = clientCAs
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		if r.config.RequireClientCert {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	return config, nil
+}
+
+func (r *certificateReloader) currentModTimes() map[string]time.Time {
+	modTimes := make(map[string]time.Time)
+	for _, path := range []string{r.config.CertFile, r.config.KeyFile, r.config.ClientCAFile} {
+		if path == "" {
+			continue
+		}
+
+		// A missing file gets the zero time, so it's picked up again once it's recreated
+		if info, err := os.Stat(path); err == nil {
+			modTimes[path] = info.ModTime()
+		} else {
+			modTimes[path] = time.Time{}
+		}
+	}
+
+	return modTimes
+}
+
+type ClientTLSConfig struct {
+	// CAFile is a PEM bundle used to verify the server, the system roots are used when empty
+	CAFile string
+	// CertFile and KeyFile are the client certificate presented when the server requires mTLS, optional otherwise
+	CertFile string
+	KeyFile  string
+}
+
+// ClientCredentials builds the transport credentials for in-process clients (such as the REST gateway) calling the TLS listener
+func ClientCredentials(config ClientTLSConfig) (credentials.TransportCredentials, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if config.CAFile != "" {
+		// #nosec G304
+		bundle, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading TLS CA file: %w", err)
+		}
+
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("no certificates found in TLS CA file %s", config.CAFile)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	if config.CertFile != "" || config.KeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading TLS client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// Utilities
+func parseTLSVersion(version string) (uint16, error) {
+	switch version {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS minimum version %q, expected 1.2 or 1.3", version)
+	}
+}
+
+func parseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	// Only the secure suites are accepted, tls.InsecureCipherSuites() are left out on purpose
+	available := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		available[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported TLS cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,217 @@
+package transport
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+}
+
+func newTestCertificate(t *testing.T, template *x509.Certificate, parent *testCertificate) testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	assert.NoError(t, err)
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	parentCertificate, parentKey := template, key
+	if parent != nil {
+		parentCertificate, parentKey = parent.certificate, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCertificate, &key.PublicKey, parentKey)
+	assert.NoError(t, err)
+
+	certificate, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	return testCertificate{certificate: certificate, key: key}
+}
+
+func (c testCertificate) write(t *testing.T, certFile, keyFile string) {
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.certificate.Raw})
+	assert.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+
+	if keyFile != "" {
+		keyDER, err := x509.MarshalECPrivateKey(c.key)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	}
+}
+
+func (c testCertificate) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.certificate.Raw}, PrivateKey: c.key}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+	server := newTestCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "server"},
+		DNSNames:    []string{"localhost"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, &ca)
+	spiffeID, _ := url.Parse("spiffe://example.org/billing")
+	clientWithURI := newTestCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "billing"},
+		URIs:        []*url.URL{spiffeID},
+		DNSNames:    []string{"billing.internal"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, &ca)
+	clientWithCN := newTestCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "reporting"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, &ca)
+	untrusted := newTestCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "intruder"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, nil)
+
+	config := TLSConfig{
+		CertFile:     filepath.Join(dir, "server.crt"),
+		KeyFile:      filepath.Join(dir, "server.key"),
+		ClientCAFile: filepath.Join(dir, "ca.crt"),
+	}
+	server.write(t, config.CertFile, config.KeyFile)
+	ca.write(t, config.ClientCAFile, "")
+
+	serverTLS, err := NewTLS(context.Background(), config)
+	assert.NoError(t, err)
+
+	var principal Principal
+	var hasPrincipal bool
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(
+		func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			principal, hasPrincipal = PrincipalFromContext(ctx)
+			return handler(ctx, req)
+		},
+	))
+	healthpb.RegisterHealthServer(grpcServer, grpchealth.NewServer())
+
+	apiServer, err := NewServer(grpcServer, Config{TLS: serverTLS})
+	assert.NoError(t, err)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go func() { _ = apiServer.ServeTLS(lis, "", "") }()
+	defer apiServer.Close()
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(ca.certificate)
+
+	mutualTLSTests := []struct {
+		name          string
+		certificate   *testCertificate
+		wantPrincipal string
+	}{
+		{
+			name:          "success case: Testing the URI SAN is used as the principal",
+			certificate:   &clientWithURI,
+			wantPrincipal: "spiffe://example.org/billing",
+		},
+		{
+			name:          "success case: Testing the CN is used as the principal when there are no SANs",
+			certificate:   &clientWithCN,
+			wantPrincipal: "reporting",
+		},
+		{
+			name: "success case: Testing clients without a certificate have no principal",
+		},
+		{
+			name:        "failure case: Testing certificates from an unknown CA don't yield a principal",
+			certificate: &untrusted,
+		},
+	}
+
+	for _, tt := range mutualTLSTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Logf("Running %s", tt.name)
+			principal, hasPrincipal = Principal{}, false
+
+			clientConfig := &tls.Config{RootCAs: rootCAs, ServerName: "localhost"}
+			if tt.certificate != nil {
+				clientConfig.Certificates = []tls.Certificate{tt.certificate.tlsCertificate()}
+			}
+
+			conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(clientConfig)))
+			assert.NoError(t, err)
+			defer conn.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantPrincipal != "", hasPrincipal)
+			assert.Equal(t, tt.wantPrincipal, principal.Name)
+		})
+	}
+}
+
+func TestCertificateReload(t *testing.T) {
+	dir := t.TempDir()
+	config := TLSConfig{
+		CertFile: filepath.Join(dir, "server.crt"),
+		KeyFile:  filepath.Join(dir, "server.key"),
+	}
+
+	first := newTestCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "first"}}, nil)
+	first.write(t, config.CertFile, config.KeyFile)
+
+	serverTLS, err := NewTLS(context.Background(), config)
+	assert.NoError(t, err)
+	reloader := serverTLS.(*certificateReloader)
+
+	servedCommonName := func() string {
+		certificate, err := serverTLS.ServerConfig().GetCertificate(&tls.ClientHelloInfo{})
+		assert.NoError(t, err)
+
+		leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+		assert.NoError(t, err)
+		return leaf.Subject.CommonName
+	}
+	assert.Equal(t, "first", servedCommonName())
+
+	t.Logf("Running failure case: Testing a broken key pair keeps the previous certificate")
+	assert.NoError(t, os.WriteFile(config.KeyFile, []byte("not a key"), 0o600))
+	reloader.modTimes = nil
+	reloader.reloadIfChanged(context.Background())
+	assert.Equal(t, "first", servedCommonName())
+
+	t.Logf("Running success case: Testing a renewed certificate is served without a restart")
+	second := newTestCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "second"}}, nil)
+	second.write(t, config.CertFile, config.KeyFile)
+	reloader.modTimes = nil
+	reloader.reloadIfChanged(context.Background())
+	assert.Equal(t, "second", servedCommonName())
+}
@@ -1,6 +1,7 @@
 package validation
 
 import (
+	"context"
 	"regexp"
 
 	"github.com/go-playground/validator/v10"
@@ -22,3 +23,21 @@ func passwordTagValidation(fl validator.FieldLevel) bool {
 
 	return hasSymbol.MatchString(password) && hasUpperCasedCharacter.MatchString(password) && hasLowerCasedCharacter.MatchString(password) && hasNumber.MatchString(password)
 }
+
+func notReservedTagValidation(policy UsernamePolicy) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		return !policy.IsReserved(fl.Field().String())
+	}
+}
+
+func notProfaneTagValidation(policy UsernamePolicy) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		return !policy.IsProfane(fl.Field().String())
+	}
+}
+
+func emailDomainTagValidation(policy EmailPolicy) validator.FuncCtx {
+	return func(ctx context.Context, fl validator.FieldLevel) bool {
+		return policy.Check(ctx, fl.Field().String()).Allowed
+	}
+}
@@ -0,0 +1,284 @@
+package validation
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//go:embed disposable_domains.txt
+var bundledDisposableDomains string
+
+// Reasons returned in an EmailDecision, ordered by precedence
+const (
+	EmailReasonTenantAllowed = "tenant_allowed"
+	EmailReasonTenantDenied  = "tenant_denied"
+	EmailReasonAllowed       = "allowed"
+	EmailReasonDenied        = "denied"
+	EmailReasonDisposable    = "disposable"
+	EmailReasonDefault       = "default"
+	EmailReasonInvalid       = "invalid"
+)
+
+type EmailPolicyConfig struct {
+	// AllowFile lists domains that are always accepted, even if they're denied or disposable
+	AllowFile string
+	// DenyFile lists domains that are always rejected
+	DenyFile string
+	// TenantOverridesFile is a JSON object of tenant to {"allow": [...], "deny": [...]}, taking precedence over the global lists
+	TenantOverridesFile string
+}
+
+type EmailDecision struct {
+	Allowed bool
+	Domain  string
+	Reason  string
+	// Rule is the list entry that decided the outcome, empty for EmailReasonDefault and EmailReasonInvalid
+	Rule string
+}
+
+// EmailPolicy decides which email domains may be used, backing the "email_domain" tag.
+// Lists are matched against the domain and its parent domains, so "mailinator.com" also covers "eu.mailinator.com".
+type EmailPolicy interface {
+	Check(ctx context.Context, email string) EmailDecision
+	// Watch reloads the list files whenever they change, until ctx is done
+	Watch(ctx context.Context, interval time.Duration)
+	// Reconfigure switches to other list files, keeping the current lists on error
+	Reconfigure(ctx context.Context, config EmailPolicyConfig) error
+}
+
+type tenantOverrides struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+type domainRules struct {
+	allow domainSet
+	deny  domainSet
+}
+
+type emailRules struct {
+	domainRules
+	disposable domainSet
+	tenants    map[string]domainRules
+}
+
+type emailPolicy struct {
+	rules atomic.Pointer[emailRules]
+	// mu guards the files being watched, which Reconfigure can change
+	mu       sync.Mutex
+	config   EmailPolicyConfig
+	modTimes map[string]time.Time
+}
+
+func NewEmailPolicy(ctx context.Context, config EmailPolicyConfig) (EmailPolicy, error) {
+	policy := &emailPolicy{
+		config: config,
+	}
+
+	rules, err := policy.load()
+	if err != nil {
+		return nil, err
+	}
+	policy.rules.Store(rules)
+	policy.modTimes = policy.currentModTimes()
+
+	slog.InfoContext(ctx, "Email policy loaded",
+		"allow", len(rules.allow), "deny", len(rules.deny), "disposable", len(rules.disposable), "tenants", len(rules.tenants))
+	return policy, nil
+}
+
+func (p *emailPolicy) Check(ctx context.Context, email string) EmailDecision {
+	_, domain, found := strings.Cut(email, "@")
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if !found || domain == "" {
+		return EmailDecision{Allowed: false, Reason: EmailReasonInvalid}
+	}
+
+	rules := p.rules.Load()
+
+	if tenant, ok := rules.tenants[TenantFromContext(ctx)]; ok {
+		if rule, ok := tenant.allow.match(domain); ok {
+			return EmailDecision{Allowed: true, Domain: domain, Reason: EmailReasonTenantAllowed, Rule: rule}
+		}
+		if rule, ok := tenant.deny.match(domain); ok {
+			return EmailDecision{Allowed: false, Domain: domain, Reason: EmailReasonTenantDenied, Rule: rule}
+		}
+	}
+
+	if rule, ok := rules.allow.match(domain); ok {
+		return EmailDecision{Allowed: true, Domain: domain, Reason: EmailReasonAllowed, Rule: rule}
+	}
+	if rule, ok := rules.deny.match(domain); ok {
+		return EmailDecision{Allowed: false, Domain: domain, Reason: EmailReasonDenied, Rule: rule}
+	}
+	if rule, ok := rules.disposable.match(domain); ok {
+		return EmailDecision{Allowed: false, Domain: domain, Reason: EmailReasonDisposable, Rule: rule}
+	}
+
+	return EmailDecision{Allowed: true, Domain: domain, Reason: EmailReasonDefault}
+}
+
+func (p *emailPolicy) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.reloadIfChanged(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *emailPolicy) Reconfigure(ctx context.Context, config EmailPolicyConfig) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	previous := p.config
+	p.config = config
+
+	rules, err := p.load()
+	if err != nil {
+		p.config = previous
+		return err
+	}
+	p.rules.Store(rules)
+	p.modTimes = p.currentModTimes()
+
+	slog.InfoContext(ctx, "Email policy reloaded",
+		"allow", len(rules.allow), "deny", len(rules.deny), "disposable", len(rules.disposable), "tenants", len(rules.tenants))
+	return nil
+}
+
+func (p *emailPolicy) reloadIfChanged(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	modTimes := p.currentModTimes()
+
+	changed := false
+	for path, modTime := range modTimes {
+		if !modTime.Equal(p.modTimes[path]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	// Remember the new times even on failure, so a broken file is reported once instead of on every tick
+	p.modTimes = modTimes
+
+	rules, err := p.load()
+	if err != nil {
+		slog.ErrorContext(ctx, "Error reloading email policy, keeping previous lists", "error", err)
+		return
+	}
+	p.rules.Store(rules)
+
+	slog.InfoContext(ctx, "Email policy reloaded",
+		"allow", len(rules.allow), "deny", len(rules.deny), "disposable", len(rules.disposable), "tenants", len(rules.tenants))
+}
+
+func (p *emailPolicy) load() (*emailRules, error) {
+	rules := &emailRules{
+		disposable: newDomainSet(strings.Split(bundledDisposableDomains, "\n")),
+		tenants:    make(map[string]domainRules),
+	}
+
+	if p.config.AllowFile != "" {
+		domains, err := readWordList(p.config.AllowFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading email allow file: %w", err)
+		}
+		rules.allow = newDomainSet(domains)
+	}
+
+	if p.config.DenyFile != "" {
+		domains, err := readWordList(p.config.DenyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading email deny file: %w", err)
+		}
+		rules.deny = newDomainSet(domains)
+	}
+
+	if p.config.TenantOverridesFile != "" {
+		// #nosec G304
+		content, err := os.ReadFile(p.config.TenantOverridesFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading email tenant overrides file: %w", err)
+		}
+
+		var overrides map[string]tenantOverrides
+		if err := json.Unmarshal(content, &overrides); err != nil {
+			return nil, fmt.Errorf("error parsing email tenant overrides file: %w", err)
+		}
+
+		for tenant, override := range overrides {
+			rules.tenants[tenant] = domainRules{
+				allow: newDomainSet(override.Allow),
+				deny:  newDomainSet(override.Deny),
+			}
+		}
+	}
+
+	return rules, nil
+}
+
+func (p *emailPolicy) currentModTimes() map[string]time.Time {
+	modTimes := make(map[string]time.Time)
+	for _, path := range []string{p.config.AllowFile, p.config.DenyFile, p.config.TenantOverridesFile} {
+		if path == "" {
+			continue
+		}
+
+		// A missing file gets the zero time, so it's picked up again once it's recreated
+		if info, err := os.Stat(path); err == nil {
+			modTimes[path] = info.ModTime()
+		} else {
+			modTimes[path] = time.Time{}
+		}
+	}
+
+	return modTimes
+}
+
+// domainSet holds lowercased domains, matched against a domain and each of its parents
+type domainSet map[string]struct{}
+
+func newDomainSet(domains []string) domainSet {
+	set := make(domainSet)
+	for _, domain := range domains {
+		domain, _, _ = strings.Cut(domain, "#")
+		domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if domain != "" {
+			set[domain] = struct{}{}
+		}
+	}
+
+	return set
+}
+
+func (s domainSet) match(domain string) (string, bool) {
+	for {
+		if _, ok := s[domain]; ok {
+			return domain, true
+		}
+
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			return "", false
+		}
+		domain = parent
+	}
+}
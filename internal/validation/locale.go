@@ -0,0 +1,61 @@
+package validation
+
+import (
+	"context"
+
+	"golang.org/x/text/language"
+)
+
+type Locale string
+
+const (
+	LocaleEnglish             Locale = "en"
+	LocaleBrazilianPortuguese Locale = "pt-BR"
+
+	// DefaultLocale is used whenever the client doesn't ask for a language we have a catalog for
+	DefaultLocale = LocaleEnglish
+)
+
+// The first tag is the fallback used by the matcher when nothing else matches
+var (
+	supportedLocales = []Locale{LocaleEnglish, LocaleBrazilianPortuguese}
+	localeMatcher    = language.NewMatcher([]language.Tag{
+		language.English,
+		language.BrazilianPortuguese,
+	})
+)
+
+type localeContextKey struct{}
+
+// ParseAcceptLanguage picks the best supported locale for an Accept-Language style header value
+func ParseAcceptLanguage(header string) Locale {
+	if header == "" {
+		return DefaultLocale
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(header)
+	if err != nil || len(tags) == 0 {
+		return DefaultLocale
+	}
+
+	_, index, confidence := localeMatcher.Match(tags...)
+	if confidence == language.No {
+		return DefaultLocale
+	}
+
+	return supportedLocales[index]
+}
+
+// ContextWithLocale returns a copy of ctx carrying the locale used for validation messages
+func ContextWithLocale(ctx context.Context, locale Locale) context.Context {
+	return context.WithValue(ctx, localeContextKey{}, locale)
+}
+
+// LocaleFromContext returns the locale stored in ctx, or DefaultLocale if there is none
+func LocaleFromContext(ctx context.Context) Locale {
+	if locale, ok := ctx.Value(localeContextKey{}).(Locale); ok && locale != "" {
+		return locale
+	}
+
+	return DefaultLocale
+}
@@ -0,0 +1,103 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Message templates are keyed by validation tag. Every template receives the field name as %[1]s,
+// the tag parameter as %[2]s and the tag itself as %[3]s, so translations can reorder or skip them freely.
+// Tags whose wording depends on the field being a number use the "<tag>.number" key.
+type messageCatalog map[string]string
+
+const defaultMessageKey = "default"
+
+var messageCatalogs = map[Locale]messageCatalog{
+	LocaleEnglish: {
+		"required":   "field '%[1]s' is required",
+		"email":      "field '%[1]s' must be a valid email address",
+		"min":        "field '%[1]s' must be at least %[2]s characters long",
+		"min.number": "field '%[1]s' must be at least %[2]s",
+		"max":        "field '%[1]s' must be at most %[2]s characters long",
+		"max.number": "field '%[1]s' must be at most %[2]s",
+		"password":   "field '%[1]s' must be at least 8 characters long and contain at least one uppercase letter, one lowercase letter, one number, and one special character",
+		"alphanum":   "field '%[1]s' must contain only alphanumeric characters",
+		"alpha":      "field '%[1]s' must contain only alphabetic characters",
+		"numeric":    "field '%[1]s' must be a valid number",
+		"len":        "field '%[1]s' must be exactly %[2]s characters long",
+		"oneof":      "field '%[1]s' must be one of [%[2]s]",
+		"datetime":   "field '%[1]s' must be a valid datetime in YYYY-MM-DD format",
+		"uuid":       "field '%[1]s' must be a valid UUID",
+		"http_url":   "field '%[1]s' must be a valid HTTP or HTTPS URL",
+
+		"alphanumunicode": "field '%[1]s' must contain only letters and numbers",
+		"not_reserved":    "field '%[1]s' is reserved and cannot be used",
+		"not_profane":     "field '%[1]s' contains offensive language",
+		"email_domain":    "field '%[1]s' uses an email domain that is not allowed",
+
+		defaultMessageKey: "field '%[1]s' failed validation for tag '%[3]s'",
+	},
+	LocaleBrazilianPortuguese: {
+		"required":   "o campo '%[1]s' é obrigatório",
+		"email":      "o campo '%[1]s' deve ser um endereço de e-mail válido",
+		"min":        "o campo '%[1]s' deve ter pelo menos %[2]s caracteres",
+		"min.number": "o campo '%[1]s' deve ser no mínimo %[2]s",
+		"max":        "o campo '%[1]s' deve ter no máximo %[2]s caracteres",
+		"max.number": "o campo '%[1]s' deve ser no máximo %[2]s",
+		"password":   "o campo '%[1]s' deve ter pelo menos 8 caracteres e conter pelo menos uma letra maiúscula, uma letra minúscula, um número e um caractere especial",
+		"alphanum":   "o campo '%[1]s' deve conter apenas caracteres alfanuméricos",
+		"alpha":      "o campo '%[1]s' deve conter apenas letras",
+		"numeric":    "o campo '%[1]s' deve ser um número válido",
+		"len":        "o campo '%[1]s' deve ter exatamente %[2]s caracteres",
+		"oneof":      "o campo '%[1]s' deve ser um dos valores [%[2]s]",
+		"datetime":   "o campo '%[1]s' deve ser uma data válida no formato AAAA-MM-DD",
+		"uuid":       "o campo '%[1]s' deve ser um UUID válido",
+		"http_url":   "o campo '%[1]s' deve ser uma URL HTTP ou HTTPS válida",
+
+		"alphanumunicode": "o campo '%[1]s' deve conter apenas letras e números",
+		"not_reserved":    "o campo '%[1]s' é reservado e não pode ser usado",
+		"not_profane":     "o campo '%[1]s' contém linguagem ofensiva",
+		"email_domain":    "o campo '%[1]s' usa um domínio de e-mail que não é permitido",
+
+		defaultMessageKey: "o campo '%[1]s' falhou na validação da regra '%[3]s'",
+	},
+}
+
+// translate builds the message for a failed field, falling back to the default locale and then to the generic message
+func translate(locale Locale, field string, err validator.FieldError) string {
+	key := err.Tag()
+	if isNumberKind(err.Kind()) {
+		if _, ok := messageCatalogs[DefaultLocale][key+".number"]; ok {
+			key += ".number"
+		}
+	}
+
+	template, ok := lookupMessage(locale, key)
+	if !ok {
+		template, _ = lookupMessage(locale, defaultMessageKey)
+	}
+
+	return fmt.Sprintf(template, field, err.Param(), err.Tag())
+}
+
+func lookupMessage(locale Locale, key string) (string, bool) {
+	if template, ok := messageCatalogs[locale][key]; ok {
+		return template, true
+	}
+
+	template, ok := messageCatalogs[DefaultLocale][key]
+	return template, ok
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
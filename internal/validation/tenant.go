@@ -0,0 +1,16 @@
+package validation
+
+import "context"
+
+type tenantContextKey struct{}
+
+// ContextWithTenant returns a copy of ctx carrying the tenant whose policy overrides apply to validation
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant stored in ctx, or an empty string if there is none
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenant
+}
@@ -0,0 +1,300 @@
+package validation
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultReservedUsernames are blocked unless the policy is configured with its own list
+var DefaultReservedUsernames = []string{
+	"admin", "administrator", "root", "system", "support", "help", "security", "staff", "moderator",
+	"owner", "official", "api", "www", "mail", "postmaster", "webmaster", "hostmaster", "noreply",
+	"billing", "abuse", "info", "null", "undefined", "anonymous",
+}
+
+//go:embed confusables.txt
+var bundledConfusables string
+
+type UsernamePolicyConfig struct {
+	// ReservedWords replaces DefaultReservedUsernames when not empty
+	ReservedWords []string
+	// ProfanityFile is an optional path to a file with one blocked word per line, '#' starts a comment. Words only
+	// match whole segments of a username (see segmentedSkeleton), unless written with a leading or trailing '*'
+	// (e.g. *word*), which lets them match inside a segment on that side.
+	ProfanityFile string
+}
+
+// UsernamePolicy decides which usernames may be registered. Reserved words and profanity are checked through
+// the "not_reserved" and "not_profane" tags, while Skeleton is used to detect look-alikes of existing usernames.
+type UsernamePolicy interface {
+	IsReserved(username string) bool
+	IsProfane(username string) bool
+	// Skeleton returns the UTS #39 skeleton of the username, two usernames are confusable when their skeletons are equal
+	Skeleton(username string) string
+	// Reconfigure replaces the reserved words and profanity list, keeping the current ones on error
+	Reconfigure(ctx context.Context, config UsernamePolicyConfig) error
+}
+
+type usernameLists struct {
+	reserved  map[string]struct{}
+	profanity []profaneWord
+}
+
+// profaneWord is an entry of the profanity list, anyStart and anyEnd being set by its leading and trailing '*'
+type profaneWord struct {
+	skeleton string
+	anyStart bool
+	anyEnd   bool
+}
+
+type usernamePolicy struct {
+	confusables map[rune]string
+	lists       atomic.Pointer[usernameLists]
+}
+
+func NewUsernamePolicy(ctx context.Context, config UsernamePolicyConfig) (UsernamePolicy, error) {
+	confusables, err := parseConfusables(strings.NewReader(bundledConfusables))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing bundled confusables: %w", err)
+	}
+
+	policy := &usernamePolicy{
+		confusables: confusables,
+	}
+
+	lists, err := policy.load(config)
+	if err != nil {
+		return nil, err
+	}
+	policy.lists.Store(lists)
+
+	slog.InfoContext(ctx, "Username policy loaded", "reserved", len(lists.reserved), "profanity", len(lists.profanity))
+	return policy, nil
+}
+
+func (p *usernamePolicy) IsReserved(username string) bool {
+	_, ok := p.lists.Load().reserved[p.Skeleton(username)]
+	return ok
+}
+
+func (p *usernamePolicy) IsProfane(username string) bool {
+	skeleton, boundaries := p.segmentedSkeleton(username)
+	for _, word := range p.lists.Load().profanity {
+		if word.matches(skeleton, boundaries) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *usernamePolicy) Reconfigure(ctx context.Context, config UsernamePolicyConfig) error {
+	lists, err := p.load(config)
+	if err != nil {
+		return err
+	}
+	p.lists.Store(lists)
+
+	slog.InfoContext(ctx, "Username policy reloaded", "reserved", len(lists.reserved), "profanity", len(lists.profanity))
+	return nil
+}
+
+func (p *usernamePolicy) load(config UsernamePolicyConfig) (*usernameLists, error) {
+	lists := &usernameLists{
+		reserved: make(map[string]struct{}),
+	}
+
+	reservedWords := config.ReservedWords
+	if len(reservedWords) == 0 {
+		reservedWords = DefaultReservedUsernames
+	}
+
+	for _, word := range reservedWords {
+		if word = strings.TrimSpace(word); word != "" {
+			lists.reserved[p.Skeleton(word)] = struct{}{}
+		}
+	}
+
+	if config.ProfanityFile != "" {
+		words, err := readWordList(config.ProfanityFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading profanity file: %w", err)
+		}
+
+		for _, word := range words {
+			entry := profaneWord{
+				anyStart: strings.HasPrefix(word, "*"),
+				anyEnd:   strings.HasSuffix(word, "*"),
+			}
+			if entry.skeleton = p.Skeleton(strings.Trim(word, "*")); entry.skeleton != "" {
+				lists.profanity = append(lists.profanity, entry)
+			}
+		}
+	}
+
+	return lists, nil
+}
+
+// Skeleton follows UTS #39 (NFD, map confusables, NFD) on the case folded username, since usernames are
+// compared case-insensitively. Some prototypes are capitals (0 → O), so the result is folded and mapped once more.
+func (p *usernamePolicy) Skeleton(username string) string {
+	skeleton := p.mapConfusables(strings.ToLower(norm.NFD.String(username)))
+	skeleton = p.mapConfusables(strings.ToLower(skeleton))
+
+	return norm.NFD.String(skeleton)
+}
+
+// segmentedSkeleton returns the skeleton of username along with the offsets in it where a segment of the username
+// starts or ends. Segments split where a lowercase letter is followed by an uppercase one (badWord), between letters
+// and digits (word99) and around any other character, so blocked words can be told apart from the words merely
+// containing them (the Scunthorpe problem). Digits amid letters do split segments, yet as they're only checked at the
+// ends of a match, look-alikes like w0rd still match word.
+func (p *usernamePolicy) segmentedSkeleton(username string) (string, map[int]bool) {
+	var builder strings.Builder
+	boundaries := map[int]bool{0: true}
+
+	var segment []rune
+	var previous rune
+	for _, r := range username {
+		if len(segment) > 0 && isSegmentBoundary(previous, r) {
+			builder.WriteString(p.Skeleton(string(segment)))
+			boundaries[builder.Len()] = true
+			segment = segment[:0]
+		}
+
+		segment = append(segment, r)
+		if !unicode.IsMark(r) {
+			previous = r
+		}
+	}
+	builder.WriteString(p.Skeleton(string(segment)))
+	boundaries[builder.Len()] = true
+
+	return builder.String(), boundaries
+}
+
+func (p *usernamePolicy) mapConfusables(s string) string {
+	var builder strings.Builder
+	builder.Grow(len(s))
+
+	for _, r := range s {
+		if target, ok := p.confusables[r]; ok {
+			builder.WriteString(target)
+			continue
+		}
+		builder.WriteRune(r)
+	}
+
+	return builder.String()
+}
+
+// Utilities
+func parseConfusables(r io.Reader) (map[rune]string, error) {
+	confusables := make(map[rune]string)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		columns := strings.Split(line, ";")
+		if len(columns) < 2 {
+			return nil, fmt.Errorf("malformed confusables line %q", scanner.Text())
+		}
+
+		source, err := parseCodePoints(columns[0])
+		if err != nil || utf8.RuneCountInString(source) != 1 {
+			return nil, fmt.Errorf("malformed confusables source in line %q", scanner.Text())
+		}
+
+		target, err := parseCodePoints(columns[1])
+		if err != nil {
+			return nil, fmt.Errorf("malformed confusables target in line %q", scanner.Text())
+		}
+
+		confusables[[]rune(source)[0]] = target
+	}
+
+	return confusables, scanner.Err()
+}
+
+// matches reports whether the word is in skeleton, starting and ending on boundaries unless it's allowed not to
+func (w profaneWord) matches(skeleton string, boundaries map[int]bool) bool {
+	for offset := 0; offset < len(skeleton); {
+		index := strings.Index(skeleton[offset:], w.skeleton)
+		if index < 0 {
+			return false
+		}
+
+		start := offset + index
+		if (w.anyStart || boundaries[start]) && (w.anyEnd || boundaries[start+len(w.skeleton)]) {
+			return true
+		}
+		offset = start + 1
+	}
+
+	return false
+}
+
+// isSegmentBoundary reports whether r starts a new segment after previous, combining marks never do
+func isSegmentBoundary(previous, r rune) bool {
+	switch {
+	case unicode.IsMark(r):
+		return false
+	case unicode.IsLower(previous) && unicode.IsUpper(r):
+		return true
+	case unicode.IsLetter(previous) && unicode.IsLetter(r):
+		return false
+	case unicode.IsDigit(previous) && unicode.IsDigit(r):
+		return false
+	default:
+		return true
+	}
+}
+
+func parseCodePoints(column string) (string, error) {
+	var builder strings.Builder
+	for _, field := range strings.Fields(column) {
+		codePoint, err := strconv.ParseUint(field, 16, 32)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteRune(rune(codePoint))
+	}
+
+	return builder.String(), nil
+}
+
+func readWordList(path string) ([]string, error) {
+	// #nosec G304
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if word := strings.TrimSpace(line); word != "" {
+			words = append(words, word)
+		}
+	}
+
+	return words, scanner.Err()
+}
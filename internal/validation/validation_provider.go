@@ -8,19 +8,37 @@ import (
 
 type ValidationError struct {
 	Errors []string
+	// Violations holds the same failures as Errors, broken down by field
+	Violations []Violation
+}
+
+type Violation struct {
+	Field   string
+	Tag     string
+	Message string
 }
 
 func (ve *ValidationError) Error() string {
 	return "validation error"
 }
 
-// DatabaseProvider is the abstraction for all validator use in the application
+// ValidationProvider is the abstraction for all validator use in the application.
+// Error messages are written in the locale carried by ctx (see ContextWithLocale).
 type ValidationProvider interface {
-	ValidateData(data any) *ValidationError
+	ValidateData(ctx context.Context, data any) *ValidationError
+	ValidateFields(ctx context.Context, fields []Field) *ValidationError
+}
+
+// Field is a single value validated against rules written in validator tag syntax, used when the rules
+// don't come from struct tags (e.g. the options declared in the proto schema)
+type Field struct {
+	Name  string
+	Value any
+	Rules string
 }
 
 // Creates a new Validate based ValidationProvider
-func NewValidateValidationrovider(ctx context.Context) ValidationProvider {
+func NewValidateValidationrovider(ctx context.Context, options ...Option) ValidationProvider {
 	validate := validator.New(validator.WithRequiredStructEnabled())
-	return New(ctx, validate)
+	return New(ctx, validate, options...)
 }
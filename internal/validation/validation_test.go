@@ -2,7 +2,10 @@ package validation
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
@@ -108,6 +111,7 @@ func TestStructValidation(t *testing.T) {
 	type arguments struct {
 		validate ValidateProvider
 		data     any
+		locale   Locale
 	}
 
 	structTests := []struct {
@@ -128,6 +132,7 @@ func TestStructValidation(t *testing.T) {
 					Email:    "testing@testing.com",
 					Password: "Testing123@123",
 				},
+				locale: LocaleEnglish,
 			},
 			want: []errorResponse{},
 		},
@@ -144,6 +149,7 @@ func TestStructValidation(t *testing.T) {
 					Email:    "testing",
 					Password: "test",
 				},
+				locale: LocaleEnglish,
 			},
 			want: []errorResponse{
 				{
@@ -166,14 +172,483 @@ func TestStructValidation(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "failure case: Testing struct validation errors using all utilized tags in the application in Brazilian Portuguese",
+			arguments: arguments{
+				validate: testValidator.validate,
+				data: struct {
+					Name     string `json:"name" validate:"required,min=3"`
+					Email    string `json:"email" validate:"required,email"`
+					Password string `json:"password" validate:"required,password"`
+				}{
+					Name:     "T",
+					Email:    "testing",
+					Password: "test",
+				},
+				locale: LocaleBrazilianPortuguese,
+			},
+			want: []errorResponse{
+				{
+					Error:        true,
+					FailedField:  "name",
+					Tag:          "min",
+					ErrorMessage: "o campo 'name' deve ter pelo menos 3 caracteres",
+				},
+				{
+					Error:        true,
+					FailedField:  "email",
+					Tag:          "email",
+					ErrorMessage: "o campo 'email' deve ser um endereço de e-mail válido",
+				},
+				{
+					Error:        true,
+					FailedField:  "password",
+					Tag:          "password",
+					ErrorMessage: "o campo 'password' deve ter pelo menos 8 caracteres e conter pelo menos uma letra maiúscula, uma letra minúscula, um número e um caractere especial",
+				},
+			},
+		},
+		{
+			name: "failure case: Testing numeric bounds and required fields in English",
+			arguments: arguments{
+				validate: testValidator.validate,
+				data: struct {
+					Username string `json:"username" validate:"required"`
+					Limit    int32  `json:"limit" validate:"min=0,max=100"`
+				}{
+					Limit: 101,
+				},
+				locale: LocaleEnglish,
+			},
+			want: []errorResponse{
+				{
+					Error:        true,
+					FailedField:  "username",
+					Tag:          "required",
+					ErrorMessage: "field 'username' is required",
+				},
+				{
+					Error:        true,
+					FailedField:  "limit",
+					Tag:          "max",
+					ErrorMessage: "field 'limit' must be at most 100",
+				},
+			},
+		},
+		{
+			name: "failure case: Testing numeric bounds and required fields in Brazilian Portuguese",
+			arguments: arguments{
+				validate: testValidator.validate,
+				data: struct {
+					Username string `json:"username" validate:"required"`
+					Limit    int32  `json:"limit" validate:"min=0,max=100"`
+				}{
+					Limit: 101,
+				},
+				locale: LocaleBrazilianPortuguese,
+			},
+			want: []errorResponse{
+				{
+					Error:        true,
+					FailedField:  "username",
+					Tag:          "required",
+					ErrorMessage: "o campo 'username' é obrigatório",
+				},
+				{
+					Error:        true,
+					FailedField:  "limit",
+					Tag:          "max",
+					ErrorMessage: "o campo 'limit' deve ser no máximo 100",
+				},
+			},
+		},
+		{
+			name: "failure case: Testing an unknown locale falls back to English",
+			arguments: arguments{
+				validate: testValidator.validate,
+				data: struct {
+					Email string `json:"email" validate:"required,email"`
+				}{
+					Email: "testing",
+				},
+				locale: Locale("fr"),
+			},
+			want: []errorResponse{
+				{
+					Error:        true,
+					FailedField:  "email",
+					Tag:          "email",
+					ErrorMessage: "field 'email' must be a valid email address",
+				},
+			},
+		},
 	}
 
 	for _, testCase := range structTests {
 		t.Run("", func(t *testing.T) {
 			t.Logf("Running structValidation %s\n", testCase.name)
-			response := structValidation(testCase.arguments.validate, testCase.arguments.data)
+			response := structValidation(ContextWithLocale(context.Background(), testCase.arguments.locale), testCase.arguments.validate, testCase.arguments.data)
 
 			assert.ElementsMatch(t, testCase.want, response, "error lists do not match")
 		})
 	}
 }
+
+func TestParseAcceptLanguage(t *testing.T) {
+	localeTests := []struct {
+		have string
+		want Locale
+		name string
+	}{
+		{
+			have: "",
+			want: LocaleEnglish,
+			name: "success case: Testing an empty header falls back to the default locale",
+		},
+		{
+			have: "pt-BR",
+			want: LocaleBrazilianPortuguese,
+			name: "success case: Testing an exact Brazilian Portuguese match",
+		},
+		{
+			have: "pt-BR,pt;q=0.9,en-US;q=0.8,en;q=0.7",
+			want: LocaleBrazilianPortuguese,
+			name: "success case: Testing a browser style header preferring Brazilian Portuguese",
+		},
+		{
+			have: "pt",
+			want: LocaleBrazilianPortuguese,
+			name: "success case: Testing a bare Portuguese tag matches Brazilian Portuguese",
+		},
+		{
+			have: "en-GB",
+			want: LocaleEnglish,
+			name: "success case: Testing a regional English tag matches English",
+		},
+		{
+			have: "en;q=0.5,pt-BR;q=0.9",
+			want: LocaleBrazilianPortuguese,
+			name: "success case: Testing quality values are respected",
+		},
+		{
+			have: "ja",
+			want: LocaleEnglish,
+			name: "failure case: Testing an unsupported language falls back to the default locale",
+		},
+		{
+			have: "@@invalid@@",
+			want: LocaleEnglish,
+			name: "failure case: Testing a malformed header falls back to the default locale",
+		},
+	}
+
+	for _, testCase := range localeTests {
+		t.Logf("Running ParseAcceptLanguage %s\n", testCase.name)
+		assert.Equal(t, testCase.want, ParseAcceptLanguage(testCase.have), "Unexpected locale for testCase: %v", testCase)
+	}
+}
+
+func TestMessageCatalogsAreComplete(t *testing.T) {
+	for locale, catalog := range messageCatalogs {
+		for key := range messageCatalogs[DefaultLocale] {
+			t.Logf("Running message catalog check for locale %s and key %s\n", locale, key)
+			assert.NotEmpty(t, catalog[key], "Missing message for key %s in locale %s", key, locale)
+		}
+	}
+}
+
+func TestFieldValidation(t *testing.T) {
+	type arguments struct {
+		fields []Field
+		locale Locale
+	}
+
+	fieldTests := []struct {
+		name      string
+		arguments arguments
+		want      []errorResponse
+	}{
+		{
+			name: "success case: Testing field validation with valid values and a field without rules",
+			arguments: arguments{
+				fields: []Field{
+					{Name: "email", Value: "testing@testing.com", Rules: "required,email"},
+					{Name: "limit", Value: int32(10), Rules: "min=0,max=100"},
+					{Name: "nickname", Value: ""},
+				},
+				locale: LocaleEnglish,
+			},
+			want: nil,
+		},
+		{
+			name: "failure case: Testing field validation errors keep the declared field names",
+			arguments: arguments{
+				fields: []Field{
+					{Name: "id", Value: "not-an-uuid", Rules: "required,uuid"},
+					{Name: "limit", Value: int32(-1), Rules: "min=0,max=100"},
+					{Name: "user_name", Value: "", Rules: "required,min=3"},
+				},
+				locale: LocaleEnglish,
+			},
+			want: []errorResponse{
+				{
+					Error:        true,
+					FailedField:  "id",
+					Tag:          "uuid",
+					ErrorMessage: "field 'id' must be a valid UUID",
+				},
+				{
+					Error:        true,
+					FailedField:  "limit",
+					Tag:          "min",
+					ErrorMessage: "field 'limit' must be at least 0",
+				},
+				{
+					Error:        true,
+					FailedField:  "user_name",
+					Tag:          "required",
+					ErrorMessage: "field 'user_name' is required",
+				},
+			},
+		},
+		{
+			name: "failure case: Testing field validation errors in Brazilian Portuguese",
+			arguments: arguments{
+				fields: []Field{
+					{Name: "password", Value: "test", Rules: "required,password"},
+				},
+				locale: LocaleBrazilianPortuguese,
+			},
+			want: []errorResponse{
+				{
+					Error:        true,
+					FailedField:  "password",
+					Tag:          "password",
+					ErrorMessage: "o campo 'password' deve ter pelo menos 8 caracteres e conter pelo menos uma letra maiúscula, uma letra minúscula, um número e um caractere especial",
+				},
+			},
+		},
+	}
+
+	for _, testCase := range fieldTests {
+		t.Run("", func(t *testing.T) {
+			t.Logf("Running fieldValidation %s\n", testCase.name)
+			response := fieldValidation(ContextWithLocale(context.Background(), testCase.arguments.locale), testValidator.validate, testCase.arguments.fields)
+
+			assert.ElementsMatch(t, testCase.want, response, "error lists do not match")
+		})
+	}
+}
+
+func TestUsernamePolicy(t *testing.T) {
+	profanityFile := filepath.Join(t.TempDir(), "profanity.txt")
+	err := os.WriteFile(profanityFile, []byte("# blocked words\nbadword\n\nworse  # trailing comment\n*cuss*\n"), 0600)
+	assert.NoError(t, err)
+
+	policy, err := NewUsernamePolicy(context.Background(), UsernamePolicyConfig{
+		ReservedWords: []string{"admin", " support "},
+		ProfanityFile: profanityFile,
+	})
+	assert.NoError(t, err)
+
+	reservedTests := []customValidationTests{
+		{have: "admin", want: true, name: "success case: Testing an exact reserved word"},
+		{have: "ADMIN", want: true, name: "success case: Testing a reserved word in a different case"},
+		{have: "аdmin", want: true, name: "success case: Testing a reserved word with a Cyrillic homoglyph"},
+		{have: "support", want: true, name: "success case: Testing a reserved word configured with surrounding spaces"},
+		{have: "root", want: false, name: "failure case: Testing a default reserved word replaced by the configured list"},
+		{have: "administrator", want: false, name: "failure case: Testing a word that only contains a reserved word"},
+	}
+
+	for _, testCase := range reservedTests {
+		t.Logf("Running IsReserved %s\n", testCase.name)
+		assert.Equal(t, testCase.want, policy.IsReserved(testCase.have.(string)), "Unexpected result for testCase: %v", testCase)
+	}
+
+	profanityTests := []customValidationTests{
+		{have: "badword", want: true, name: "success case: Testing an exact blocked word"},
+		{have: "xxBadWord99", want: true, name: "success case: Testing a blocked word between segments of a username"},
+		{have: "w0rse", want: true, name: "success case: Testing a blocked word with a digit homoglyph"},
+		{have: "w0rse1", want: true, name: "success case: Testing a blocked word with a digit homoglyph followed by digits"},
+		{have: "discussion", want: true, name: "success case: Testing a word opted into matching inside other words"},
+		{have: "goodword", want: false, name: "failure case: Testing a username without blocked words"},
+		{have: "xxbadword99", want: false, name: "failure case: Testing a blocked word inside a segment of a username"},
+		{have: "worsen", want: false, name: "failure case: Testing a word only starting with a blocked word"},
+	}
+
+	for _, testCase := range profanityTests {
+		t.Logf("Running IsProfane %s\n", testCase.name)
+		assert.Equal(t, testCase.want, policy.IsProfane(testCase.have.(string)), "Unexpected result for testCase: %v", testCase)
+	}
+
+	skeletonTests := []struct {
+		have [2]string
+		want bool
+		name string
+	}{
+		{have: [2]string{"paypal", "paypa1"}, want: true, name: "success case: Testing the digit 1 confused with a lowercase l"},
+		{have: [2]string{"google", "g00gle"}, want: true, name: "success case: Testing zeros confused with the letter o"},
+		{have: [2]string{"modern", "rnodern"}, want: true, name: "success case: Testing rn confused with m"},
+		{have: [2]string{"apple", "аррlе"}, want: true, name: "success case: Testing a mixed-script Cyrillic look-alike"},
+		{have: [2]string{"alice", "ＡＬＩＣＥ"}, want: true, name: "success case: Testing fullwidth letters"},
+		{have: [2]string{"alice", "bob"}, want: false, name: "failure case: Testing unrelated usernames"},
+		{have: [2]string{"admin", "adm1n"}, want: false, name: "failure case: Testing i and 1, which UTS #39 does not consider confusable"},
+	}
+
+	for _, testCase := range skeletonTests {
+		t.Logf("Running Skeleton %s\n", testCase.name)
+		got := policy.Skeleton(testCase.have[0]) == policy.Skeleton(testCase.have[1])
+		assert.Equal(t, testCase.want, got, "Unexpected result for testCase: %v", testCase)
+	}
+
+	_, err = NewUsernamePolicy(context.Background(), UsernamePolicyConfig{ProfanityFile: filepath.Join(t.TempDir(), "missing.txt")})
+	assert.Error(t, err, "Expected error for a missing profanity file")
+}
+
+func TestUsernamePolicyTags(t *testing.T) {
+	usernameTests := []customValidationTests{
+		{have: "johndoe", want: true, name: "success case: Testing a regular username against the default policy"},
+		{have: "admin", want: false, name: "failure case: Testing a default reserved username"},
+		{have: "R00T", want: false, name: "failure case: Testing a default reserved username with homoglyphs"},
+	}
+
+	for _, testCase := range usernameTests {
+		t.Logf("Running not_reserved,not_profane %s\n", testCase.name)
+		err := testValidator.validate.Var(testCase.have, "not_reserved,not_profane")
+
+		if testCase.want {
+			assert.NoError(t, err, "Unexpected error for testCase: %v", testCase)
+		} else {
+			assert.Error(t, err, "Expected error for testCase: %v", testCase)
+		}
+	}
+}
+
+func TestUsernameCharacters(t *testing.T) {
+	usernameTests := []customValidationTests{
+		{have: "johndoe99", want: true, name: "success case: Testing an ASCII username"},
+		{have: "аррlе", want: true, name: "success case: Testing a Cyrillic username, left to the confusable check"},
+		{have: "ΑΒΓ", want: true, name: "success case: Testing a Greek username"},
+		{have: "john_doe", want: false, name: "failure case: Testing a username with punctuation"},
+		{have: "john doe", want: false, name: "failure case: Testing a username with a space"},
+	}
+
+	for _, testCase := range usernameTests {
+		t.Logf("Running alphanumunicode %s\n", testCase.name)
+		err := testValidator.validate.Var(testCase.have, "alphanumunicode")
+
+		if testCase.want {
+			assert.NoError(t, err, "Unexpected error for testCase: %v", testCase)
+		} else {
+			assert.Error(t, err, "Expected error for testCase: %v", testCase)
+		}
+	}
+}
+
+func TestEmailPolicy(t *testing.T) {
+	directory := t.TempDir()
+	allowFile := filepath.Join(directory, "allow.txt")
+	denyFile := filepath.Join(directory, "deny.txt")
+	tenantsFile := filepath.Join(directory, "tenants.json")
+
+	assert.NoError(t, os.WriteFile(allowFile, []byte("# partners\nyopmail.com\n"), 0600))
+	assert.NoError(t, os.WriteFile(denyFile, []byte("spam.example\n"), 0600))
+	assert.NoError(t, os.WriteFile(tenantsFile, []byte(`{"acme": {"allow": ["spam.example"], "deny": ["gmail.com"]}}`), 0600))
+
+	ctx := context.Background()
+	policy, err := NewEmailPolicy(ctx, EmailPolicyConfig{
+		AllowFile:           allowFile,
+		DenyFile:            denyFile,
+		TenantOverridesFile: tenantsFile,
+	})
+	assert.NoError(t, err)
+
+	emailTests := []struct {
+		tenant string
+		have   string
+		want   EmailDecision
+		name   string
+	}{
+		{
+			have: "john@gmail.com",
+			want: EmailDecision{Allowed: true, Domain: "gmail.com", Reason: EmailReasonDefault},
+			name: "success case: Testing a domain that isn't in any list",
+		},
+		{
+			have: "john@Mailinator.com",
+			want: EmailDecision{Allowed: false, Domain: "mailinator.com", Reason: EmailReasonDisposable, Rule: "mailinator.com"},
+			name: "failure case: Testing a bundled disposable domain in a different case",
+		},
+		{
+			have: "john@eu.mailinator.com",
+			want: EmailDecision{Allowed: false, Domain: "eu.mailinator.com", Reason: EmailReasonDisposable, Rule: "mailinator.com"},
+			name: "failure case: Testing a subdomain of a bundled disposable domain",
+		},
+		{
+			have: "john@yopmail.com",
+			want: EmailDecision{Allowed: true, Domain: "yopmail.com", Reason: EmailReasonAllowed, Rule: "yopmail.com"},
+			name: "success case: Testing the allow list overrides the disposable list",
+		},
+		{
+			have: "john@spam.example",
+			want: EmailDecision{Allowed: false, Domain: "spam.example", Reason: EmailReasonDenied, Rule: "spam.example"},
+			name: "failure case: Testing a domain in the deny list",
+		},
+		{
+			tenant: "acme",
+			have:   "john@spam.example",
+			want:   EmailDecision{Allowed: true, Domain: "spam.example", Reason: EmailReasonTenantAllowed, Rule: "spam.example"},
+			name:   "success case: Testing a tenant allow override takes precedence over the deny list",
+		},
+		{
+			tenant: "acme",
+			have:   "john@gmail.com",
+			want:   EmailDecision{Allowed: false, Domain: "gmail.com", Reason: EmailReasonTenantDenied, Rule: "gmail.com"},
+			name:   "failure case: Testing a tenant deny override",
+		},
+		{
+			have: "not-an-email",
+			want: EmailDecision{Allowed: false, Reason: EmailReasonInvalid},
+			name: "failure case: Testing an address without a domain",
+		},
+	}
+
+	for _, testCase := range emailTests {
+		t.Logf("Running EmailPolicy.Check %s\n", testCase.name)
+		got := policy.Check(ContextWithTenant(ctx, testCase.tenant), testCase.have)
+		assert.Equal(t, testCase.want, got, "Unexpected decision for testCase: %v", testCase)
+	}
+
+	t.Log("Running EmailPolicy reload after the deny list changes")
+	assert.NoError(t, os.WriteFile(denyFile, []byte("gmail.com\n"), 0600))
+	assert.NoError(t, os.Chtimes(denyFile, time.Now(), time.Now().Add(time.Minute)))
+	policy.(*emailPolicy).reloadIfChanged(ctx)
+
+	assert.False(t, policy.Check(ctx, "john@gmail.com").Allowed, "Expected the reloaded deny list to block gmail.com")
+	assert.True(t, policy.Check(ctx, "john@spam.example").Allowed, "Expected the reloaded deny list to allow spam.example")
+
+	t.Log("Running EmailPolicy reload with a broken tenant overrides file keeps the previous lists")
+	assert.NoError(t, os.WriteFile(tenantsFile, []byte(`{broken`), 0600))
+	assert.NoError(t, os.Chtimes(tenantsFile, time.Now(), time.Now().Add(2*time.Minute)))
+	policy.(*emailPolicy).reloadIfChanged(ctx)
+
+	assert.Equal(t, EmailReasonTenantDenied, policy.Check(ContextWithTenant(ctx, "acme"), "john@gmail.com").Reason)
+}
+
+func TestEmailDomainTag(t *testing.T) {
+	emailTests := []customValidationTests{
+		{have: "john@example.com", want: true, name: "success case: Testing a regular domain against the default policy"},
+		{have: "john@guerrillamail.com", want: false, name: "failure case: Testing a bundled disposable domain"},
+	}
+
+	for _, testCase := range emailTests {
+		t.Logf("Running email_domain %s\n", testCase.name)
+		err := testValidator.validate.VarCtx(context.Background(), testCase.have, "email_domain")
+
+		if testCase.want {
+			assert.NoError(t, err, "Unexpected error for testCase: %v", testCase)
+		} else {
+			assert.Error(t, err, "Expected error for testCase: %v", testCase)
+		}
+	}
+}
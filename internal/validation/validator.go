@@ -2,10 +2,8 @@ package validation
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
 	"os"
-	"reflect"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
@@ -13,12 +11,33 @@ import (
 
 type ValidateProvider interface {
 	RegisterValidation(tag string, fn validator.Func, callValidationEvenIfNull ...bool) error
+	RegisterValidationCtx(tag string, fn validator.FuncCtx, callValidationEvenIfNull ...bool) error
 	Struct(s any) error
+	StructCtx(ctx context.Context, s any) error
 	Var(field any, tag string) error
+	VarCtx(ctx context.Context, field any, tag string) error
 }
 
 type Validator struct {
-	validate ValidateProvider
+	validate       ValidateProvider
+	usernamePolicy UsernamePolicy
+	emailPolicy    EmailPolicy
+}
+
+type Option func(*Validator)
+
+// WithEmailPolicy replaces the default email policy backing the "email_domain" tag
+func WithEmailPolicy(policy EmailPolicy) Option {
+	return func(v *Validator) {
+		v.emailPolicy = policy
+	}
+}
+
+// WithUsernamePolicy replaces the default username policy backing the "not_reserved" and "not_profane" tags
+func WithUsernamePolicy(policy UsernamePolicy) Option {
+	return func(v *Validator) {
+		v.usernamePolicy = policy
+	}
 }
 
 type errorResponse struct {
@@ -28,38 +47,70 @@ type errorResponse struct {
 	ErrorMessage string
 }
 
-func New(ctx context.Context, validate ValidateProvider) ValidationProvider {
+func New(ctx context.Context, validate ValidateProvider, options ...Option) ValidationProvider {
+	v := &Validator{
+		validate: validate,
+	}
+
+	for _, option := range options {
+		option(v)
+	}
+
+	if v.usernamePolicy == nil {
+		policy, err := NewUsernamePolicy(ctx, UsernamePolicyConfig{})
+		if err != nil {
+			slog.ErrorContext(ctx, "Error creating default username policy", "error", err)
+			os.Exit(1)
+		}
+		v.usernamePolicy = policy
+	}
+
+	if v.emailPolicy == nil {
+		policy, err := NewEmailPolicy(ctx, EmailPolicyConfig{})
+		if err != nil {
+			slog.ErrorContext(ctx, "Error creating default email policy", "error", err)
+			os.Exit(1)
+		}
+		v.emailPolicy = policy
+	}
+
 	if err := validate.RegisterValidation("password", passwordTagValidation); err != nil {
 		slog.ErrorContext(ctx, "Error registering passwordTagValidation", "error", err)
 		os.Exit(1)
 	}
 
-	return &Validator{
-		validate,
+	if err := validate.RegisterValidation("not_reserved", notReservedTagValidation(v.usernamePolicy)); err != nil {
+		slog.ErrorContext(ctx, "Error registering notReservedTagValidation", "error", err)
+		os.Exit(1)
 	}
-}
 
-func (v *Validator) ValidateData(data any) *ValidationError {
-	if errors := structValidation(v.validate, data); len(errors) > 0 && errors[0].Error {
-		var errorMessages []string
-
-		for _, err := range errors {
-			errorMessages = append(errorMessages, err.ErrorMessage)
-		}
+	if err := validate.RegisterValidation("not_profane", notProfaneTagValidation(v.usernamePolicy)); err != nil {
+		slog.ErrorContext(ctx, "Error registering notProfaneTagValidation", "error", err)
+		os.Exit(1)
+	}
 
-		return &ValidationError{
-			Errors: errorMessages,
-		}
+	if err := validate.RegisterValidationCtx("email_domain", emailDomainTagValidation(v.emailPolicy)); err != nil {
+		slog.ErrorContext(ctx, "Error registering emailDomainTagValidation", "error", err)
+		os.Exit(1)
 	}
 
-	return nil
+	return v
+}
+
+func (v *Validator) ValidateData(ctx context.Context, data any) *ValidationError {
+	return newValidationError(structValidation(ctx, v.validate, data))
+}
+
+func (v *Validator) ValidateFields(ctx context.Context, fields []Field) *ValidationError {
+	return newValidationError(fieldValidation(ctx, v.validate, fields))
 }
 
 // Utilities
-func structValidation(validate ValidateProvider, data any) []errorResponse {
+func structValidation(ctx context.Context, validate ValidateProvider, data any) []errorResponse {
 	var validationErrors []errorResponse
+	locale := LocaleFromContext(ctx)
 
-	errors := validate.Struct(data)
+	errors := validate.StructCtx(ctx, data)
 	if errors != nil {
 		for _, err := range errors.(validator.ValidationErrors) {
 			var errResp errorResponse
@@ -69,40 +120,7 @@ func structValidation(validate ValidateProvider, data any) []errorResponse {
 
 			errResp.FailedField = strings.ToLower(err.Field())
 
-			switch err.Tag() {
-			case "required":
-				errResp.ErrorMessage = fmt.Sprintf("field '%s' is required", errResp.FailedField)
-			case "email":
-				errResp.ErrorMessage = "field 'email' must be a valid email address"
-			case "min":
-				if err.Kind() == reflect.Int || err.Kind() == reflect.Float64 {
-					errResp.ErrorMessage = fmt.Sprintf("field '%s' must be at least %s", errResp.FailedField, err.Param())
-				} else {
-					errResp.ErrorMessage = fmt.Sprintf("field '%s' must be at least %s characters long", errResp.FailedField, err.Param())
-				}
-			case "max":
-				if err.Kind() == reflect.Int || err.Kind() == reflect.Float64 {
-					errResp.ErrorMessage = fmt.Sprintf("field '%s' must be at most %s", errResp.FailedField, err.Param())
-				} else {
-					errResp.ErrorMessage = fmt.Sprintf("field '%s' must be at most %s characters long", errResp.FailedField, err.Param())
-				}
-			case "password":
-				errResp.ErrorMessage = "field 'password' must be at least 8 characters long and contain at least one uppercase letter, one lowercase letter, one number, and one special character"
-			case "alphanum":
-				errResp.ErrorMessage = fmt.Sprintf("field '%s' must contain only alphanumeric characters", errResp.FailedField)
-			case "alpha":
-				errResp.ErrorMessage = fmt.Sprintf("field '%s' must contain only alphabetic characters", errResp.FailedField)
-			case "numeric":
-				errResp.ErrorMessage = fmt.Sprintf("field '%s' must be a valid number", errResp.FailedField)
-			case "len":
-				errResp.ErrorMessage = fmt.Sprintf("field '%s' must be exactly %s characters long", errResp.FailedField, err.Param())
-			case "oneof":
-				errResp.ErrorMessage = fmt.Sprintf("field '%s' must be one of [%s]", errResp.FailedField, err.Param())
-			case "datetime":
-				errResp.ErrorMessage = fmt.Sprintf("field '%s' must be a valid datetime in YYYY-MM-DD format", errResp.FailedField)
-			default:
-				errResp.ErrorMessage = fmt.Sprintf("field '%s' failed validation for tag '%s'", errResp.FailedField, err.Tag())
-			}
+			errResp.ErrorMessage = translate(locale, errResp.FailedField, err)
 
 			validationErrors = append(validationErrors, errResp)
 		}
@@ -110,3 +128,52 @@ func structValidation(validate ValidateProvider, data any) []errorResponse {
 
 	return validationErrors
 }
+
+func fieldValidation(ctx context.Context, validate ValidateProvider, fields []Field) []errorResponse {
+	var validationErrors []errorResponse
+	locale := LocaleFromContext(ctx)
+
+	for _, field := range fields {
+		if field.Rules == "" {
+			continue
+		}
+
+		errors := validate.VarCtx(ctx, field.Value, field.Rules)
+		if errors == nil {
+			continue
+		}
+
+		for _, err := range errors.(validator.ValidationErrors) {
+			validationErrors = append(validationErrors, errorResponse{
+				Error:        true,
+				FailedField:  field.Name,
+				Tag:          err.Tag(),
+				ErrorMessage: translate(locale, field.Name, err),
+			})
+		}
+	}
+
+	return validationErrors
+}
+
+func newValidationError(errors []errorResponse) *ValidationError {
+	if len(errors) == 0 || !errors[0].Error {
+		return nil
+	}
+
+	var errorMessages []string
+	var violations []Violation
+	for _, err := range errors {
+		errorMessages = append(errorMessages, err.ErrorMessage)
+		violations = append(violations, Violation{
+			Field:   err.FailedField,
+			Tag:     err.Tag,
+			Message: err.ErrorMessage,
+		})
+	}
+
+	return &ValidationError{
+		Errors:     errorMessages,
+		Violations: violations,
+	}
+}
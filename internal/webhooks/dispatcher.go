@@ -0,0 +1,236 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vinofsteel/grpc-management/internal/database"
+)
+
+type DispatcherConfig struct {
+	// PollInterval is how often due deliveries are checked for once there are none left
+	PollInterval time.Duration
+	// BatchSize is how many deliveries are sent at the same time
+	BatchSize int32
+	// Timeout bounds each attempt, 10 seconds when 0
+	Timeout time.Duration
+	// MaxAttempts is how many attempts a delivery gets before it's dead
+	MaxAttempts int32
+	// MinBackoff and MaxBackoff bound the wait before a failed delivery is retried, doubling with every attempt
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// AllowPrivateTargets lets deliveries reach loopback, private and other non-public addresses, for tests only
+	AllowPrivateTargets bool
+}
+
+type Dispatcher struct {
+	queries database.Queries
+	client  *http.Client
+	config  DispatcherConfig
+	// lease is how long a claimed delivery is reserved, outlasting an attempt
+	lease time.Duration
+	now   func() time.Time
+}
+
+// NewDispatcher sends the deliveries recorded in queries. Several dispatchers (e.g. one per server) can share them,
+// each delivery being claimed by a single one at a time. Redirects aren't followed, any status other than 2xx
+// failing the attempt, and connections to non-public addresses are refused (see ErrForbiddenTarget).
+func NewDispatcher(queries database.Queries, config DispatcherConfig) *Dispatcher {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 10
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = time.Second
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		config.MaxBackoff = max(time.Hour, config.MinBackoff)
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !config.AllowPrivateTargets {
+		dialer.Control = controlTarget
+	}
+	// No proxy from the environment either, it would be the one connecting to the target
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Dispatcher{
+		queries: queries,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   config.Timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		config: config,
+		lease:  2*config.Timeout + time.Minute,
+		now:    time.Now,
+	}
+}
+
+// Run sends deliveries until ctx is done
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Full batches mean more deliveries are due
+		for {
+			claimed, err := d.dispatchBatch(ctx)
+			if err != nil || claimed < int(d.config.BatchSize) {
+				break
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatchBatch sends the deliveries of a claimed batch concurrently, returning how many were claimed
+func (d *Dispatcher) dispatchBatch(ctx context.Context) (int, error) {
+	now := d.timestamp()
+	deliveries, err := d.queries.ClaimWebhookDeliveries(ctx, database.ClaimWebhookDeliveriesParams{
+		Now:        now,
+		LeaseUntil: now.Add(d.lease),
+		Limit:      d.config.BatchSize,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error claiming webhook deliveries", "error", err)
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.deliver(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery *database.WebhookDelivery) {
+	subscription, err := d.queries.ListWebhookSubscriptionById(ctx, database.ListWebhookSubscriptionByIdParams{ID: delivery.SubscriptionID})
+	// Deleted since, along with the delivery
+	if errors.Is(err, sql.ErrNoRows) {
+		return
+	}
+	// Tried again once the lease expires
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing webhook subscription", "error", err, "subscription_id", delivery.SubscriptionID)
+		return
+	}
+
+	start := time.Now()
+	responseCode, err := d.send(ctx, subscription, delivery)
+	latency := time.Since(start)
+
+	// Recorded even if ctx is done, the attempt having been made or not
+	ctx = context.WithoutCancel(ctx)
+
+	attempt := database.RecordWebhookDeliveryAttemptParams{
+		ID:            delivery.ID,
+		Status:        database.WebhookDeliveryDelivered,
+		ResponseCode:  responseCode,
+		LatencyMs:     latency.Milliseconds(),
+		AttemptedAt:   d.timestamp(),
+		NextAttemptAt: d.timestamp(),
+	}
+	logAttrs := []any{"delivery_id", delivery.ID, "subscription_id", delivery.SubscriptionID, "event_type", delivery.EventType, "attempts", delivery.Attempts + 1}
+
+	switch {
+	case err == nil:
+		slog.InfoContext(ctx, "Delivered webhook", append(logAttrs, "response_code", responseCode.Int32, "latency", latency)...)
+	case delivery.Attempts+1 >= d.config.MaxAttempts:
+		attempt.Status = database.WebhookDeliveryDead
+		attempt.Error = sql.NullString{String: err.Error(), Valid: true}
+		slog.ErrorContext(ctx, "Webhook delivery is dead after its last attempt", append(logAttrs, "error", err)...)
+	default:
+		backoff := d.backoff(delivery.Attempts)
+		attempt.Status = database.WebhookDeliveryFailed
+		attempt.Error = sql.NullString{String: err.Error(), Valid: true}
+		attempt.NextAttemptAt = attempt.AttemptedAt.Add(backoff)
+		slog.WarnContext(ctx, "Error delivering webhook", append(logAttrs, "error", err, "retry_in", backoff)...)
+	}
+
+	// Should this fail, the delivery is attempted again once its lease expires
+	if err := d.queries.RecordWebhookDeliveryAttempt(ctx, attempt); err != nil {
+		slog.ErrorContext(ctx, "Error recording webhook delivery attempt", "error", err, "delivery_id", delivery.ID)
+	}
+}
+
+// send POSTs the signed delivery, returning the response code, null when there was no response
+func (d *Dispatcher) send(ctx context.Context, subscription *database.WebhookSubscription, delivery *database.WebhookDelivery) (sql.NullInt32, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return sql.NullInt32{}, err
+	}
+
+	timestamp := d.now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Id", delivery.EventID.String())
+	req.Header.Set("X-Event-Type", delivery.EventType)
+	req.Header.Set(DeliveryIDHeader, delivery.ID.String())
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(SignatureHeader, Sign(subscription.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return sql.NullInt32{}, err
+	}
+	defer resp.Body.Close()
+
+	// Drained so the connection is reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	responseCode := sql.NullInt32{Int32: int32(resp.StatusCode), Valid: true}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return responseCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return responseCode, nil
+}
+
+// Utilities
+// backoff is the wait before retrying a delivery that failed after attempts previous attempts
+func (d *Dispatcher) backoff(attempts int32) time.Duration {
+	backoff := d.config.MinBackoff
+	for range attempts {
+		backoff *= 2
+		if backoff >= d.config.MaxBackoff {
+			return d.config.MaxBackoff
+		}
+	}
+
+	return backoff
+}
+
+// timestamp is in UTC with microsecond precision, as written by the repositories
+func (d *Dispatcher) timestamp() time.Time {
+	return d.now().UTC().Truncate(time.Microsecond)
+}
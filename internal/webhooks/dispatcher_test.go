@@ -0,0 +1,189 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/vinofsteel/grpc-management/internal/database"
+	"github.com/vinofsteel/grpc-management/internal/database/memory"
+	"github.com/vinofsteel/grpc-management/internal/events/proto_events"
+	"github.com/vinofsteel/grpc-management/internal/outbox"
+)
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+	receiver := newTestReceiver(t)
+	q := memory.NewMemoryQueries()
+	dispatcher := NewDispatcher(q, DispatcherConfig{
+		MaxAttempts:         2,
+		MinBackoff:          time.Second,
+		MaxBackoff:          time.Minute,
+		AllowPrivateTargets: true,
+	})
+	now := time.Now()
+	dispatcher.now = func() time.Time { return now }
+	publisher := &publisher{queries: q, now: func() time.Time { return now }}
+
+	created := insertSubscription(t, ctx, q, receiver.URL, outbox.EventUserCreated)
+	insertSubscription(t, ctx, q, receiver.URL, outbox.EventUserDeleted)
+
+	t.Logf("Running success case: Testing events are delivered signed to the subscriptions of their type")
+	event := &proto_events.Event{Id: uuid.NewString(), Type: outbox.EventUserCreated, Version: 1, UserId: uuid.NewString()}
+	assert.NoError(t, publisher.Publish(ctx, event))
+	// Published again by the relay, still delivered once
+	assert.NoError(t, publisher.Publish(ctx, event))
+
+	claimed, err := dispatcher.dispatchBatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, claimed)
+
+	requests := receiver.requests()
+	if assert.Len(t, requests, 1) {
+		assert.NoError(t, Verify("0123456789abcdef", requests[0].header, requests[0].body, 5*time.Minute, now))
+		assert.Equal(t, event.Id, requests[0].header.Get("X-Event-Id"))
+		assert.JSONEq(t, `{"id":"`+event.Id+`","type":"user.created","version":1,"userId":"`+event.UserId+`"}`, string(requests[0].body))
+	}
+
+	deliveries, err := q.ListWebhookDeliveries(ctx, database.ListWebhookDeliveriesParams{SubscriptionID: created.ID})
+	assert.NoError(t, err)
+	if assert.Len(t, deliveries, 1) {
+		assert.Equal(t, database.WebhookDeliveryDelivered, deliveries[0].Status)
+		assert.Equal(t, int32(http.StatusOK), deliveries[0].ResponseCode.Int32)
+		assert.True(t, deliveries[0].LatencyMs.Valid)
+	}
+
+	t.Logf("Running failure case: Testing failed deliveries are retried with backoff, then dead")
+	receiver.status.Store(http.StatusServiceUnavailable)
+	assert.NoError(t, publisher.Publish(ctx, &proto_events.Event{Id: uuid.NewString(), Type: outbox.EventUserCreated}))
+
+	_, err = dispatcher.dispatchBatch(ctx)
+	assert.NoError(t, err)
+	failed := deliveryWithStatus(t, ctx, q, created.ID, database.WebhookDeliveryFailed)
+	assert.Equal(t, int32(http.StatusServiceUnavailable), failed.ResponseCode.Int32)
+	assert.Equal(t, "webhook responded with status 503", failed.LastError.String)
+
+	claimed, err = dispatcher.dispatchBatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, claimed, "not retried before its backoff")
+
+	now = now.Add(time.Second)
+	claimed, err = dispatcher.dispatchBatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, claimed)
+	dead := deliveryWithStatus(t, ctx, q, created.ID, database.WebhookDeliveryDead)
+	assert.Equal(t, int32(2), dead.Attempts)
+
+	now = now.Add(time.Hour)
+	claimed, err = dispatcher.dispatchBatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, claimed, "dead deliveries aren't retried")
+
+	t.Logf("Running success case: Testing a redelivered dead delivery is sent again")
+	receiver.status.Store(http.StatusOK)
+	_, err = q.RedeliverWebhookDelivery(ctx, database.RedeliverWebhookDeliveryParams{ID: dead.ID, Now: dispatcher.timestamp()})
+	assert.NoError(t, err)
+
+	claimed, err = dispatcher.dispatchBatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, claimed)
+	redelivered, err := q.ListWebhookDeliveryById(ctx, database.ListWebhookDeliveryByIdParams{ID: dead.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, database.WebhookDeliveryDelivered, redelivered.Status)
+	assert.Equal(t, int32(1), redelivered.Attempts)
+	assert.Len(t, receiver.requests(), 4)
+}
+
+func TestDispatcherPrivateTargets(t *testing.T) {
+	ctx := context.Background()
+	receiver := newTestReceiver(t)
+	q := memory.NewMemoryQueries()
+	dispatcher := NewDispatcher(q, DispatcherConfig{MaxAttempts: 2})
+	publisher := &publisher{queries: q, now: dispatcher.timestamp}
+
+	t.Logf("Running failure case: Testing deliveries to a loopback address are refused when connecting")
+	subscription := insertSubscription(t, ctx, q, receiver.URL, outbox.EventUserCreated)
+	assert.NoError(t, publisher.Publish(ctx, &proto_events.Event{Id: uuid.NewString(), Type: outbox.EventUserCreated}))
+
+	_, err := dispatcher.dispatchBatch(ctx)
+	assert.NoError(t, err)
+	failed := deliveryWithStatus(t, ctx, q, subscription.ID, database.WebhookDeliveryFailed)
+	assert.Contains(t, failed.LastError.String, ErrForbiddenTarget.Error())
+	assert.Empty(t, receiver.requests())
+}
+
+// testReceiver is a webhook endpoint recording the deliveries it gets, answering them with status
+type testReceiver struct {
+	*httptest.Server
+	status atomic.Int64
+
+	mu       sync.Mutex
+	received []receivedRequest
+}
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func (r *testReceiver) requests() []receivedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]receivedRequest(nil), r.received...)
+}
+
+// Utilities
+func newTestReceiver(t *testing.T) *testReceiver {
+	t.Helper()
+
+	receiver := &testReceiver{}
+	receiver.status.Store(http.StatusOK)
+	receiver.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		receiver.mu.Lock()
+		receiver.received = append(receiver.received, receivedRequest{header: r.Header, body: body})
+		receiver.mu.Unlock()
+
+		w.WriteHeader(int(receiver.status.Load()))
+	}))
+	t.Cleanup(receiver.Close)
+
+	return receiver
+}
+
+func insertSubscription(t *testing.T, ctx context.Context, q database.Queries, url, eventType string) *database.WebhookSubscription {
+	t.Helper()
+
+	subscription, err := q.InsertWebhookSubscription(ctx, database.InsertWebhookSubscriptionParams{
+		ID:         uuid.New(),
+		URL:        url,
+		EventTypes: database.EventTypes{eventType},
+		Secret:     "0123456789abcdef",
+		CreatedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("error inserting webhook subscription: %v", err)
+	}
+
+	return subscription
+}
+
+func deliveryWithStatus(t *testing.T, ctx context.Context, q database.Queries, subscriptionID uuid.UUID, status string) *database.WebhookDelivery {
+	t.Helper()
+
+	deliveries, err := q.ListWebhookDeliveries(ctx, database.ListWebhookDeliveriesParams{SubscriptionID: subscriptionID, Status: status})
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("expected a single %s delivery, got %d (error: %v)", status, len(deliveries), err)
+	}
+
+	return deliveries[0]
+}
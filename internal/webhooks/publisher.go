@@ -0,0 +1,64 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vinofsteel/grpc-management/internal/database"
+	"github.com/vinofsteel/grpc-management/internal/events/proto_events"
+	"github.com/vinofsteel/grpc-management/internal/outbox"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+type publisher struct {
+	queries database.Queries
+	now     func() time.Time
+}
+
+// NewPublisher is the outbox publisher turning every event into a delivery to each subscription of its type, sent
+// later by the Dispatcher. An event published again gets no new deliveries.
+func NewPublisher(queries database.Queries) outbox.Publisher {
+	return &publisher{queries: queries, now: time.Now}
+}
+
+func (p *publisher) Publish(ctx context.Context, event *proto_events.Event) error {
+	eventID, err := uuid.Parse(event.Id)
+	if err != nil {
+		return fmt.Errorf("invalid event ID %q: %w", event.Id, err)
+	}
+
+	payload, err := protojson.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	createdAt := p.now().UTC().Truncate(time.Microsecond)
+	return p.queries.WithTx(ctx, func(tx database.Queries) error {
+		subscriptions, err := tx.ListWebhookSubscriptions(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, subscription := range subscriptions {
+			if !slices.Contains(subscription.EventTypes, event.Type) {
+				continue
+			}
+
+			if err := tx.InsertWebhookDelivery(ctx, database.InsertWebhookDeliveryParams{
+				ID:             uuid.New(),
+				SubscriptionID: subscription.ID,
+				EventID:        eventID,
+				EventType:      event.Type,
+				Payload:        payload,
+				CreatedAt:      createdAt,
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
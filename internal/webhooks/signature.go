@@ -0,0 +1,66 @@
+// Package webhooks delivers the user events to the webhook subscriptions that asked for them. Every event relayed
+// from the outbox becomes a delivery per matching subscription, which the dispatcher POSTs signed with the secret of
+// the subscription, retrying failures with backoff until they're delivered or dead-lettered.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of a delivery, besides X-Event-Id and X-Event-Type. The signature is the hex HMAC-SHA256, keyed by the
+// secret of the subscription, of the timestamp (Unix seconds), a dot and the body, prefixed with "sha256=". The
+// timestamp being signed, receivers can reject old deliveries being replayed.
+const (
+	SignatureHeader  = "X-Webhook-Signature"
+	TimestampHeader  = "X-Webhook-Timestamp"
+	DeliveryIDHeader = "X-Webhook-Delivery-Id"
+
+	signaturePrefix = "sha256="
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrExpiredTimestamp = errors.New("webhook timestamp outside the tolerance")
+)
+
+// Sign returns the value of the signature header of a delivery of body sent at timestamp
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	return signaturePrefix + hex.EncodeToString(mac(secret, strconv.FormatInt(timestamp.Unix(), 10), body))
+}
+
+// Verify checks the signature and timestamp headers of a delivery received at now, as receivers should. Timestamps
+// further than tolerance from now are rejected.
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration, now time.Time) error {
+	timestamp := header.Get(TimestampHeader)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if now.Sub(time.Unix(seconds, 0)).Abs() > tolerance {
+		return ErrExpiredTimestamp
+	}
+
+	signature, err := hex.DecodeString(strings.TrimPrefix(header.Get(SignatureHeader), signaturePrefix))
+	if err != nil || !hmac.Equal(signature, mac(secret, timestamp, body)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// Utilities
+func mac(secret, timestamp string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
@@ -0,0 +1,42 @@
+package webhooks
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"id":"event"}`)
+
+	verifyTests := []struct {
+		name      string
+		secret    string
+		timestamp time.Time
+		body      []byte
+		wantErr   error
+	}{
+		{name: "success case: Testing a signed delivery is verified", secret: "secret", timestamp: now, body: body},
+		{name: "success case: Testing a delivery within the tolerance is verified", secret: "secret", timestamp: now.Add(-4 * time.Minute), body: body},
+		{name: "failure case: Testing another secret is rejected", secret: "other", timestamp: now, body: body, wantErr: ErrInvalidSignature},
+		{name: "failure case: Testing a tampered body is rejected", secret: "secret", timestamp: now, body: []byte(`{"id":"other"}`), wantErr: ErrInvalidSignature},
+		{name: "failure case: Testing an old delivery is rejected", secret: "secret", timestamp: now.Add(-10 * time.Minute), body: body, wantErr: ErrExpiredTimestamp},
+	}
+
+	for _, tt := range verifyTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Logf("Running %s", tt.name)
+
+			header := http.Header{}
+			header.Set(TimestampHeader, strconv.FormatInt(tt.timestamp.Unix(), 10))
+			header.Set(SignatureHeader, Sign("secret", tt.timestamp, body))
+
+			err := Verify(tt.secret, header, tt.body, 5*time.Minute, now)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"syscall"
+)
+
+// ErrForbiddenTarget is returned for webhook URLs resolving to addresses that aren't publicly routable, which would
+// let subscribers reach internal services (e.g. databases or the cloud metadata endpoint) through the dispatcher
+var ErrForbiddenTarget = errors.New("webhook target is not a public address")
+
+// Special purpose ranges net/netip doesn't classify, per the IANA special-purpose address registries
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001::/23"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+// CheckTarget rejects webhook URLs whose host is, or resolves to, an address that isn't public. Hosts that can't be
+// resolved yet are accepted, the dispatcher checking every address it connects to anyway.
+func CheckTarget(ctx context.Context, rawURL string) error {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	host := target.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return checkAddress(addr)
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if err := checkAddress(addr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Utilities
+// controlTarget runs before every connection of the dispatcher with the address DNS resolved to, so hosts re-pointed
+// to internal addresses after their subscription was created (DNS rebinding) are still refused
+func controlTarget(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+
+	return checkAddress(addrPort.Addr())
+}
+
+func checkAddress(addr netip.Addr) error {
+	if !isPublicAddress(addr) {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, addr)
+	}
+
+	return nil
+}
+
+// isPublicAddress excludes loopback, private, link-local, multicast, unspecified and special purpose addresses
+func isPublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,64 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckTarget(t *testing.T) {
+	targetTests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{
+			name: "success case: Testing a public address is accepted",
+			url:  "https://93.184.215.14/webhooks",
+		},
+		{
+			name:    "failure case: Testing a loopback address is rejected",
+			url:     "http://127.0.0.1:8080/webhooks",
+			wantErr: true,
+		},
+		{
+			name:    "failure case: Testing localhost is rejected once resolved",
+			url:     "http://localhost/webhooks",
+			wantErr: true,
+		},
+		{
+			name:    "failure case: Testing a private address is rejected",
+			url:     "http://10.0.0.5/webhooks",
+			wantErr: true,
+		},
+		{
+			name:    "failure case: Testing the link-local metadata endpoint is rejected",
+			url:     "http://169.254.169.254/latest/meta-data",
+			wantErr: true,
+		},
+		{
+			name:    "failure case: Testing an IPv4-mapped loopback address is rejected",
+			url:     "http://[::ffff:127.0.0.1]/webhooks",
+			wantErr: true,
+		},
+		{
+			name:    "failure case: Testing a shared address space (CGNAT) address is rejected",
+			url:     "http://100.64.0.1/webhooks",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range targetTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Logf("Running %s", tt.name)
+
+			err := CheckTarget(context.Background(), tt.url)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrForbiddenTarget)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
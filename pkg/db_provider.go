@@ -2,12 +2,49 @@ package pkg
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
+// Bounds of the delay between connection attempts in WaitForConnection
+const (
+	initialRetryDelay = 250 * time.Millisecond
+	maxRetryDelay     = 10 * time.Second
+)
+
 // DBProvider is the abstraction for all database use in the application
 type DBProvider interface {
 	GetConnection(ctx context.Context) (*sqlx.DB, error)
 	Close() error
 }
+
+// WaitForConnection retries provider.GetConnection until it succeeds or timeout passes, so the server can start
+// alongside its database. Attempts back off exponentially with jitter, so restarting replicas don't retry in lockstep.
+func WaitForConnection(ctx context.Context, provider DBProvider, timeout time.Duration) (*sqlx.DB, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	delay := initialRetryDelay
+	for attempt := 1; ; attempt++ {
+		db, err := provider.GetConnection(ctx)
+		if err == nil {
+			return db, nil
+		}
+
+		// Half the delay is fixed and half random
+		wait := delay/2 + rand.N(delay/2+1)
+		slog.WarnContext(ctx, "Database unreachable, retrying", "attempt", attempt, "retry_in", wait, "error", err)
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("database still unreachable after %d attempts: %w", attempt, err)
+		}
+
+		delay = min(2*delay, maxRetryDelay)
+	}
+}
@@ -0,0 +1,70 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type flakyProvider struct {
+	failures int
+	attempts int
+}
+
+func (p *flakyProvider) GetConnection(ctx context.Context) (*sqlx.DB, error) {
+	p.attempts++
+	if p.attempts <= p.failures {
+		return nil, errors.New("connection refused")
+	}
+
+	return &sqlx.DB{}, nil
+}
+
+func (p *flakyProvider) Close() error {
+	return nil
+}
+
+func TestWaitForConnection(t *testing.T) {
+	waitTests := []struct {
+		name         string
+		failures     int
+		timeout      time.Duration
+		wantErr      bool
+		wantAttempts int
+	}{
+		{
+			name:         "success case: Testing the connection is returned once the database is up",
+			failures:     2,
+			timeout:      5 * time.Second,
+			wantAttempts: 3,
+		},
+		{
+			name:     "failure case: Testing retries stop after the timeout",
+			failures: 1000,
+			timeout:  300 * time.Millisecond,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range waitTests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Logf("Running %s", tt.name)
+
+			provider := &flakyProvider{failures: tt.failures}
+			db, err := WaitForConnection(context.Background(), provider, tt.timeout)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, db)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotNil(t, db)
+			assert.Equal(t, tt.wantAttempts, provider.attempts)
+		})
+	}
+}
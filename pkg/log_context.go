@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type logAttrsContextKey struct{}
+
+// ContextWithLogAttrs adds attributes to every record logged with ctx (through the *Context slog functions),
+// such as the request ID set by the interceptors
+func ContextWithLogAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(logAttrsContextKey{}).([]slog.Attr)
+
+	// Copied, so contexts derived from the same parent don't share the backing array
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	merged = append(merged, existing...)
+	merged = append(merged, attrs...)
+
+	return context.WithValue(ctx, logAttrsContextKey{}, merged)
+}
+
+// contextHandler adds the attributes stored with ContextWithLogAttrs to each record, along with
+// the trace and span IDs of the span active in the context
+type contextHandler struct {
+	slog.Handler
+}
+
+func newContextHandler(handler slog.Handler) slog.Handler {
+	return contextHandler{Handler: handler}
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs, ok := ctx.Value(logAttrsContextKey{}).([]slog.Attr); ok {
+		record.AddAttrs(attrs...)
+	}
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -7,14 +7,15 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"time"
 )
 
-func SetupSlog(ctx context.Context) {
-	env := os.Getenv("ENV")
+// logLevel is shared by every handler, so the level can be changed while the server runs
+var logLevel = new(slog.LevelVar)
 
+// SetupSlog configures the default logger for env. level overrides the environment's default level when set.
+func SetupSlog(ctx context.Context, env, level string) {
 	logsDir := "logs"
 	// #nosec G301
 	if err := os.MkdirAll(logsDir, 0755); err != nil {
@@ -36,13 +37,18 @@ func SetupSlog(ctx context.Context) {
 	// Create a multi-writer that writes to both stdout and the log file
 	multiWriter := io.MultiWriter(os.Stdout, logFile)
 
+	if err := SetLogLevel(env, level); err != nil {
+		fmt.Printf("Error parsing log level: %v\n", err)
+		os.Exit(1)
+	}
+
 	var handler slog.Handler
 
 	switch env {
 	case "production":
 		// Use JSON format in production for better parsing by log aggregation tools
 		handler = slog.NewJSONHandler(multiWriter, &slog.HandlerOptions{
-			Level: slog.LevelInfo,
+			Level: logLevel,
 			// Add timestamp with consistent ISO8601 format
 			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
 				if a.Key == slog.TimeKey {
@@ -54,18 +60,18 @@ func SetupSlog(ctx context.Context) {
 	case "test":
 		// Minimal logging in test environment
 		handler = slog.NewTextHandler(multiWriter, &slog.HandlerOptions{
-			Level: slog.LevelWarn,
+			Level: logLevel,
 		})
 	default:
 		// Development environment with more verbose logging
 		handler = slog.NewTextHandler(multiWriter, &slog.HandlerOptions{
-			Level:     slog.LevelDebug,
+			Level:     logLevel,
 			AddSource: true, // Include source file and line in logs for development
 		})
 	}
 
-	// Replace the default slog logger
-	logger := slog.New(handler)
+	// Replace the default slog logger, adding the attributes carried by the context (e.g. request IDs)
+	logger := slog.New(newContextHandler(handler))
 	slog.SetDefault(logger)
 
 	// Log the initial configuration
@@ -76,17 +82,31 @@ func SetupSlog(ctx context.Context) {
 	)
 }
 
+// SetLogLevel changes the level of the default logger. Each environment has its own default level,
+// replaced by level when set.
+func SetLogLevel(env, level string) error {
+	if level != "" {
+		return logLevel.UnmarshalText([]byte(level))
+	}
+
+	switch env {
+	case "production":
+		logLevel.Set(slog.LevelInfo)
+	case "test":
+		logLevel.Set(slog.LevelWarn)
+	default:
+		logLevel.Set(slog.LevelDebug)
+	}
+
+	return nil
+}
+
 func ScheduleLogRotation(ctx context.Context, days int) {
-	// Get retention period from env or use default
+	// Use the given retention period or the default
 	retentionDays := 7
-	if days != 0 {
+	if days > 0 {
 		retentionDays = days
 	}
-	if retentionStr := os.Getenv("LOG_RETENTION_DAYS"); retentionStr != "" {
-		if days, err := strconv.Atoi(retentionStr); err == nil && days > 0 {
-			retentionDays = days
-		}
-	}
 
 	slog.InfoContext(ctx, "Setting up log rotation", "retentionDays", retentionDays)
 
@@ -1,26 +1,23 @@
 #!/bin/sh
 
-# Logic to wait until database 
-MAX_TRIES=30
-TRIES=0
+# The application waits for the database on its own (PG_CONNECT_TIMEOUT), retrying with backoff
 
-until pg_isready -h $PGHOST -p $PGPORT -U $PGUSER || [ $TRIES -eq $MAX_TRIES ]; 
-    do echo "Verifying if database is up... $TRIES/$MAX_TRIES"
-        TRIES=$((TRIES+1))
-        sleep 2
-done
-
-if [ $TRIES -eq $MAX_TRIES ]; then 
-    echo "Database is down after $MAX_TRIES attempts, exiting..."
-    exit 1
-fi
+if [ "$ENV" != "production" ]; then 
+    # Checking whether the database exists needs the server up though, so wait for it a little while
+    MAX_TRIES=15
+    TRIES=0
 
-echo "Database is up."
+    until pg_isready -h $PGHOST -p $PGPORT -U $PGUSER || [ $TRIES -eq $MAX_TRIES ];
+        do echo "Verifying if database is up... $TRIES/$MAX_TRIES"
+            TRIES=$((TRIES+1))
+            sleep 2
+    done
 
-if [ "$ENV" != "production" ]; then 
     DB_EXISTS=$(psql -h $PGHOST -p $PGPORT -U $PGUSER -tAc "SELECT 1 FROM pg_database WHERE datname='$PGDATABASE'")
 
-    if [ "$DB_EXISTS" != "1" ]; then
+    if [ $? -ne 0 ]; then
+        echo "Database is not reachable yet, skipping creation check."
+    elif [ "$DB_EXISTS" != "1" ]; then
         echo "Database $PGDATABASE does not exist. Creating it..."
         createdb -h $PGHOST -p $PGPORT -U $PGUSER $PGDATABASE
     else
@@ -30,11 +27,9 @@ fi
 
 # Start the application
 if [ "$ENV" = "production" ]; then
-  # We only run migrations automatically on prod, so that we can write migrations without them running on every reload in development
-  echo "Running migrations to $PGDATABASE db..."
-  goose -dir internal/database/migrations postgres "$PG_CONN_STRING" up
-
-  make build && exec ./luso-wiki
+  # We only run migrations automatically on prod, so that we can write migrations without them running on every reload in development.
+  # They're embedded in the binary and applied under an advisory lock, so replicas starting together don't race
+  make build && PG_AUTO_MIGRATE=true exec ./luso-wiki
 else
   exec air
 fi